@@ -0,0 +1,220 @@
+package writer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AuditChainMode 审计哈希链的适用范围
+type AuditChainMode string
+
+const (
+	// AuditChainAudit 只为 log_type 为 audit 的日志建立哈希链
+	AuditChainAudit AuditChainMode = "audit"
+	// AuditChainAll 为所有经缓冲区写入的日志建立哈希链
+	AuditChainAll AuditChainMode = "all"
+)
+
+// auditLogType 审计日志的 log_type
+const auditLogType = "audit"
+
+// ErrAuditChainBroken VerifyAuditChain 发现哈希不符、断链或分叉时返回（与具体问题的错误一起用 errors.Join 合并）
+var ErrAuditChainBroken = errors.New("audit chain broken")
+
+// chainedLocked 判断条目是否需要加入哈希链
+func (w *PostgresqlWriter) chainedLocked(entry LogEntry) bool {
+	switch w.auditChain {
+	case AuditChainAll:
+		return true
+	case AuditChainAudit:
+		return entry.LogType == auditLogType
+	default:
+		return false
+	}
+}
+
+// chainEntryLocked 在已持有 bufferMux 的情况下将条目链接到哈希链末尾：prev_hash 为上一条的 hash，hash 覆盖条目内容和 prev_hash
+func (w *PostgresqlWriter) chainEntryLocked(entry *LogEntry) {
+	if entry.Timestamp == "" {
+		// 哈希需要覆盖时间，不能交给数据库默认值生成
+		entry.Timestamp = time.Now().Format(timestampLayout)
+	}
+	entry.PrevHash = w.auditPrev
+	entry.Hash = w.auditHash(*entry)
+	w.auditPrev = entry.Hash
+}
+
+// auditHash 计算条目的哈希：对写入数据库后能原样读回的规范化内容（时间精确到微秒、fields 按存储类型规范化）做 SHA-256
+func (w *PostgresqlWriter) auditHash(entry LogEntry) string {
+	entry = w.storedEntry(entry)
+	payload := struct {
+		Timestamp string `json:"ts"`
+		Level     string `json:"level"`
+		Content   string `json:"content"`
+		LogType   string `json:"log_type"`
+		Duration  string `json:"duration"`
+		Trace     string `json:"trace"`
+		Span      string `json:"span"`
+		UserID    *int64 `json:"user_id"`
+		Username  string `json:"username"`
+		Component string `json:"component"`
+		EntryID   string `json:"entry_id"`
+		ExpiresAt string `json:"expires_at"`
+		Fields    any    `json:"fields"`
+		PrevHash  string `json:"prev_hash"`
+	}{
+		Timestamp: canonicalAuditTime(entry.Timestamp),
+		Level:     entry.Level,
+		Content:   entry.Content,
+		LogType:   entry.LogType,
+		Duration:  entry.Duration,
+		Trace:     entry.Trace,
+		Span:      entry.Span,
+		UserID:    entry.UserID,
+		Username:  entry.Username,
+		Component: entry.Component,
+		EntryID:   entry.EntryID,
+		ExpiresAt: canonicalAuditTime(entry.ExpiresAt),
+		Fields:    w.canonicalAuditFields(entry.Fields),
+		PrevHash:  entry.PrevHash,
+	}
+	data, _ := json.Marshal(payload)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// canonicalAuditTime 将时间规范化为 UTC、微秒精度（PostgreSQL 时间类型的精度），解析失败时原样返回
+func canonicalAuditTime(s string) string {
+	if s == "" {
+		return ""
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return s
+	}
+	return t.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)
+}
+
+// canonicalAuditFields 将 fields 规范化为从数据库读回后的形式：hstore 中所有值都是字符串，JSON 存储经过一次编码和解码
+func (w *PostgresqlWriter) canonicalAuditFields(fields map[string]interface{}) any {
+	if len(fields) == 0 {
+		return nil
+	}
+	if w.fieldStorage == FieldStorageHstore {
+		values := make(map[string]string, len(fields))
+		for k, v := range fields {
+			values[k] = formatFieldValue(v)
+		}
+		return values
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil
+	}
+	return decoded
+}
+
+// auditRowsSource 返回所有日志表中带 hash 的行（UNION ALL），用于查找链尾和校验
+func (w *PostgresqlWriter) auditRowsSource(columns string) string {
+	parts := make([]string, 0, 2)
+	for _, table := range w.tables() {
+		parts = append(parts, fmt.Sprintf("SELECT %s FROM %s WHERE hash IS NOT NULL", columns, table))
+	}
+	return strings.Join(parts, " UNION ALL ")
+}
+
+// loadAuditTip 读取已持久化的链尾（没有后继的 hash），重启后从此处继续链接
+// 数据库执行器未实现 DBQuerier 时从空链开始
+func (w *PostgresqlWriter) loadAuditTip(ctx context.Context) error {
+	querier, ok := w.db.(DBQuerier)
+	if !ok {
+		return nil
+	}
+	query := fmt.Sprintf(`WITH c AS (%s) SELECT hash FROM c WHERE NOT EXISTS (SELECT 1 FROM c d WHERE d.prev_hash = c.hash) ORDER BY timestamp DESC LIMIT 1`,
+		w.auditRowsSource("prev_hash, hash, timestamp"))
+	rows, err := querier.Query(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to load audit chain tip: %w", err)
+	}
+	defer rows.Close()
+	if rows.Next() {
+		if err := rows.Scan(&w.auditPrev); err != nil {
+			return fmt.Errorf("failed to load audit chain tip: %w", err)
+		}
+	}
+	return rows.Err()
+}
+
+// auditRows 在 scanEntry 的列之后额外读取 prev_hash 和 hash
+type auditRows struct {
+	Rows
+	prevHash, hash **string
+}
+
+// Scan 追加哈希列的接收变量
+func (r *auditRows) Scan(dest ...any) error {
+	return r.Rows.Scan(append(dest, r.prevHash, r.hash)...)
+}
+
+// VerifyAuditChain 读取所有带 hash 的日志并校验哈希链，返回校验的条数
+// 发现内容被修改（哈希不符）、条目缺失（prev_hash 指向不存在的 hash）或分叉时返回包含 ErrAuditChainBroken 的错误；数据库执行器需实现 DBQuerier 接口
+func (w *PostgresqlWriter) VerifyAuditChain(ctx context.Context) (int, error) {
+	querier, ok := w.db.(DBQuerier)
+	if !ok {
+		return 0, fmt.Errorf("database executor does not implement DBQuerier")
+	}
+
+	columns := w.selectList() + ", prev_hash, hash"
+	rows, err := querier.Query(ctx, fmt.Sprintf("SELECT * FROM (%s) c ORDER BY timestamp", w.auditRowsSource(columns)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query audit chain: %w", err)
+	}
+	defer rows.Close()
+
+	var errs []error
+	hashes := make(map[string]bool)
+	prevs := make(map[string]string) // prev_hash → 首个引用它的 hash
+	count := 0
+	for rows.Next() {
+		var prevHash, hash *string
+		entry, err := w.scanEntry(&auditRows{Rows: rows, prevHash: &prevHash, hash: &hash})
+		if err != nil {
+			return count, fmt.Errorf("failed to scan audit row: %w", err)
+		}
+		entry.PrevHash, entry.Hash = derefString(prevHash), derefString(hash)
+		count++
+
+		if got := w.auditHash(entry); got != entry.Hash {
+			errs = append(errs, fmt.Errorf("entry at %s (hash %s) was modified", entry.Timestamp, entry.Hash))
+		}
+		hashes[entry.Hash] = true
+		if other, dup := prevs[entry.PrevHash]; dup {
+			errs = append(errs, fmt.Errorf("entries %s and %s share prev_hash %q", other, entry.Hash, entry.PrevHash))
+		} else {
+			prevs[entry.PrevHash] = entry.Hash
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("failed to read audit rows: %w", err)
+	}
+
+	for prev, hash := range prevs {
+		if prev != "" && !hashes[prev] {
+			errs = append(errs, fmt.Errorf("entry %s follows missing entry %s", hash, prev))
+		}
+	}
+	if len(errs) > 0 {
+		return count, errors.Join(append([]error{ErrAuditChainBroken}, errs...)...)
+	}
+	return count, nil
+}
@@ -0,0 +1,91 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// auditTableRows 将 INSERT 参数转换为 VerifyAuditChain 读取的行：scanEntry 的列之后是 prev_hash 和 hash
+func auditTableRows(t *testing.T, w *PostgresqlWriter, inserts []execCall) [][]any {
+	t.Helper()
+	var rows [][]any
+	for _, call := range inserts {
+		row := make([]any, 0, len(queryColumns)+3)
+		for _, column := range append(append([]string(nil), queryColumns...), "fields", "prev_hash", "hash") {
+			v := argOf(t, w, call, column)
+			switch val := v.(type) {
+			case []byte:
+				v = string(val)
+			case *int64:
+				if val == nil {
+					v = nil
+				} else {
+					v = *val
+				}
+			}
+			row = append(row, v)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func TestAuditChain(t *testing.T) {
+	var table [][]any
+	db := &queryDB{queryFunc: func(sql string, args []any) ([][]any, error) {
+		if strings.HasPrefix(sql, "WITH c AS") {
+			// 启动时读取链尾：空表
+			return nil, nil
+		}
+		return table, nil
+	}}
+	w := newTestWriter(t, db, &PostgresConfig{AuditChain: AuditChainAudit})
+	for _, action := range []string{"grant", "revoke", "login"} {
+		w.Info(action, Field("log_type", "audit"), Field("actor", "ann"))
+	}
+	w.Info("not audited")
+	w.Info("export", Field("log_type", "audit"))
+	flushSync(t, w)
+
+	inserts := db.inserts()
+	var chained []execCall
+	prev := ""
+	for _, call := range inserts {
+		hash, _ := argOf(t, w, call, "hash").(string)
+		if argOf(t, w, call, "log_type") != "audit" {
+			if hash != "" {
+				t.Errorf("non-audit entry %v was chained", argOf(t, w, call, "content"))
+			}
+			continue
+		}
+		if got := argOf(t, w, call, "prev_hash"); got != prev && !(prev == "" && got == nil) {
+			t.Errorf("%v: prev_hash = %v, want %q", argOf(t, w, call, "content"), got, prev)
+		}
+		prev = hash
+		chained = append(chained, call)
+	}
+	if len(chained) != 4 {
+		t.Fatalf("chained %d entries, want 4", len(chained))
+	}
+
+	table = auditTableRows(t, w, chained)
+	if n, err := w.VerifyAuditChain(context.Background()); err != nil || n != 4 {
+		t.Fatalf("VerifyAuditChain = %d, %v; want 4 intact entries", n, err)
+	}
+
+	// 修改一条内容
+	table = auditTableRows(t, w, chained)
+	table[1][2] = "grant"
+	if _, err := w.VerifyAuditChain(context.Background()); !errors.Is(err, ErrAuditChainBroken) || !strings.Contains(err.Error(), "was modified") {
+		t.Errorf("tampered content: err = %v, want a modified entry", err)
+	}
+
+	// 删除一条
+	table = auditTableRows(t, w, chained)
+	table = append(table[:1], table[2:]...)
+	if _, err := w.VerifyAuditChain(context.Background()); !errors.Is(err, ErrAuditChainBroken) || !strings.Contains(err.Error(), "missing entry") {
+		t.Errorf("deleted entry: err = %v, want a missing entry", err)
+	}
+}
@@ -0,0 +1,81 @@
+package writer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// maxBindParams PostgreSQL 扩展协议中单条语句的参数个数上限
+const maxBindParams = 65535
+
+// maxRowsPerInsert 返回单条多行 INSERT 最多包含的行数，保证参数个数不超过 maxBindParams
+func (w *PostgresqlWriter) maxRowsPerInsert() int {
+	return max(1, maxBindParams/len(w.insertColumns))
+}
+
+// writeMultiRow 按目标表分组写入多行 INSERT，每组按参数上限拆分为多条语句
+// 语句失败时该组逐条重写，由 writeRows 负责重试、转交 Fallback 和超时处理；调用前条目已按表分组
+func (w *PostgresqlWriter) writeMultiRow(ctx context.Context, entries []LogEntry) (written, failed int, errs []error) {
+	maxRows := w.maxRowsPerInsert()
+	for start := 0; start < len(entries); {
+		if err := ctx.Err(); err != nil {
+			failed += len(entries) - start
+			errs = append(errs, w.abandonEntries(entries[start:], err))
+			break
+		}
+
+		table := w.tableFor(entries[start])
+		end := start + 1
+		for end < len(entries) && end-start < maxRows && w.tableFor(entries[end]) == table {
+			end++
+		}
+		group := entries[start:end]
+		start = end
+
+		if err := w.insertRows(ctx, table, group); err != nil {
+			n, f, e := w.writeRows(ctx, group)
+			written, failed, errs = written+n, failed+f, append(errs, e...)
+			continue
+		}
+		written += len(group)
+		for _, entry := range group {
+			if err := w.notify(ctx, w.db, entry); err != nil {
+				errs = append(errs, fmt.Errorf("failed to notify: %w", err))
+			}
+		}
+	}
+	return written, failed, errs
+}
+
+// insertRows 以一条多行 INSERT 写入同一张表的多条日志
+func (w *PostgresqlWriter) insertRows(ctx context.Context, table string, entries []LogEntry) error {
+	if err := w.ensureShardTable(ctx, table); err != nil {
+		return err
+	}
+	return w.db.Exec(ctx, w.insertRowsQuery(table, len(entries)), w.insertRowsArgs(entries)...)
+}
+
+// insertRowsQuery 生成写入 n 行的 INSERT 语句，参数按行依次编号，列顺序与 insertArgs 一致
+func (w *PostgresqlWriter) insertRowsQuery(table string, n int) string {
+	columns := len(w.insertColumns)
+	rows := make([]string, n)
+	placeholders := make([]string, columns)
+	for i := range rows {
+		for j := range placeholders {
+			placeholders[j] = w.placeholder(i*columns + j + 1)
+		}
+		rows[i] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+	return fmt.Sprintf(`INSERT INTO %s (%s) VALUES %s`,
+		table, strings.Join(w.insertColumns, ", "), strings.Join(rows, ", "))
+}
+
+// insertRowsArgs 按行拼接多条日志的 INSERT 参数
+func (w *PostgresqlWriter) insertRowsArgs(entries []LogEntry) []any {
+	args := make([]any, 0, len(entries)*len(w.insertColumns))
+	for _, entry := range entries {
+		args = append(args, w.insertArgs(entry)...)
+	}
+	return args
+}
@@ -0,0 +1,177 @@
+package writer
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultMaxInFlight 非 SQL Writer 默认同时发送的批次数上限
+const defaultMaxInFlight = 2
+
+// batcher 非 SQL Writer（Elastic、Loki、OTel、gRPC）共用的缓冲、定时刷新和关闭逻辑
+// 同时发送的批次不超过 maxInFlight：名额用完时日志暂留缓冲区，由先完成的发送协程接续发送；
+// 缓冲区也已满时 add 阻塞到有名额释放，因此下游持续变慢时内存占用有上限（约 maxInFlight+1 个批次），不会无限积压协程和批次副本
+type batcher struct {
+	bufferSize    int
+	flushInterval time.Duration
+	maxInFlight   int
+	send          func(entries []LogEntry) error // 发送一批日志，在发送协程中调用
+	onError       func(err error)
+
+	buffer       []LogEntry
+	mu           sync.Mutex
+	closed       bool       // 由 mu 保护，close 后拒绝新日志
+	inFlight     int        // 由 mu 保护，正在发送的协程数
+	pendingFlush bool       // 由 mu 保护，名额用完时被推迟的刷新
+	slotFree     *sync.Cond // 与 mu 关联，发送协程释放名额时广播
+	done         chan struct{}
+	wg           sync.WaitGroup
+}
+
+// newBatcher 创建批量发送器并启动后台刷新协程，参数不大于 0 时使用默认值
+func newBatcher(bufferSize int, flushInterval time.Duration, maxInFlight int, send func(entries []LogEntry) error, onError func(err error)) *batcher {
+	if bufferSize <= 0 {
+		bufferSize = 100
+	}
+	if flushInterval <= 0 {
+		flushInterval = 5 * time.Second
+	}
+	if maxInFlight <= 0 {
+		maxInFlight = defaultMaxInFlight
+	}
+	b := &batcher{
+		bufferSize:    bufferSize,
+		flushInterval: flushInterval,
+		maxInFlight:   maxInFlight,
+		send:          send,
+		onError:       onError,
+		buffer:        make([]LogEntry, 0, bufferSize),
+		done:          make(chan struct{}),
+	}
+	b.slotFree = sync.NewCond(&b.mu)
+
+	b.wg.Add(1)
+	go b.flushLoop()
+	return b
+}
+
+// add 添加一条日志到缓冲区，缓冲区满时触发发送
+// 关闭后调用会丢弃该条目，并通过 onError 返回 ErrWriterClosed
+func (b *batcher) add(entry LogEntry) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		b.handleError(ErrWriterClosed)
+		return
+	}
+
+	if len(b.buffer) >= b.bufferSize && b.inFlight >= b.maxInFlight {
+		// 背压：缓冲区已满且名额用完，等待（期间释放锁）发送协程取走缓冲区或释放名额；wg 保证 Close 等待本次调用结束
+		b.wg.Add(1)
+		defer b.wg.Done()
+		b.pendingFlush = true
+		for len(b.buffer) >= b.bufferSize && b.inFlight >= b.maxInFlight {
+			b.slotFree.Wait()
+		}
+	}
+
+	b.buffer = append(b.buffer, entry)
+	// 等待期间写入器可能已关闭并完成最后一次刷新，此时由本次调用负责提交
+	if len(b.buffer) >= b.bufferSize || b.closed {
+		b.flushLocked()
+	}
+	b.mu.Unlock()
+}
+
+// flushLoop 后台定时刷新协程
+func (b *batcher) flushLoop() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.flush()
+		case <-b.done:
+			b.flush()
+			return
+		}
+	}
+}
+
+// flush 将缓冲区中的日志交给发送协程
+func (b *batcher) flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.flushLocked()
+}
+
+// flushLocked 在已持有锁的情况下刷新缓冲区；名额用完时只标记推迟，由正在发送的协程接续
+func (b *batcher) flushLocked() {
+	if len(b.buffer) == 0 {
+		return
+	}
+	if b.inFlight >= b.maxInFlight {
+		b.pendingFlush = true
+		return
+	}
+
+	entries := b.takeLocked()
+	b.inFlight++
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for len(entries) > 0 {
+			if err := b.send(entries); err != nil {
+				b.handleError(err)
+			}
+			entries = b.next()
+		}
+	}()
+}
+
+// takeLocked 取出缓冲区中的全部条目
+func (b *batcher) takeLocked() []LogEntry {
+	entries := b.buffer
+	b.buffer = make([]LogEntry, 0, b.bufferSize)
+	return entries
+}
+
+// next 发送协程完成一批后调用：有被推迟的刷新时取出缓冲区继续发送，否则释放名额
+func (b *batcher) next() []LogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pendingFlush && len(b.buffer) > 0 {
+		b.pendingFlush = false
+		// 缓冲区已取空，唤醒等待中的 add
+		b.slotFree.Broadcast()
+		return b.takeLocked()
+	}
+	b.pendingFlush = false
+	b.inFlight--
+	b.slotFree.Broadcast()
+	return nil
+}
+
+// handleError 将错误交给 onError 回调
+func (b *batcher) handleError(err error) {
+	if b.onError != nil {
+		b.onError(err)
+	}
+}
+
+// close 停止接收日志，等待缓冲区中的日志全部发送完成；重复调用返回 ErrWriterClosed
+func (b *batcher) close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrWriterClosed
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	close(b.done)
+	b.wg.Wait()
+	return nil
+}
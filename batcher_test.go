@@ -0,0 +1,107 @@
+package writer
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatcherLimitsInFlightSends(t *testing.T) {
+	const maxInFlight = 2
+	var active, peak, sent atomic.Int64
+	release := make(chan struct{})
+	send := func(entries []LogEntry) error {
+		n := active.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		sent.Add(int64(len(entries)))
+		active.Add(-1)
+		return nil
+	}
+	b := newBatcher(5, time.Hour, maxInFlight, send, nil)
+
+	// 下游阻塞期间持续写入：超出名额后 add 阻塞，而不是为每批启动新协程
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				b.add(LogEntry{Level: "info"})
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	b.mu.Lock()
+	buffered := len(b.buffer)
+	b.mu.Unlock()
+	if buffered > b.bufferSize {
+		t.Errorf("buffer grew to %d entries while saturated, want at most %d", buffered, b.bufferSize)
+	}
+
+	close(release)
+	wg.Wait()
+	if err := b.close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := peak.Load(); got > maxInFlight {
+		t.Errorf("peak concurrent sends = %d, want <= %d", got, maxInFlight)
+	}
+	if got := sent.Load(); got != 400 {
+		t.Errorf("sent %d entries, want 400", got)
+	}
+}
+
+func TestBatcherFlushOnClose(t *testing.T) {
+	var sent atomic.Int64
+	b := newBatcher(100, time.Hour, 0, func(entries []LogEntry) error {
+		sent.Add(int64(len(entries)))
+		return nil
+	}, nil)
+	for i := 0; i < 3; i++ {
+		b.add(LogEntry{Level: "info"})
+	}
+	if err := b.close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sent.Load(); got != 3 {
+		t.Errorf("sent %d entries, want 3", got)
+	}
+}
+
+func TestBatcherAddAfterClose(t *testing.T) {
+	var errs []error
+	b := newBatcher(10, time.Hour, 1, func([]LogEntry) error { return nil }, func(err error) { errs = append(errs, err) })
+	if err := b.close(); err != nil {
+		t.Fatal(err)
+	}
+	b.add(LogEntry{Level: "info"})
+	if len(errs) != 1 || !errors.Is(errs[0], ErrWriterClosed) {
+		t.Errorf("errors = %v, want [ErrWriterClosed]", errs)
+	}
+	if err := b.close(); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("second close = %v, want ErrWriterClosed", err)
+	}
+}
+
+func TestBatcherReportsSendErrors(t *testing.T) {
+	errs := make(chan error, 1)
+	b := newBatcher(1, time.Hour, 1, func([]LogEntry) error { return errors.New("boom") }, func(err error) { errs <- err })
+	b.add(LogEntry{Level: "info"})
+	b.close()
+	select {
+	case err := <-errs:
+		if err.Error() != "boom" {
+			t.Errorf("error = %v, want boom", err)
+		}
+	default:
+		t.Fatal("expected send error to reach onError")
+	}
+}
@@ -3,18 +3,64 @@ package writer
 import (
 	"fmt"
 	"os"
-	"strings"
+	"slices"
 	"time"
 
 	"github.com/fatih/color"
 )
 
 // ConsoleWriter 控制台 Writer，将日志输出到标准输出（不依赖 go-zero）
-type ConsoleWriter struct{}
+type ConsoleWriter struct {
+	disabled           bool
+	idGenerator        func() string
+	includeGoroutineID bool
+	callerFunction     bool
+	beforeWrite        func(entry *LogEntry) bool
+	emptyContent       EmptyContentMode
+	emptyPlaceholder   string
+	content            contentFormatter // NilContent、MaxReaderBytes
+	keepTrailingSpace  bool
+	encoder            Encoder
+	escalationRules    []EscalationRule
+	minLevel           int
+	levels             *levelPolicy
+}
 
 // NewConsoleWriter 创建一个控制台 Writer
 func NewConsoleWriter() *ConsoleWriter {
-	return &ConsoleWriter{}
+	return &ConsoleWriter{encoder: TextEncoder{}, content: defaultContent}
+}
+
+// NewConsoleWriterWithConfig 使用配置创建一个控制台 Writer
+// config: 配置项（可选，传 nil 等同于 NewConsoleWriter）
+func NewConsoleWriterWithConfig(config *ConsoleConfig) *ConsoleWriter {
+	if config == nil {
+		return NewConsoleWriter()
+	}
+	encoder := config.Encoder
+	if encoder == nil {
+		encoder = TextEncoder{
+			Pretty:            config.Pretty,
+			FieldSeparator:    config.FieldSeparator,
+			KeyValueDelimiter: config.KeyValueDelimiter,
+			FieldPrefix:       config.FieldPrefix,
+		}
+	}
+	return &ConsoleWriter{
+		encoder:            encoder,
+		disabled:           config.Disabled,
+		idGenerator:        config.IDGenerator,
+		includeGoroutineID: config.IncludeGoroutineID,
+		callerFunction:     config.CallerFunction,
+		beforeWrite:        config.BeforeWrite,
+		emptyContent:       config.EmptyContent,
+		emptyPlaceholder:   config.EmptyContentPlaceholder,
+		content:            newContentFormatter(config.NilContent, config.MaxReaderBytes),
+		keepTrailingSpace:  config.KeepTrailingSpace,
+		escalationRules:    config.EscalationRules,
+		minLevel:           levelRank(config.MinLevel),
+		levels:             newLevelPolicy(config.UnknownLevelPolicy, append(slices.Clone(builtinLevels), config.CustomLevels...), config.UnknownLevel),
+	}
 }
 
 // getLevelColor 根据日志级别返回对应的颜色函数
@@ -39,110 +85,180 @@ func getLevelColor(level string) func(format string, a ...interface{}) string {
 	}
 }
 
-// log 内部日志方法，接收 caller 参数
-func (c *ConsoleWriter) log(level string, content any, caller string, fields ...LogField) {
-	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
-	contentStr := FormatContent(content)
-	levelColor := getLevelColor(level)
-
-	var parts []string
-	// 级别使用颜色
-	parts = append(parts, levelColor("[%s]", strings.ToUpper(level)))
-	// 时间戳使用灰色
-	timestampColor := color.New(color.FgHiBlack)
-	parts = append(parts, timestampColor.Sprint(timestamp))
-	if caller != "" {
-		// caller 使用灰色
-		parts = append(parts, timestampColor.Sprint(caller))
+// log 内部日志方法，caller 取包外第一个调用者（经 MultiWriter、Named 等封装时同样准确）
+func (c *ConsoleWriter) log(level string, content any, fields ...LogField) {
+	if !c.Enabled(level) {
+		return
 	}
-	parts = append(parts, contentStr)
+	c.logText(level, c.content.format(content), fields)
+}
 
-	trace, span, duration, logType, userID, username := extractFields(fields)
-	// 字段使用青色
-	fieldColor := color.New(color.FgCyan)
-	if trace != "" {
-		parts = append(parts, fieldColor.Sprint(fmt.Sprintf("trace=%s", trace)))
-	}
-	if span != "" {
-		parts = append(parts, fieldColor.Sprint(fmt.Sprintf("span=%s", span)))
+// logText 与 log 相同，内容已格式化为字符串，调用方已完成级别过滤
+func (c *ConsoleWriter) logText(level, text string, fields []LogField) {
+	caller := externalCaller(c.callerFunction)
+	now := time.Now()
+
+	entry := LogEntry{
+		Timestamp: now.Format(timestampLayout),
+		Level:     level,
+		Content:   text,
+		Fields:    convertLogFields(fields),
 	}
-	if duration != "" {
-		parts = append(parts, fieldColor.Sprint(fmt.Sprintf("duration=%s", duration)))
+	if !c.keepTrailingSpace {
+		entry.Content = trimTrailingSpace(entry.Content)
 	}
-	if logType != "" {
-		parts = append(parts, fieldColor.Sprint(fmt.Sprintf("log_type=%s", logType)))
+	if !applyEmptyContent(&entry.Content, c.emptyContent, c.emptyPlaceholder) {
+		return
 	}
-	if userID != nil {
-		parts = append(parts, fieldColor.Sprint(fmt.Sprintf("user_id=%d", *userID)))
+	entry.Level = escalateLevel(c.escalationRules, entry.Level, entry.Content, fields)
+	applySpecialFields(&entry, fields, now)
+	if c.idGenerator != nil {
+		entry.EntryID = c.idGenerator()
 	}
-	if username != "" {
-		parts = append(parts, fieldColor.Sprint(fmt.Sprintf("username=%s", username)))
+	if c.includeGoroutineID && !hasField(fields, "goroutine") {
+		if entry.Fields == nil {
+			entry.Fields = make(map[string]interface{}, 1)
+		}
+		entry.Fields["goroutine"] = goroutineID()
 	}
+	c.write(entry, caller)
+}
 
-	for _, field := range fields {
-		if field.Key != "trace" && field.Key != "span" && field.Key != "duration" && field.Key != "log_type" && field.Key != "logType" && field.Key != "user_id" && field.Key != "userId" && field.Key != "username" && field.Key != "userName" {
-			parts = append(parts, fieldColor.Sprint(fmt.Sprintf("%s=%v", field.Key, field.Value)))
-		}
+// AddEntry 直接输出已构造好的日志条目，不再进行字段提取
+func (c *ConsoleWriter) AddEntry(entry LogEntry) {
+	if c.disabled {
+		return
 	}
+	c.write(entry, externalCaller(c.callerFunction))
+}
 
-	output := strings.Join(parts, " ")
-	if level == "error" || level == "warn" || level == "alert" || level == "severe" || level == "stack" {
-		fmt.Fprintf(os.Stderr, "%s\n", output)
+// write 编码并输出一条日志，error 类级别和 warn 输出到 stderr，其余输出到 stdout
+func (c *ConsoleWriter) write(entry LogEntry, caller string) {
+	level, keep, err := c.levels.resolve(entry.Level)
+	if !keep {
+		return
+	}
+	if err != nil {
+		// warn 策略：首次遇到未知级别时额外输出一条提示
+		c.write(LogEntry{Timestamp: entry.Timestamp, Level: "warn", Content: err.Error()}, caller)
+	}
+	entry.Level = level
+	if !applyBeforeWrite(c.beforeWrite, &entry) {
+		return
+	}
+	encoder := c.encoder
+	if encoder == nil {
+		// 零值 ConsoleWriter 使用默认格式
+		encoder = TextEncoder{}
+	}
+	line := append(encoder.Encode(entry, caller), '\n')
+	if isErrorLevel(entry.Level) || entry.Level == "warn" {
+		os.Stderr.Write(line)
 	} else {
-		fmt.Fprintf(os.Stdout, "%s\n", output)
+		os.Stdout.Write(line)
 	}
 }
 
 // Log 写入日志（公开方法，供外部直接调用）
 func (c *ConsoleWriter) Log(level string, content any, fields ...LogField) {
-	c.log(level, content, GetCaller(2), fields...)
+	c.log(level, content, fields...)
 }
 
 // Info 写入 info 级别日志
 func (c *ConsoleWriter) Info(content any, fields ...LogField) {
-	c.log("info", content, GetCaller(2), fields...)
+	c.log("info", content, fields...)
 }
 
 // Error 写入 error 级别日志
 func (c *ConsoleWriter) Error(content any, fields ...LogField) {
-	c.log("error", content, GetCaller(2), fields...)
+	c.log("error", content, fields...)
 }
 
 // Debug 写入 debug 级别日志
 func (c *ConsoleWriter) Debug(content any, fields ...LogField) {
-	c.log("debug", content, GetCaller(2), fields...)
+	c.log("debug", content, fields...)
 }
 
 // Warn 写入 warn 级别日志
 func (c *ConsoleWriter) Warn(content any, fields ...LogField) {
-	c.log("warn", content, GetCaller(2), fields...)
+	c.log("warn", content, fields...)
+}
+
+// LogMsg 写入只有消息、没有字段的日志；内容为 string 时不经过 any 装箱，级别被过滤时没有任何内存分配
+func (c *ConsoleWriter) LogMsg(level, msg string) {
+	if !c.Enabled(level) {
+		return
+	}
+	c.logText(level, msg, nil)
+}
+
+// InfoMsg 写入只有消息的 info 级别日志
+func (c *ConsoleWriter) InfoMsg(msg string) {
+	c.LogMsg("info", msg)
+}
+
+// ErrorMsg 写入只有消息的 error 级别日志
+func (c *ConsoleWriter) ErrorMsg(msg string) {
+	c.LogMsg("error", msg)
+}
+
+// DebugMsg 写入只有消息的 debug 级别日志
+func (c *ConsoleWriter) DebugMsg(msg string) {
+	c.LogMsg("debug", msg)
+}
+
+// WarnMsg 写入只有消息的 warn 级别日志
+func (c *ConsoleWriter) WarnMsg(msg string) {
+	c.LogMsg("warn", msg)
 }
 
 // Infof 写入 info 级别格式化日志
 func (c *ConsoleWriter) Infof(format string, args ...any) {
-	c.log("info", fmt.Sprintf(format, args...), GetCaller(2))
+	c.Logf("info", format, args...)
 }
 
 // Errorf 写入 error 级别格式化日志
 func (c *ConsoleWriter) Errorf(format string, args ...any) {
-	c.log("error", fmt.Sprintf(format, args...), GetCaller(2))
+	c.Logf("error", format, args...)
 }
 
 // Debugf 写入 debug 级别格式化日志
 func (c *ConsoleWriter) Debugf(format string, args ...any) {
-	c.log("debug", fmt.Sprintf(format, args...), GetCaller(2))
+	c.Logf("debug", format, args...)
 }
 
 // Warnf 写入 warn 级别格式化日志
 func (c *ConsoleWriter) Warnf(format string, args ...any) {
-	c.log("warn", fmt.Sprintf(format, args...), GetCaller(2))
+	c.Logf("warn", format, args...)
 }
 
 // Logf 写入格式化日志
 func (c *ConsoleWriter) Logf(level string, format string, args ...any) {
-	c.log(level, fmt.Sprintf(format, args...), GetCaller(2))
+	// 级别被过滤时不格式化，避免无谓的分配
+	if !c.Enabled(level) {
+		return
+	}
+	c.log(level, fmt.Sprintf(format, args...))
 }
 
+// Enabled 判断该级别的日志是否会被输出（未禁用且不低于 MinLevel），不加锁、不分配内存
+func (c *ConsoleWriter) Enabled(level string) bool {
+	return !c.disabled && levelRank(level) >= c.minLevel
+}
+
+// Named 返回带组件名的派生 Writer
+func (c *ConsoleWriter) Named(component string) Writer {
+	return newDerivedWriter(c, component)
+}
+
+// With 返回附加默认字段的派生 Writer，每条日志都会带上这些字段（调用时传入的同名字段优先）
+func (c *ConsoleWriter) With(fields ...LogField) Writer {
+	return newFieldsWriter(c, fields)
+}
+
+// Flush 刷新写入器（控制台 Writer 直接输出，无需刷新）
+func (c *ConsoleWriter) Flush() {}
+
 // Close 关闭写入器（控制台 Writer 不需要关闭）
 func (c *ConsoleWriter) Close() error {
 	return nil
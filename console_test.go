@@ -0,0 +1,140 @@
+package writer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// consoleEntries 解析 JSONEncoder 输出的每一行
+func consoleEntries(t *testing.T, output string) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("bad console line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestConsoleGoroutineID(t *testing.T) {
+	output := captureOutput(t)
+	NewConsoleWriterWithConfig(&ConsoleConfig{Encoder: JSONEncoder{}, IncludeGoroutineID: true}).Info("with id")
+	NewConsoleWriterWithConfig(&ConsoleConfig{Encoder: JSONEncoder{}}).Info("without id")
+	want := goroutineID()
+
+	entries := consoleEntries(t, output())
+	fields, _ := entries[0]["fields"].(map[string]any)
+	if fields["goroutine"] != float64(want) {
+		t.Errorf("goroutine = %v, want %d", fields["goroutine"], want)
+	}
+	if _, ok := entries[1]["fields"]; ok {
+		t.Errorf("fields present by default: %v", entries[1]["fields"])
+	}
+}
+
+func TestConsoleNilContent(t *testing.T) {
+	output := captureOutput(t)
+	NewConsoleWriterWithConfig(&ConsoleConfig{Encoder: JSONEncoder{}}).Info(nil)
+	NewConsoleWriterWithConfig(&ConsoleConfig{Encoder: JSONEncoder{}, NilContent: "-"}).Info(nil)
+	NewConsoleWriterWithConfig(&ConsoleConfig{Encoder: JSONEncoder{}, EmptyContent: EmptyContentSkip}).Info(nil)
+	NewConsoleWriterWithConfig(&ConsoleConfig{Encoder: JSONEncoder{}, MaxReaderBytes: 3}).Info(strings.NewReader("abcdef"))
+
+	entries := consoleEntries(t, output())
+	var got []any
+	for _, entry := range entries {
+		got = append(got, entry["content"])
+	}
+	want := []any{"", "-", "abc...(truncated)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("contents = %v, want %v", got, want)
+	}
+}
+
+func TestConsoleEscalationToStderr(t *testing.T) {
+	stdout := capturePipe(t, &os.Stdout)
+	stderr := capturePipe(t, &os.Stderr)
+	c := NewConsoleWriterWithConfig(&ConsoleConfig{
+		Encoder:         JSONEncoder{},
+		EscalationRules: []EscalationRule{{Pattern: regexp.MustCompile(`out of memory`), Level: "severe"}},
+	})
+	c.Info("cache warmed")
+	c.Info("worker: out of memory")
+
+	errEntries := consoleEntries(t, stderr())
+	if len(errEntries) != 1 || errEntries[0]["level"] != "severe" || errEntries[0]["content"] != "worker: out of memory" {
+		t.Errorf("stderr = %v, want the escalated entry", errEntries)
+	}
+	outEntries := consoleEntries(t, stdout())
+	if len(outEntries) != 1 || outEntries[0]["level"] != "info" {
+		t.Errorf("stdout = %v, want only the unmatched entry", outEntries)
+	}
+}
+
+func TestConsoleCustomEncoder(t *testing.T) {
+	output := captureOutput(t)
+	var got []LogEntry
+	c := NewConsoleWriterWithConfig(&ConsoleConfig{Encoder: EncoderFunc(func(entry LogEntry, caller string) []byte {
+		got = append(got, entry)
+		return []byte("custom|" + entry.Level + "|" + entry.Content + "|" + entry.Trace)
+	})})
+	c.Info("hello", Field("trace", "t1"))
+	c.Error("boom")
+
+	if out, want := output(), "custom|info|hello|t1\ncustom|error|boom|\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+	if len(got) != 2 || got[0].Content != "hello" || got[1].Level != "error" {
+		t.Errorf("encoder received %+v", got)
+	}
+}
+
+func TestConsoleUnknownLevelPolicies(t *testing.T) {
+	output := captureOutput(t)
+	NewConsoleWriterWithConfig(&ConsoleConfig{Encoder: JSONEncoder{}, UnknownLevelPolicy: UnknownLevelReject}).Log("verbose", "rejected")
+	NewConsoleWriterWithConfig(&ConsoleConfig{Encoder: JSONEncoder{}, UnknownLevelPolicy: UnknownLevelMap, UnknownLevel: "error"}).Log("verbose", "mapped")
+	warn := NewConsoleWriterWithConfig(&ConsoleConfig{Encoder: JSONEncoder{}, UnknownLevelPolicy: UnknownLevelWarn})
+	warn.Log("verbose", "warned")
+	warn.Log("verbose", "warned again")
+
+	// stdout 和 stderr 写入同一管道，顺序与调用顺序一致
+	var got []string
+	for _, entry := range consoleEntries(t, output()) {
+		got = append(got, fmt.Sprintf("%v:%v", entry["level"], entry["content"]))
+	}
+	want := []string{"error:mapped", `warn:unknown log level: "verbose"`, "verbose:warned", "verbose:warned again"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestConsoleRendersWithError(t *testing.T) {
+	noColor(t)
+	output := captureOutput(t)
+	NewConsoleWriter().Error("payment failed", WithError(errors.New("card declined")))
+	if got := output(); !strings.HasSuffix(got, " payment failed error=card declined\n") {
+		t.Errorf("console output = %q, want the message followed by error=...", got)
+	}
+}
+
+func TestConsoleTrimsTrailingNewline(t *testing.T) {
+	output := captureOutput(t)
+	NewConsoleWriterWithConfig(&ConsoleConfig{Encoder: JSONEncoder{}}).Info("line one\nline two\n")
+	NewConsoleWriterWithConfig(&ConsoleConfig{Encoder: JSONEncoder{}, KeepTrailingSpace: true}).Info("kept\n")
+
+	entries := consoleEntries(t, output())
+	if got := entries[0]["content"]; got != "line one\nline two" {
+		t.Errorf("content = %q, want trailing newline trimmed and internal newline kept", got)
+	}
+	if got := entries[1]["content"]; got != "kept\n" {
+		t.Errorf("content with KeepTrailingSpace = %q, want %q", got, "kept\n")
+	}
+}
@@ -0,0 +1,22 @@
+package writer
+
+import "context"
+
+// writerContextKey context 中保存 Writer 的键
+type writerContextKey struct{}
+
+// noopWriter FromContext 在 context 中没有 Writer 时返回的空 Writer
+var noopWriter Writer = NewDisabledWriter()
+
+// NewContext 返回携带 w 的 context，用于中间件保存请求级 Writer（如已通过 With 附加 trace、user_id）
+func NewContext(ctx context.Context, w Writer) context.Context {
+	return context.WithValue(ctx, writerContextKey{}, w)
+}
+
+// FromContext 返回 NewContext 保存的 Writer；没有时返回丢弃所有日志的 DisabledWriter，调用方无需判空
+func FromContext(ctx context.Context) Writer {
+	if w, ok := ctx.Value(writerContextKey{}).(Writer); ok && w != nil {
+		return w
+	}
+	return noopWriter
+}
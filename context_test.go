@@ -0,0 +1,38 @@
+package writer
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContextRoundTrip(t *testing.T) {
+	mem := &memoryWriter{}
+	ctx := NewContext(context.Background(), mem.With(Field("trace", "t-1")))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	FromContext(ctx).Info("handled")
+	entries := mem.all()
+	if len(entries) != 1 || entries[0].Content != "handled" || entries[0].Trace != "t-1" {
+		t.Errorf("entries = %+v, want one entry carrying trace t-1", entries)
+	}
+}
+
+func TestFromContextNoopFallback(t *testing.T) {
+	for name, ctx := range map[string]context.Context{
+		"empty":     context.Background(),
+		"nil value": NewContext(context.Background(), nil),
+	} {
+		w := FromContext(ctx)
+		if w == nil {
+			t.Fatalf("%s: FromContext returned nil", name)
+		}
+		// 空 Writer 可以直接调用，包括派生出的 Writer
+		w.Info("dropped")
+		w.Named("api").With(Field("k", "v")).Error("dropped")
+		w.Flush()
+		if _, ok := w.(*DisabledWriter); !ok {
+			t.Errorf("%s: FromContext = %T, want *DisabledWriter", name, w)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package writer
+
+import "fmt"
+
+// derivedWriter 派生的 Writer，为每条日志附加组件名和默认字段，写入委托给父 Writer
+// 由各 Writer 的 Named、With 方法创建，与父 Writer 共享缓冲区和连接
+// 派生时总是复制父级字段再追加，不修改已有的 derivedWriter，兄弟 Writer 之间互不影响
+type derivedWriter struct {
+	parent    Writer
+	component string
+	fields    []LogField
+	scope     []LogField // component 字段与默认字段预先合并的结果，避免每次写日志时重新构造
+}
+
+// newScopedWriter 创建派生 Writer 并预先合并作用域字段
+func newScopedWriter(parent Writer, component string, fields []LogField) *derivedWriter {
+	d := &derivedWriter{parent: parent, component: component, fields: fields}
+	var base []LogField
+	if component != "" {
+		base = []LogField{Field("component", component)}
+	}
+	scope := mergeFields(base, fields)
+	// 限制容量，下游即使 append 也不会写入共享的底层数组
+	d.scope = scope[:len(scope):len(scope)]
+	return d
+}
+
+// newDerivedWriter 基于父 Writer 创建带组件名的派生 Writer
+func newDerivedWriter(parent Writer, component string) *derivedWriter {
+	return newScopedWriter(parent, component, nil)
+}
+
+// newFieldsWriter 基于父 Writer 创建带默认字段的派生 Writer
+func newFieldsWriter(parent Writer, fields []LogField) *derivedWriter {
+	return newScopedWriter(parent, "", mergeFields(nil, fields))
+}
+
+// Named 返回子组件 Writer，组件名以点号连接，如 auth.oauth；默认字段保留
+func (d *derivedWriter) Named(component string) Writer {
+	if component == "" {
+		return d
+	}
+	if d.component != "" {
+		component = d.component + "." + component
+	}
+	return newScopedWriter(d.parent, component, d.fields)
+}
+
+// With 返回附加默认字段的子 Writer，同名字段以子级为准
+func (d *derivedWriter) With(fields ...LogField) Writer {
+	if len(fields) == 0 {
+		return d
+	}
+	return newScopedWriter(d.parent, d.component, mergeFields(d.fields, fields))
+}
+
+// Log 写入日志（核心方法）
+// 合并 component、默认字段和调用方传入的字段，越靠近调用处的同名字段优先；合并用的切片来自池，父 Writer 返回后归还
+func (d *derivedWriter) Log(level string, content any, fields ...LogField) {
+	if len(fields) == 0 || len(d.scope) == 0 {
+		if len(fields) == 0 {
+			fields = d.scope
+		}
+		d.parent.Log(level, content, fields...)
+		return
+	}
+	buf := getFieldSlice()
+	*buf = appendMergedFields(*buf, d.scope, fields)
+	d.parent.Log(level, content, *buf...)
+	putFieldSlice(buf)
+}
+
+// Info 写入 info 级别日志
+func (d *derivedWriter) Info(content any, fields ...LogField) {
+	d.Log("info", content, fields...)
+}
+
+// Error 写入 error 级别日志
+func (d *derivedWriter) Error(content any, fields ...LogField) {
+	d.Log("error", content, fields...)
+}
+
+// Debug 写入 debug 级别日志
+func (d *derivedWriter) Debug(content any, fields ...LogField) {
+	d.Log("debug", content, fields...)
+}
+
+// Warn 写入 warn 级别日志
+func (d *derivedWriter) Warn(content any, fields ...LogField) {
+	d.Log("warn", content, fields...)
+}
+
+// Infof 写入 info 级别格式化日志
+func (d *derivedWriter) Infof(format string, args ...any) {
+	d.Log("info", fmt.Sprintf(format, args...))
+}
+
+// Errorf 写入 error 级别格式化日志
+func (d *derivedWriter) Errorf(format string, args ...any) {
+	d.Log("error", fmt.Sprintf(format, args...))
+}
+
+// Debugf 写入 debug 级别格式化日志
+func (d *derivedWriter) Debugf(format string, args ...any) {
+	d.Log("debug", fmt.Sprintf(format, args...))
+}
+
+// Warnf 写入 warn 级别格式化日志
+func (d *derivedWriter) Warnf(format string, args ...any) {
+	d.Log("warn", fmt.Sprintf(format, args...))
+}
+
+// Logf 写入格式化日志
+func (d *derivedWriter) Logf(level string, format string, args ...any) {
+	d.Log(level, fmt.Sprintf(format, args...))
+}
+
+// AddEntry 提交日志条目，条目未设置组件名时使用当前组件名
+// 默认字段中的普通字段在条目缺少同名字段时补充到 Fields（复制后修改，不影响调用方的 map）
+func (d *derivedWriter) AddEntry(entry LogEntry) {
+	if entry.Component == "" {
+		entry.Component = d.component
+	}
+	if len(d.fields) > 0 {
+		merged := make(map[string]interface{}, len(entry.Fields)+len(d.fields))
+		for _, f := range d.fields {
+			if !isSpecialKey(f.Key) {
+				merged[f.Key] = f.Value
+			}
+		}
+		for k, v := range entry.Fields {
+			merged[k] = v
+		}
+		if len(merged) > 0 {
+			entry.Fields = merged
+		}
+	}
+	d.parent.AddEntry(entry)
+}
+
+// Flush 刷新父 Writer
+func (d *derivedWriter) Flush() {
+	d.parent.Flush()
+}
+
+// Close 派生 Writer 不拥有底层资源，关闭由父 Writer 负责，此处为空操作
+func (d *derivedWriter) Close() error {
+	return nil
+}
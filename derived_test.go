@@ -0,0 +1,111 @@
+package writer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNamedNesting(t *testing.T) {
+	mem := &memoryWriter{}
+	auth := mem.Named("auth")
+	auth.Named("oauth").Info("token issued")
+	auth.Info("login")
+	auth.Named("").Info("unnamed child")
+	auth.AddEntry(LogEntry{Level: "info", Content: "raw"})
+
+	want := []string{"auth.oauth", "auth", "auth", "auth"}
+	entries := mem.all()
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if entry.Component != want[i] {
+			t.Errorf("entry %d (%s): component = %q, want %q", i, entry.Content, entry.Component, want[i])
+		}
+	}
+}
+
+func TestNamedMultiWriter(t *testing.T) {
+	a, b := &memoryWriter{}, &memoryWriter{}
+	NewMultiWriter(a, b).Named("db").Named("pool").Warn("exhausted")
+	for i, mem := range []*memoryWriter{a, b} {
+		if entries := mem.all(); len(entries) != 1 || entries[0].Component != "db.pool" {
+			t.Errorf("writer %d: entries = %+v, want component db.pool", i, entries)
+		}
+	}
+}
+
+func TestNamedPostgresColumn(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, nil)
+	if indexes := db.execs("CREATE INDEX"); !strings.Contains(execSQL(indexes), "(component)") {
+		t.Errorf("no index on component: %s", execSQL(indexes))
+	}
+
+	w.Named("auth").Named("oauth").Info("token issued")
+	flushSync(t, w)
+	if got := argOf(t, w, db.inserts()[0], "component"); got != "auth.oauth" {
+		t.Errorf("component = %v, want auth.oauth", got)
+	}
+}
+
+func TestWithThreeLevelOverrides(t *testing.T) {
+	mem := &memoryWriter{}
+	request := mem.With(Field("request_id", "r1"), Field("scope", "request"))
+	handler := request.With(Field("handler", "users"), Field("scope", "handler"))
+	db := handler.Named("db").With(Field("scope", "db"), Field("table", "users"))
+	// 兄弟 Writer 不共享父级的字段切片
+	sibling := handler.With(Field("table", "orders"))
+
+	db.Info("query", Field("table", "accounts"))
+	handler.Info("handled")
+	request.Info("done")
+	sibling.Info("sibling")
+
+	want := []map[string]interface{}{
+		{"request_id": "r1", "scope": "db", "handler": "users", "table": "accounts"},
+		{"request_id": "r1", "scope": "handler", "handler": "users"},
+		{"request_id": "r1", "scope": "request"},
+		{"request_id": "r1", "scope": "handler", "handler": "users", "table": "orders"},
+	}
+	entries := mem.all()
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if !reflect.DeepEqual(entry.Fields, want[i]) {
+			t.Errorf("entry %d (%s): fields = %v, want %v", i, entry.Content, entry.Fields, want[i])
+		}
+	}
+	if entries[0].Component != "db" {
+		t.Errorf("component = %q, want db", entries[0].Component)
+	}
+}
+
+// BenchmarkDerivedLog 派生 Writer 合并字段：pooled 为当前实现，merged 为每次调用分配新切片的对照
+func BenchmarkDerivedLog(b *testing.B) {
+	fields := []LogField{Field("status", 200), Field("path", "/api")}
+	b.Run("pooled", func(b *testing.B) {
+		w := newBenchWriter(b, false)
+		d := w.Named("http").With(Field("region", "eu"))
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				d.Info("request served", fields...)
+			}
+		})
+	})
+	b.Run("merged", func(b *testing.B) {
+		w := newBenchWriter(b, false)
+		d := w.Named("http").With(Field("region", "eu")).(*derivedWriter)
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				w.Info("request served", mergeFields(d.scope, fields)...)
+			}
+		})
+	})
+}
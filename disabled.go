@@ -0,0 +1,60 @@
+package writer
+
+// DisabledWriter 丢弃所有日志的 Writer，用于 CI、本地开发等无需输出日志的场景
+type DisabledWriter struct{}
+
+// NewDisabledWriter 创建一个丢弃所有日志的 Writer
+func NewDisabledWriter() *DisabledWriter {
+	return &DisabledWriter{}
+}
+
+// Log 丢弃日志
+func (DisabledWriter) Log(level string, content any, fields ...LogField) {}
+
+// Info 丢弃日志
+func (DisabledWriter) Info(content any, fields ...LogField) {}
+
+// Error 丢弃日志
+func (DisabledWriter) Error(content any, fields ...LogField) {}
+
+// Debug 丢弃日志
+func (DisabledWriter) Debug(content any, fields ...LogField) {}
+
+// Warn 丢弃日志
+func (DisabledWriter) Warn(content any, fields ...LogField) {}
+
+// Infof 丢弃日志
+func (DisabledWriter) Infof(format string, args ...any) {}
+
+// Errorf 丢弃日志
+func (DisabledWriter) Errorf(format string, args ...any) {}
+
+// Debugf 丢弃日志
+func (DisabledWriter) Debugf(format string, args ...any) {}
+
+// Warnf 丢弃日志
+func (DisabledWriter) Warnf(format string, args ...any) {}
+
+// Logf 丢弃日志
+func (DisabledWriter) Logf(level string, format string, args ...any) {}
+
+// AddEntry 丢弃日志
+func (DisabledWriter) AddEntry(entry LogEntry) {}
+
+// Named 返回丢弃所有日志的 Writer
+func (DisabledWriter) Named(component string) Writer {
+	return DisabledWriter{}
+}
+
+// With 返回丢弃所有日志的 Writer
+func (DisabledWriter) With(fields ...LogField) Writer {
+	return DisabledWriter{}
+}
+
+// Flush 空操作
+func (DisabledWriter) Flush() {}
+
+// Close 空操作
+func (DisabledWriter) Close() error {
+	return nil
+}
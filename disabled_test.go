@@ -0,0 +1,121 @@
+package writer
+
+import (
+	"testing"
+	"time"
+)
+
+// newBenchWriter 创建写入 nopDB 的 PostgresqlWriter，disabled 为 true 时整体禁用
+func newBenchWriter(b testing.TB, disabled bool) *PostgresqlWriter {
+	w, err := NewPostgresqlWriter(nopDB{}, &PostgresConfig{
+		TableName:     "logs",
+		BufferSize:    1000,
+		FlushInterval: time.Second,
+		Disabled:      disabled,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { w.Close() })
+	return w
+}
+
+// newFilteredWriter 创建 MinLevel 为 info 的 PostgresqlWriter，debug 日志被过滤
+func newFilteredWriter(b testing.TB) *PostgresqlWriter {
+	w, err := NewPostgresqlWriter(nopDB{}, &PostgresConfig{
+		TableName:     "logs",
+		BufferSize:    1000,
+		FlushInterval: time.Second,
+		MinLevel:      "info",
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { w.Close() })
+	return w
+}
+
+func TestFilteredDebugAllocatesNothing(t *testing.T) {
+	w := newFilteredWriter(t)
+	tests := map[string]func(){
+		"Debug":  func() { w.Debug("cache miss", Field("key", "user:1")) },
+		"Debugf": func() { w.Debugf("cache miss %s", "user:1") },
+		"Log":    func() { w.Log("debug", "cache miss") },
+	}
+	for name, fn := range tests {
+		if allocs := testing.AllocsPerRun(100, fn); allocs != 0 {
+			t.Errorf("filtered %s allocates %.1f times per call, want 0", name, allocs)
+		}
+	}
+	if w.BufferLen() != 0 {
+		t.Errorf("filtered debug logs buffered %d entries", w.BufferLen())
+	}
+}
+
+func TestDisabledPathAllocatesNothing(t *testing.T) {
+	pg := newBenchWriter(t, true)
+	var dw Writer = NewDisabledWriter()
+	tests := map[string]func(){
+		"PostgresqlWriter.Info": func() { pg.Info("request served", Field("status", 200)) },
+		"PostgresqlWriter.Log":  func() { pg.Log("error", "request failed") },
+		"DisabledWriter.Info":   func() { dw.Info("request served") },
+		"DisabledWriter.Infof":  func() { dw.Infof("request %s", "served") },
+	}
+	for name, fn := range tests {
+		if allocs := testing.AllocsPerRun(100, fn); allocs != 0 {
+			t.Errorf("%s allocates %.1f times per call, want 0", name, allocs)
+		}
+	}
+	if pg.BufferLen() != 0 {
+		t.Errorf("disabled writer buffered %d entries", pg.BufferLen())
+	}
+}
+
+func BenchmarkDisabled(b *testing.B) {
+	b.Run("baseline", func(b *testing.B) {
+		w := newBenchWriter(b, false)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			w.Info("request served", Field("status", 200))
+		}
+	})
+	b.Run("disabled", func(b *testing.B) {
+		w := newBenchWriter(b, true)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			w.Info("request served", Field("status", 200))
+		}
+	})
+	b.Run("DisabledWriter", func(b *testing.B) {
+		var w Writer = NewDisabledWriter()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			w.Info("request served", Field("status", 200))
+		}
+	})
+}
+
+func BenchmarkFilteredDebug(b *testing.B) {
+	b.Run("serial", func(b *testing.B) {
+		w := newFilteredWriter(b)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			w.Debug("cache miss", Field("key", "user:1"))
+		}
+	})
+	// 过滤判断不加锁，并发调用不争用 bufferMux
+	b.Run("parallel", func(b *testing.B) {
+		w := newFilteredWriter(b)
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				w.Debug("cache miss", Field("key", "user:1"))
+			}
+		})
+	})
+}
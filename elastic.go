@@ -0,0 +1,286 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ElasticWriter 通过 _bulk API 将日志写入 Elasticsearch/OpenSearch
+type ElasticWriter struct {
+	client       *http.Client
+	bulkURL      string
+	index        string
+	indexPattern string
+	headers      map[string]string
+	username     string
+	password     string
+	serializer   Serializer
+	beforeWrite  func(entry *LogEntry) bool
+
+	batch *batcher
+}
+
+// ElasticConfig Elasticsearch Writer 配置
+type ElasticConfig struct {
+	URL           string                     `json:"url"`            // 集群地址，如 http://localhost:9200
+	Index         string                     `json:"index"`          // 固定索引名
+	IndexPattern  string                     `json:"index_pattern"`  // 按日期生成的索引名（Go 时间格式），如 logs-2006.01.02，优先于 Index
+	Headers       map[string]string          `json:"headers"`        // 附加请求头（如 Authorization）
+	Username      string                     `json:"username"`       // Basic Auth 用户名（可选）
+	Password      string                     `json:"password"`       // Basic Auth 密码（可选）
+	BufferSize    int                        `json:"buffer_size"`    // 缓冲区大小
+	FlushInterval time.Duration              `json:"flush_interval"` // 刷新间隔
+	MaxInFlight   int                        `json:"max_in_flight"`  // 同时发送的 _bulk 请求数上限（默认 2），达到上限且缓冲区已满时写日志阻塞
+	Timeout       time.Duration              `json:"timeout"`        // 单次 _bulk 请求超时
+	HTTPClient    *http.Client               `json:"-"`              // 自定义 HTTP 客户端（可选）
+	Serializer    Serializer                 `json:"-"`              // 文档序列化方式（可选，默认 JSON；输出必须为单行，以符合 NDJSON）
+	BeforeWrite   func(entry *LogEntry) bool `json:"-"`              // 写入前的钩子（可选），可修改条目，返回 false 丢弃该条日志
+	OnError       func(err error)            `json:"-"`              // 写入失败回调（可选）
+}
+
+// DefaultElasticConfig 返回默认 Elasticsearch 配置
+func DefaultElasticConfig() *ElasticConfig {
+	return &ElasticConfig{
+		URL:           "http://localhost:9200",
+		Index:         "logs",
+		BufferSize:    100,
+		FlushInterval: 5 * time.Second,
+		Timeout:       30 * time.Second,
+	}
+}
+
+// bulkResponse _bulk API 响应
+type bulkResponse struct {
+	Errors bool                                `json:"errors"`
+	Items  []map[string]bulkResponseItemResult `json:"items"`
+}
+
+// bulkResponseItemResult _bulk API 单条操作结果
+type bulkResponseItemResult struct {
+	Index  string          `json:"_index"`
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// NewElasticWriter 创建一个 Elasticsearch 日志写入器
+// config: 配置项（可选，传 nil 使用默认配置）
+func NewElasticWriter(config *ElasticConfig) (*ElasticWriter, error) {
+	if config == nil {
+		config = DefaultElasticConfig()
+	}
+	if config.URL == "" {
+		return nil, fmt.Errorf("elastic url is required")
+	}
+	if config.Index == "" && config.IndexPattern == "" {
+		return nil, fmt.Errorf("elastic index or index pattern is required")
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		client = &http.Client{Timeout: timeout}
+	}
+
+	serializer := config.Serializer
+	if serializer == nil {
+		serializer = JSONSerializer{}
+	}
+
+	w := &ElasticWriter{
+		client:       client,
+		bulkURL:      strings.TrimRight(config.URL, "/") + "/_bulk",
+		index:        config.Index,
+		indexPattern: config.IndexPattern,
+		headers:      config.Headers,
+		username:     config.Username,
+		password:     config.Password,
+		serializer:   serializer,
+		beforeWrite:  config.BeforeWrite,
+	}
+	w.batch = newBatcher(config.BufferSize, config.FlushInterval, config.MaxInFlight, w.writeEntries, config.OnError)
+	return w, nil
+}
+
+// indexFor 返回日志条目对应的索引名
+func (w *ElasticWriter) indexFor(entry LogEntry) string {
+	if w.indexPattern == "" {
+		return w.index
+	}
+	ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+	if err != nil {
+		ts = time.Now()
+	}
+	return ts.Format(w.indexPattern)
+}
+
+// AddEntry 添加一条日志到缓冲区
+// 写入器关闭后调用会丢弃该条目，并通过 OnError 回调返回 ErrWriterClosed
+func (w *ElasticWriter) AddEntry(entry LogEntry) {
+	if !applyBeforeWrite(w.beforeWrite, &entry) {
+		return
+	}
+	w.batch.add(entry)
+}
+
+// Log 写入日志（核心方法）
+func (w *ElasticWriter) Log(level string, content any, fields ...LogField) {
+	w.AddEntry(newLogEntry(level, content, fields))
+}
+
+// Named 返回带组件名的派生 Writer，与当前 Writer 共享缓冲区
+func (w *ElasticWriter) Named(component string) Writer {
+	return newDerivedWriter(w, component)
+}
+
+// With 返回附加默认字段的派生 Writer，每条日志都会带上这些字段（调用时传入的同名字段优先）
+func (w *ElasticWriter) With(fields ...LogField) Writer {
+	return newFieldsWriter(w, fields)
+}
+
+// Info 写入 info 级别日志
+func (w *ElasticWriter) Info(content any, fields ...LogField) {
+	w.Log("info", content, fields...)
+}
+
+// Error 写入 error 级别日志
+func (w *ElasticWriter) Error(content any, fields ...LogField) {
+	w.Log("error", content, fields...)
+}
+
+// Debug 写入 debug 级别日志
+func (w *ElasticWriter) Debug(content any, fields ...LogField) {
+	w.Log("debug", content, fields...)
+}
+
+// Warn 写入 warn 级别日志
+func (w *ElasticWriter) Warn(content any, fields ...LogField) {
+	w.Log("warn", content, fields...)
+}
+
+// Infof 写入 info 级别格式化日志
+func (w *ElasticWriter) Infof(format string, args ...any) {
+	w.Log("info", fmt.Sprintf(format, args...))
+}
+
+// Errorf 写入 error 级别格式化日志
+func (w *ElasticWriter) Errorf(format string, args ...any) {
+	w.Log("error", fmt.Sprintf(format, args...))
+}
+
+// Debugf 写入 debug 级别格式化日志
+func (w *ElasticWriter) Debugf(format string, args ...any) {
+	w.Log("debug", fmt.Sprintf(format, args...))
+}
+
+// Warnf 写入 warn 级别格式化日志
+func (w *ElasticWriter) Warnf(format string, args ...any) {
+	w.Log("warn", fmt.Sprintf(format, args...))
+}
+
+// Logf 写入格式化日志
+func (w *ElasticWriter) Logf(level string, format string, args ...any) {
+	w.Log(level, fmt.Sprintf(format, args...))
+}
+
+// Flush 将缓冲区中的日志异步提交到 Elasticsearch
+func (w *ElasticWriter) Flush() {
+	w.batch.flush()
+}
+
+// buildBulkBody 将日志条目编码为 _bulk API 所需的 NDJSON
+func (w *ElasticWriter) buildBulkBody(entries []LogEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		action := map[string]map[string]string{
+			"index": {"_index": w.indexFor(entry)},
+		}
+		actionJSON, err := json.Marshal(action)
+		if err != nil {
+			return nil, err
+		}
+		docJSON, err := w.serializer.Marshal(entry)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(actionJSON)
+		buf.WriteByte('\n')
+		buf.Write(docJSON)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// writeEntries 通过 _bulk API 批量写入日志条目
+func (w *ElasticWriter) writeEntries(entries []LogEntry) error {
+	body, err := w.buildBulkBody(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode bulk body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, w.bulkURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create bulk request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+	if w.username != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send bulk request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read bulk response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("bulk request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	return parseBulkResponse(respBody)
+}
+
+// parseBulkResponse 解析 _bulk 响应，汇总单条失败
+func parseBulkResponse(body []byte) error {
+	var result bulkResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("failed to decode bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+
+	var failures []string
+	for i, item := range result.Items {
+		for _, res := range item {
+			if res.Status >= 300 {
+				failures = append(failures, fmt.Sprintf("item %d (index %s, status %d): %s", i, res.Index, res.Status, string(res.Error)))
+			}
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("bulk request had %d failed items: %s", len(failures), strings.Join(failures, "; "))
+}
+
+// Close 关闭写入器，等待所有缓冲的日志写入完成
+// 关闭后的写入契约与 PostgresqlWriter.Close 相同
+func (w *ElasticWriter) Close() error {
+	return w.batch.close()
+}
@@ -0,0 +1,122 @@
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestElasticWriterBulkMixedResponse(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		lines []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" {
+			t.Errorf("path = %s, want /_bulk", r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("Content-Type = %s, want application/x-ndjson", ct)
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		mu.Unlock()
+		io.WriteString(w, `{"errors":true,"items":[
+			{"index":{"_index":"logs","status":201}},
+			{"index":{"_index":"logs","status":400,"error":{"type":"mapper_parsing_exception"}}}
+		]}`)
+	}))
+	defer srv.Close()
+
+	errs := make(chan error, 4)
+	w, err := NewElasticWriter(&ElasticConfig{
+		URL:           srv.URL,
+		Index:         "logs",
+		BufferSize:    10,
+		FlushInterval: time.Hour,
+		OnError:       func(err error) { errs <- err },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Info("ok", Field("status", 200))
+	w.Error("bad")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-errs:
+		msg := err.Error()
+		if !strings.Contains(msg, "1 failed items") || !strings.Contains(msg, "item 1") || !strings.Contains(msg, "mapper_parsing_exception") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	default:
+		t.Fatal("expected an error for the failed bulk item")
+	}
+	if len(errs) != 0 {
+		t.Errorf("got %d extra errors", len(errs))
+	}
+
+	if len(lines) != 4 {
+		t.Fatalf("got %d NDJSON lines, want 4: %q", len(lines), lines)
+	}
+	var action map[string]map[string]string
+	if err := json.Unmarshal([]byte(lines[0]), &action); err != nil || action["index"]["_index"] != "logs" {
+		t.Errorf("bad action line %q: %v", lines[0], err)
+	}
+	var doc LogEntry
+	if err := json.Unmarshal([]byte(lines[1]), &doc); err != nil {
+		t.Fatalf("bad document line %q: %v", lines[1], err)
+	}
+	if doc.Level != "info" || doc.Content != "ok" || doc.Fields["status"] != float64(200) {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+}
+
+func TestElasticWriterBulkAllSucceeded(t *testing.T) {
+	if err := parseBulkResponse([]byte(`{"errors":false,"items":[{"index":{"status":201}}]}`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestElasticWriterIndexPattern(t *testing.T) {
+	w := &ElasticWriter{indexPattern: "logs-2006.01.02"}
+	if got := w.indexFor(LogEntry{Timestamp: "2025-12-17T08:00:00Z"}); got != "logs-2025.12.17" {
+		t.Errorf("indexFor = %s, want logs-2025.12.17", got)
+	}
+}
+
+func TestElasticWriterHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	errs := make(chan error, 1)
+	w, err := NewElasticWriter(&ElasticConfig{URL: srv.URL, Index: "logs", OnError: func(err error) { errs <- err }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Info("hello")
+	w.Close()
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "status 503") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	default:
+		t.Fatal("expected an error for the 503 response")
+	}
+}
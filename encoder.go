@@ -0,0 +1,211 @@
+package writer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+// Encoder 控制台输出格式接口，将一条日志编码为一行（或多行）文本，不含末尾换行
+// caller 为调用位置（file:line），无法获取时为空字符串
+type Encoder interface {
+	Encode(entry LogEntry, caller string) []byte
+}
+
+// EncoderFunc 将普通函数适配为 Encoder
+type EncoderFunc func(entry LogEntry, caller string) []byte
+
+// Encode 实现 Encoder 接口
+func (f EncoderFunc) Encode(entry LogEntry, caller string) []byte {
+	return f(entry, caller)
+}
+
+// TextEncoder 默认的彩色文本格式：级别、时间、调用位置、内容、特殊字段，最后是按键名排序的普通字段
+type TextEncoder struct {
+	Pretty            bool   // 多行模式：首行输出级别、时间和内容，每个字段单独缩进一行并对齐键名
+	FieldSeparator    string // 字段之间以及内容与第一个字段之间的分隔符（默认空格），如 " | "
+	KeyValueDelimiter string // 键与值之间的分隔符（默认 =），如 :
+	FieldPrefix       string // 每个键名前的前缀（默认无），如 @；Pretty 模式下只有该项生效
+}
+
+// Encode 实现 Encoder 接口
+func (e TextEncoder) Encode(entry LogEntry, caller string) []byte {
+	levelColor := getLevelColor(entry.Level)
+	ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+	if err != nil {
+		ts = time.Now()
+	}
+
+	var parts []string
+	// 级别使用颜色
+	parts = append(parts, levelColor("[%s]", strings.ToUpper(entry.Level)))
+	// 时间戳使用灰色
+	timestampColor := color.New(color.FgHiBlack)
+	parts = append(parts, timestampColor.Sprint(ts.Format("2006-01-02 15:04:05.000")))
+	if caller != "" {
+		// caller 使用灰色
+		parts = append(parts, timestampColor.Sprint(caller))
+	}
+	parts = append(parts, entry.Content)
+
+	kvs := entryFields(entry)
+
+	// 字段使用青色
+	fieldColor := color.New(color.FgCyan)
+	if e.Pretty {
+		// 多行模式：首行为级别、时间和内容，之后每个字段单独一行，键名对齐
+		width := 0
+		for _, kv := range kvs {
+			width = max(width, len(e.FieldPrefix)+len(kv.Key))
+		}
+		lines := []string{strings.Join(parts, " ")}
+		for _, kv := range kvs {
+			key := fieldColor.Sprint(fmt.Sprintf("%-*s", width+1, e.FieldPrefix+kv.Key+":"))
+			lines = append(lines, fmt.Sprintf("    %s %s", key, formatFieldValue(kv.Value)))
+		}
+		return []byte(strings.Join(lines, "\n"))
+	}
+	if len(kvs) == 0 {
+		return []byte(strings.Join(parts, " "))
+	}
+	sep, delim := e.FieldSeparator, e.KeyValueDelimiter
+	if sep == "" {
+		sep = " "
+	}
+	if delim == "" {
+		delim = "="
+	}
+	fields := make([]string, 0, len(kvs))
+	for _, kv := range kvs {
+		fields = append(fields, fieldColor.Sprint(e.FieldPrefix+kv.Key+delim+formatFieldValue(kv.Value)))
+	}
+	return []byte(strings.Join(parts, " ") + sep + strings.Join(fields, sep))
+}
+
+// entryFields 按输出顺序展开日志条目的字段：特殊字段在前，普通字段按键名排序在后
+func entryFields(entry LogEntry) []LogField {
+	var kvs []LogField
+	if entry.EntryID != "" {
+		kvs = append(kvs, Field("entry_id", entry.EntryID))
+	}
+	if entry.Component != "" {
+		kvs = append(kvs, Field("component", entry.Component))
+	}
+	if entry.Trace != "" {
+		kvs = append(kvs, Field("trace", entry.Trace))
+	}
+	if entry.Span != "" {
+		kvs = append(kvs, Field("span", entry.Span))
+	}
+	if entry.Duration != "" {
+		kvs = append(kvs, Field("duration", entry.Duration))
+	}
+	if entry.LogType != "" {
+		kvs = append(kvs, Field("log_type", entry.LogType))
+	}
+	if entry.UserID != nil {
+		kvs = append(kvs, Field("user_id", *entry.UserID))
+	}
+	if entry.Username != "" {
+		kvs = append(kvs, Field("username", entry.Username))
+	}
+	if entry.ExpiresAt != "" {
+		kvs = append(kvs, Field("expires_at", entry.ExpiresAt))
+	}
+
+	keys := make([]string, 0, len(entry.Fields))
+	for k := range entry.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		kvs = append(kvs, Field(k, entry.Fields[k]))
+	}
+	return kvs
+}
+
+// JSONEncoder 每条日志输出为一行 JSON，字段与 LogEntry 的 JSON 结构一致，另加 caller
+type JSONEncoder struct{}
+
+// Encode 实现 Encoder 接口
+func (JSONEncoder) Encode(entry LogEntry, caller string) []byte {
+	type record struct {
+		LogEntry
+		Caller string `json:"caller,omitempty"`
+	}
+	data, err := json.Marshal(record{entry, caller})
+	if err != nil {
+		// fields 中含无法序列化的值时退化为字符串形式（复制后修改，不影响调用方的 map），保证日志不丢失
+		fields := make(map[string]interface{}, len(entry.Fields))
+		for k, v := range entry.Fields {
+			fields[k] = formatFieldValue(v)
+		}
+		entry.Fields = fields
+		data, _ = json.Marshal(record{entry, caller})
+	}
+	return data
+}
+
+// LogfmtEncoder logfmt 格式（level=info ts=... msg="..." trace=...），便于 Loki/Grafana 等工具解析
+// 同时实现 Encoder（ConsoleWriter）和 Serializer（FileWriter 等）接口；含空格、等号、引号或控制字符的值加引号并转义
+type LogfmtEncoder struct{}
+
+// Encode 实现 Encoder 接口
+func (e LogfmtEncoder) Encode(entry LogEntry, caller string) []byte {
+	var b strings.Builder
+	writeLogfmtPair(&b, "level", entry.Level)
+	writeLogfmtPair(&b, "ts", entry.Timestamp)
+	if caller != "" {
+		writeLogfmtPair(&b, "caller", caller)
+	}
+	writeLogfmtPair(&b, "msg", entry.Content)
+	for _, kv := range entryFields(entry) {
+		writeLogfmtPair(&b, kv.Key, formatFieldValue(kv.Value))
+	}
+	return []byte(b.String())
+}
+
+// Marshal 实现 Serializer 接口
+func (e LogfmtEncoder) Marshal(entry LogEntry) ([]byte, error) {
+	return e.Encode(entry, ""), nil
+}
+
+// writeLogfmtPair 写入一个 key=value，键名中的非法字符替换为下划线，值按需加引号
+func writeLogfmtPair(b *strings.Builder, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			b.WriteByte('_')
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('=')
+	if logfmtNeedsQuote(value) {
+		b.WriteString(strconv.Quote(value))
+	} else {
+		b.WriteString(value)
+	}
+}
+
+// logfmtNeedsQuote 判断值是否需要加引号：空字符串、含空白、等号、引号、反斜杠、控制字符或非法 UTF-8
+func logfmtNeedsQuote(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package writer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+// noColor 在测试期间关闭颜色输出，便于比较文本
+func noColor(t *testing.T) {
+	t.Helper()
+	prev := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() { color.NoColor = prev })
+}
+
+// textEntry 构造时间固定的日志条目
+func textEntry(content string, fields map[string]interface{}) LogEntry {
+	return LogEntry{Timestamp: "2024-05-01T10:00:00Z", Level: "info", Content: content, Trace: "t1", Fields: fields}
+}
+
+func TestTextEncoderPretty(t *testing.T) {
+	noColor(t)
+	entry := textEntry("request done", map[string]interface{}{"status": 200, "path": "/users", "latency_ms": 12})
+	got := string(TextEncoder{Pretty: true}.Encode(entry, "main.go:10"))
+	want := "[INFO] 2024-05-01 10:00:00.000 main.go:10 request done\n" +
+		"    trace:      t1\n" +
+		"    latency_ms: 12\n" +
+		"    path:       /users\n" +
+		"    status:     200"
+	if got != want {
+		t.Errorf("pretty output:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := string(TextEncoder{Pretty: true}.Encode(LogEntry{Timestamp: entry.Timestamp, Level: "warn", Content: "bare"}, "")); got != "[WARN] 2024-05-01 10:00:00.000 bare" {
+		t.Errorf("pretty output without fields = %q", got)
+	}
+}
+
+func TestTextEncoderNestedFieldsAsJSON(t *testing.T) {
+	noColor(t)
+	entry := textEntry("request", map[string]interface{}{"http": map[string]any{"status": 200, "method": "GET"}, "tags": []string{"a", "b"}})
+	entry.Trace = ""
+	got := string(TextEncoder{}.Encode(entry, ""))
+	want := `[INFO] 2024-05-01 10:00:00.000 request http={"method":"GET","status":200} tags=["a","b"]`
+	if got != want {
+		t.Errorf("output = %s, want %s", got, want)
+	}
+}
+
+func TestLogfmtEncoderQuoting(t *testing.T) {
+	entry := textEntry(`said "hi" to bob`, map[string]interface{}{
+		"query":   "a=b",
+		"path":    "/users",
+		"empty":   "",
+		"note":    "two words",
+		"bad key": "x",
+	})
+	got := string(LogfmtEncoder{}.Encode(entry, "main.go:10"))
+	want := `level=info ts=2024-05-01T10:00:00Z caller=main.go:10 msg="said \"hi\" to bob" trace=t1 bad_key=x empty="" note="two words" path=/users query="a=b"`
+	if got != want {
+		t.Errorf("logfmt:\n%s\nwant:\n%s", got, want)
+	}
+
+	data, err := LogfmtEncoder{}.Marshal(LogEntry{Timestamp: entry.Timestamp, Level: "warn", Content: "line1\nline2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `level=warn ts=2024-05-01T10:00:00Z msg="line1\nline2"`; string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+}
+
+func TestTextEncoderCustomSeparators(t *testing.T) {
+	noColor(t)
+	entry := textEntry("request", map[string]interface{}{"status": 200, "path": "/users"})
+	got := string(TextEncoder{FieldSeparator: " | ", KeyValueDelimiter: ":", FieldPrefix: "@"}.Encode(entry, ""))
+	want := "[INFO] 2024-05-01 10:00:00.000 request | @trace:t1 | @path:/users | @status:200"
+	if got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	// 默认仍为空格分隔的 k=v
+	if got := string(TextEncoder{}.Encode(entry, "")); got != "[INFO] 2024-05-01 10:00:00.000 request trace=t1 path=/users status=200" {
+		t.Errorf("default output = %q", got)
+	}
+}
+
+func TestConsoleFieldSeparatorConfig(t *testing.T) {
+	noColor(t)
+	output := captureOutput(t)
+	NewConsoleWriterWithConfig(&ConsoleConfig{FieldSeparator: " | ", KeyValueDelimiter: ":"}).Info("saved", Field("id", 7))
+	if got := output(); !strings.HasSuffix(got, " saved | id:7\n") {
+		t.Errorf("console output = %q, want fields joined with the configured separators", got)
+	}
+}
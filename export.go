@@ -0,0 +1,78 @@
+package writer
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// exportHeader 导出文件的表头，fields 以 JSON 文本输出
+var exportHeader = []string{"timestamp", "level", "content", "log_type", "duration", "trace", "span", "user_id", "username", "component", "entry_id", "expires_at", "fields"}
+
+// ExportCSV 将符合条件的日志以 CSV 格式流式写入 out（首行为表头）
+// 含逗号、引号、换行的内容按 RFC 4180 转义；数据库执行器需实现 DBQuerier 接口
+func (w *PostgresqlWriter) ExportCSV(ctx context.Context, opts QueryOptions, out io.Writer) error {
+	return w.export(ctx, opts, out, ',')
+}
+
+// ExportTSV 与 ExportCSV 相同，但以制表符分隔
+func (w *PostgresqlWriter) ExportTSV(ctx context.Context, opts QueryOptions, out io.Writer) error {
+	return w.export(ctx, opts, out, '\t')
+}
+
+// export 按指定分隔符导出日志
+func (w *PostgresqlWriter) export(ctx context.Context, opts QueryOptions, out io.Writer, comma rune) error {
+	cw := csv.NewWriter(out)
+	cw.Comma = comma
+
+	if err := cw.Write(exportHeader); err != nil {
+		return fmt.Errorf("failed to write export header: %w", err)
+	}
+	err := w.queryEach(ctx, opts, func(entry LogEntry) error {
+		record, err := exportRecord(entry)
+		if err != nil {
+			return err
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportRecord 将日志条目展开为一行，列顺序与 exportHeader 一致
+func exportRecord(entry LogEntry) ([]string, error) {
+	userID := ""
+	if entry.UserID != nil {
+		userID = strconv.FormatInt(*entry.UserID, 10)
+	}
+	fields := ""
+	if len(entry.Fields) > 0 {
+		data, err := json.Marshal(entry.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode fields: %w", err)
+		}
+		fields = string(data)
+	}
+	return []string{
+		entry.Timestamp,
+		entry.Level,
+		entry.Content,
+		entry.LogType,
+		entry.Duration,
+		entry.Trace,
+		entry.Span,
+		userID,
+		entry.Username,
+		entry.Component,
+		entry.EntryID,
+		entry.ExpiresAt,
+		fields,
+	}, nil
+}
@@ -0,0 +1,78 @@
+package writer
+
+import (
+	"context"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportCSVEscaping(t *testing.T) {
+	ts := time.Date(2025, 12, 17, 8, 0, 0, 0, time.UTC)
+	db := &queryDB{queryFunc: func(sql string, args []any) ([][]any, error) {
+		return [][]any{
+			logRow(ts, "error", "said \"no\", then left\nsecond line", `{"path":"/a,b"}`),
+			logRow(ts, "info", "plain", ""),
+		}, nil
+	}}
+	w := newTestWriter(t, db, nil)
+
+	var out strings.Builder
+	if err := w.ExportCSV(context.Background(), QueryOptions{Levels: []string{"error", "info"}}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"said ""no"", then left`+"\nsecond line\"") {
+		t.Errorf("content not quoted per RFC 4180:\n%s", out.String())
+	}
+
+	records, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || !reflect.DeepEqual(records[0], exportHeader) {
+		t.Fatalf("got %d records with header %v", len(records), records[0])
+	}
+	col := func(name string) int {
+		for i, h := range exportHeader {
+			if h == name {
+				return i
+			}
+		}
+		t.Fatalf("no %s column", name)
+		return -1
+	}
+	row := records[1]
+	if row[col("content")] != "said \"no\", then left\nsecond line" {
+		t.Errorf("content round trip = %q", row[col("content")])
+	}
+	if row[col("fields")] != `{"path":"/a,b"}` || records[2][col("fields")] != "" {
+		t.Errorf("fields = %q, %q", row[col("fields")], records[2][col("fields")])
+	}
+	if row[col("timestamp")] != "2025-12-17T08:00:00Z" {
+		t.Errorf("timestamp = %q", row[col("timestamp")])
+	}
+}
+
+func TestExportTSV(t *testing.T) {
+	db := &queryDB{queryFunc: func(sql string, args []any) ([][]any, error) {
+		return [][]any{logRow(time.Now(), "info", "a\tb", "")}, nil
+	}}
+	w := newTestWriter(t, db, nil)
+	var out strings.Builder
+	if err := w.ExportTSV(context.Background(), QueryOptions{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "timestamp\tlevel\t") || !strings.Contains(lines[1], "\"a\tb\"") {
+		t.Errorf("unexpected TSV output:\n%s", out.String())
+	}
+}
+
+func TestExportRequiresQuerier(t *testing.T) {
+	w := newTestWriter(t, &mockDB{}, nil)
+	if err := w.ExportCSV(context.Background(), QueryOptions{}, &strings.Builder{}); err == nil || !strings.Contains(err.Error(), "DBQuerier") {
+		t.Errorf("err = %v, want DBQuerier error", err)
+	}
+}
@@ -0,0 +1,274 @@
+package writer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileConfig 文件 Writer 配置
+type FileConfig struct {
+	Path          string                     `json:"path"`           // 日志文件路径（必填）
+	Perm          os.FileMode                `json:"perm"`           // 新建文件的权限（默认 0644）
+	BufferBytes   int                        `json:"buffer_bytes"`   // 写缓冲区字节数（默认 64KB）
+	FlushInterval time.Duration              `json:"flush_interval"` // 刷新间隔
+	Serializer    Serializer                 `json:"-"`              // 日志序列化方式（可选，默认 JSON，每条一行）
+	BeforeWrite   func(entry *LogEntry) bool `json:"-"`              // 写入前的钩子（可选），可修改条目，返回 false 丢弃该条日志
+	OnError       func(err error)            `json:"-"`              // 写入失败回调（可选）
+}
+
+// DefaultFileConfig 返回默认文件配置（Path 需由调用方设置）
+func DefaultFileConfig() *FileConfig {
+	return &FileConfig{
+		Perm:          0644,
+		BufferBytes:   64 * 1024,
+		FlushInterval: time.Second,
+	}
+}
+
+// FileWriter 将日志按行追加写入文件
+// 不自行轮转文件，可配合系统 logrotate：轮转后调用 Reopen（通常在收到 SIGHUP 时）
+type FileWriter struct {
+	path          string
+	perm          os.FileMode
+	flushInterval time.Duration
+	serializer    Serializer
+	onError       func(err error)
+	beforeWrite   func(entry *LogEntry) bool
+
+	file    *os.File
+	buf     *bufio.Writer
+	fileMux sync.Mutex // 保护 file、buf 和 closed
+	closed  bool
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewFileWriter 创建一个文件日志写入器，文件不存在时自动创建
+func NewFileWriter(config *FileConfig) (*FileWriter, error) {
+	if config == nil || config.Path == "" {
+		return nil, fmt.Errorf("log file path is required")
+	}
+
+	defaults := DefaultFileConfig()
+	perm := config.Perm
+	if perm == 0 {
+		perm = defaults.Perm
+	}
+	bufferBytes := config.BufferBytes
+	if bufferBytes <= 0 {
+		bufferBytes = defaults.BufferBytes
+	}
+	flushInterval := config.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaults.FlushInterval
+	}
+	serializer := config.Serializer
+	if serializer == nil {
+		serializer = JSONSerializer{}
+	}
+
+	w := &FileWriter{
+		path:          config.Path,
+		perm:          perm,
+		flushInterval: flushInterval,
+		serializer:    serializer,
+		onError:       config.OnError,
+		beforeWrite:   config.BeforeWrite,
+		done:          make(chan struct{}),
+	}
+
+	file, err := w.openFile()
+	if err != nil {
+		return nil, err
+	}
+	w.file = file
+	w.buf = bufio.NewWriterSize(file, bufferBytes)
+
+	// 启动后台刷新协程
+	w.wg.Add(1)
+	go w.flushLoop()
+
+	return w, nil
+}
+
+// openFile 以追加方式打开配置的文件
+func (w *FileWriter) openFile() (*os.File, error) {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, w.perm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	return file, nil
+}
+
+// AddEntry 写入一条日志到文件缓冲区
+// 写入器关闭后调用会丢弃该条目，并通过 OnError 回调返回 ErrWriterClosed
+func (w *FileWriter) AddEntry(entry LogEntry) {
+	if !applyBeforeWrite(w.beforeWrite, &entry) {
+		return
+	}
+	line, err := w.serializer.Marshal(entry)
+	if err != nil {
+		w.handleError(fmt.Errorf("failed to marshal log entry: %w", err))
+		return
+	}
+
+	w.fileMux.Lock()
+	defer w.fileMux.Unlock()
+	if w.closed {
+		w.handleError(ErrWriterClosed)
+		return
+	}
+	if _, err := w.buf.Write(append(line, '\n')); err != nil {
+		w.handleError(fmt.Errorf("failed to write log file: %w", err))
+	}
+}
+
+// Log 写入日志（核心方法）
+func (w *FileWriter) Log(level string, content any, fields ...LogField) {
+	w.AddEntry(newLogEntry(level, content, fields))
+}
+
+// Named 返回带组件名的派生 Writer，与当前 Writer 共享文件
+func (w *FileWriter) Named(component string) Writer {
+	return newDerivedWriter(w, component)
+}
+
+// With 返回附加默认字段的派生 Writer，每条日志都会带上这些字段（调用时传入的同名字段优先）
+func (w *FileWriter) With(fields ...LogField) Writer {
+	return newFieldsWriter(w, fields)
+}
+
+// Info 写入 info 级别日志
+func (w *FileWriter) Info(content any, fields ...LogField) {
+	w.Log("info", content, fields...)
+}
+
+// Error 写入 error 级别日志
+func (w *FileWriter) Error(content any, fields ...LogField) {
+	w.Log("error", content, fields...)
+}
+
+// Debug 写入 debug 级别日志
+func (w *FileWriter) Debug(content any, fields ...LogField) {
+	w.Log("debug", content, fields...)
+}
+
+// Warn 写入 warn 级别日志
+func (w *FileWriter) Warn(content any, fields ...LogField) {
+	w.Log("warn", content, fields...)
+}
+
+// Infof 写入 info 级别格式化日志
+func (w *FileWriter) Infof(format string, args ...any) {
+	w.Log("info", fmt.Sprintf(format, args...))
+}
+
+// Errorf 写入 error 级别格式化日志
+func (w *FileWriter) Errorf(format string, args ...any) {
+	w.Log("error", fmt.Sprintf(format, args...))
+}
+
+// Debugf 写入 debug 级别格式化日志
+func (w *FileWriter) Debugf(format string, args ...any) {
+	w.Log("debug", fmt.Sprintf(format, args...))
+}
+
+// Warnf 写入 warn 级别格式化日志
+func (w *FileWriter) Warnf(format string, args ...any) {
+	w.Log("warn", fmt.Sprintf(format, args...))
+}
+
+// Logf 写入格式化日志
+func (w *FileWriter) Logf(level string, format string, args ...any) {
+	w.Log(level, fmt.Sprintf(format, args...))
+}
+
+// flushLoop 后台定时刷新协程
+func (w *FileWriter) flushLoop() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.Flush()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Flush 将缓冲区写入文件
+func (w *FileWriter) Flush() {
+	w.fileMux.Lock()
+	defer w.fileMux.Unlock()
+	if w.closed {
+		return
+	}
+	if err := w.buf.Flush(); err != nil {
+		w.handleError(fmt.Errorf("failed to flush log file: %w", err))
+	}
+}
+
+// Reopen 刷新缓冲区后关闭当前文件，并重新打开配置的路径
+// 外部 logrotate 重命名文件后调用，之后的日志写入原路径下的新文件
+func (w *FileWriter) Reopen() error {
+	w.fileMux.Lock()
+	defer w.fileMux.Unlock()
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	flushErr := w.buf.Flush()
+	file, err := w.openFile()
+	if err != nil {
+		// 无法打开新文件时继续写入旧文件，避免丢失日志
+		return err
+	}
+	closeErr := w.file.Close()
+	w.file = file
+	w.buf.Reset(file)
+
+	if flushErr != nil {
+		return fmt.Errorf("failed to flush log file before reopen: %w", flushErr)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to close rotated log file: %w", closeErr)
+	}
+	return nil
+}
+
+// handleError 将错误交给 OnError 回调
+func (w *FileWriter) handleError(err error) {
+	if w.onError != nil {
+		w.onError(err)
+	}
+}
+
+// Close 关闭写入器，刷新缓冲区后关闭文件
+// 关闭后的写入契约与 PostgresqlWriter.Close 相同
+func (w *FileWriter) Close() error {
+	w.fileMux.Lock()
+	if w.closed {
+		w.fileMux.Unlock()
+		return ErrWriterClosed
+	}
+	w.closed = true
+	w.fileMux.Unlock()
+
+	close(w.done)
+	w.wg.Wait()
+
+	w.fileMux.Lock()
+	defer w.fileMux.Unlock()
+	flushErr := w.buf.Flush()
+	closeErr := w.file.Close()
+	if flushErr != nil {
+		return fmt.Errorf("failed to flush log file: %w", flushErr)
+	}
+	return closeErr
+}
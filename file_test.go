@@ -0,0 +1,66 @@
+package writer
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fileContents 返回文件中每行日志的 content
+func fileContents(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contents []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry LogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("bad line %q: %v", line, err)
+		}
+		contents = append(contents, entry.Content)
+	}
+	return contents
+}
+
+func TestFileWriterReopenAfterRename(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	rotated := filepath.Join(dir, "app.log.1")
+	w, err := NewFileWriter(&FileConfig{Path: path, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Info("before rotation")
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatal(err)
+	}
+	// 重命名后、Reopen 前的日志仍写入旧 inode（即轮转后的文件）
+	w.Info("during rotation")
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	w.Info("after rotation")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(fileContents(t, rotated), ","); got != "before rotation,during rotation" {
+		t.Errorf("rotated file = %s", got)
+	}
+	if got := strings.Join(fileContents(t, path), ","); got != "after rotation" {
+		t.Errorf("new file = %s", got)
+	}
+	if err := w.Reopen(); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("Reopen after Close = %v, want ErrWriterClosed", err)
+	}
+}
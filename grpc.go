@@ -0,0 +1,303 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// GRPCLogStream 客户端流式 RPC 的最小发送接口
+// 用户将生成的客户端流（如 LogService_PushClient）适配为此接口，包本身不依赖生成代码
+type GRPCLogStream interface {
+	// Send 发送一条日志
+	Send(entry LogEntry) error
+	// Close 结束流（通常调用生成代码的 CloseAndRecv）
+	Close() error
+}
+
+// GRPCRawLogStream 可选接口：发送已由 Serializer 编码的字节（如 msgpack、CBOR 或 protobuf 的 bytes 字段）
+// 配置了 GRPCConfig.Serializer 时，Open 返回的流必须实现此接口，日志改为编码后通过 SendRaw 发送
+type GRPCRawLogStream interface {
+	GRPCLogStream
+	// SendRaw 发送一条已编码的日志
+	SendRaw(data []byte) error
+}
+
+// GRPCStreamOpener 打开一个新的日志流，首次写入及流出错后重连时调用
+// ctx 在写入器关闭前一直有效，应直接传给生成代码的流式方法
+type GRPCStreamOpener func(ctx context.Context) (GRPCLogStream, error)
+
+// GRPCConfig gRPC Writer 配置
+type GRPCConfig struct {
+	Open          GRPCStreamOpener `json:"-"`              // 打开日志流（必填）
+	BufferSize    int              `json:"buffer_size"`    // 缓冲区大小
+	FlushInterval time.Duration    `json:"flush_interval"` // 刷新间隔
+	MaxReconnects int              `json:"max_reconnects"` // 单批次发送失败后的最大重连次数
+	// ReconnectBackoff 重连前的等待时间（默认 100ms），连续失败时逐次翻倍，不超过 MaxReconnectBackoff；发送成功后重新计算
+	ReconnectBackoff    time.Duration              `json:"reconnect_backoff"`
+	MaxReconnectBackoff time.Duration              `json:"max_reconnect_backoff"` // 重连等待时间的上限（默认 10 秒）
+	Serializer          Serializer                 `json:"-"`                     // 日志编码方式（可选）；设置后流须实现 GRPCRawLogStream，日志编码后经 SendRaw 发送
+	BeforeWrite         func(entry *LogEntry) bool `json:"-"`                     // 写入前的钩子（可选），可修改条目，返回 false 丢弃该条日志
+	OnError             func(err error)            `json:"-"`                     // 写入失败回调（可选）
+}
+
+// DefaultGRPCConfig 返回默认 gRPC 配置（Open 需由调用方设置）
+func DefaultGRPCConfig() *GRPCConfig {
+	return &GRPCConfig{
+		BufferSize:          100,
+		FlushInterval:       5 * time.Second,
+		MaxReconnects:       1,
+		ReconnectBackoff:    defaultReconnectBackoff,
+		MaxReconnectBackoff: defaultMaxReconnectBackoff,
+	}
+}
+
+const (
+	// defaultReconnectBackoff 默认首次重连前的等待时间
+	defaultReconnectBackoff = 100 * time.Millisecond
+	// defaultMaxReconnectBackoff 默认重连等待时间的上限
+	defaultMaxReconnectBackoff = 10 * time.Second
+)
+
+// GRPCWriter 通过客户端流式 RPC 发送日志
+type GRPCWriter struct {
+	open          GRPCStreamOpener
+	maxReconnects int
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	serializer    Serializer
+	beforeWrite   func(entry *LogEntry) bool
+
+	// ctx 为日志流的生命周期上下文，Close 时取消
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	stream    GRPCLogStream
+	backoff   time.Duration // 由 streamMux 保护，下次重连前的等待时间，0 表示上次发送成功
+	streamMux sync.Mutex    // 保护 stream（发送协程与 Close 之间）
+
+	// batch 只允许一个批次在发送，批次按取出缓冲区的顺序依次发送
+	batch *batcher
+}
+
+// NewGRPCWriter 创建一个 gRPC 日志写入器
+// 流在首次发送时打开，构造时不进行网络调用
+func NewGRPCWriter(config *GRPCConfig) (*GRPCWriter, error) {
+	if config == nil || config.Open == nil {
+		return nil, fmt.Errorf("grpc stream opener is required")
+	}
+
+	maxReconnects := config.MaxReconnects
+	if maxReconnects < 0 {
+		maxReconnects = 0
+	}
+	minBackoff := config.ReconnectBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultReconnectBackoff
+	}
+	maxBackoff := config.MaxReconnectBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxReconnectBackoff
+	}
+	maxBackoff = max(maxBackoff, minBackoff)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w := &GRPCWriter{
+		open:          config.Open,
+		maxReconnects: maxReconnects,
+		minBackoff:    minBackoff,
+		maxBackoff:    maxBackoff,
+		serializer:    config.Serializer,
+		ctx:           ctx,
+		cancel:        cancel,
+		beforeWrite:   config.BeforeWrite,
+	}
+	w.batch = newBatcher(config.BufferSize, config.FlushInterval, 1, w.sendEntries, config.OnError)
+	return w, nil
+}
+
+// AddEntry 添加一条日志到缓冲区
+// 写入器关闭后调用会丢弃该条目，并通过 OnError 回调返回 ErrWriterClosed
+func (w *GRPCWriter) AddEntry(entry LogEntry) {
+	if !applyBeforeWrite(w.beforeWrite, &entry) {
+		return
+	}
+	w.batch.add(entry)
+}
+
+// Log 写入日志（核心方法）
+func (w *GRPCWriter) Log(level string, content any, fields ...LogField) {
+	w.AddEntry(newLogEntry(level, content, fields))
+}
+
+// Named 返回带组件名的派生 Writer，与当前 Writer 共享缓冲区
+func (w *GRPCWriter) Named(component string) Writer {
+	return newDerivedWriter(w, component)
+}
+
+// With 返回附加默认字段的派生 Writer，每条日志都会带上这些字段（调用时传入的同名字段优先）
+func (w *GRPCWriter) With(fields ...LogField) Writer {
+	return newFieldsWriter(w, fields)
+}
+
+// Info 写入 info 级别日志
+func (w *GRPCWriter) Info(content any, fields ...LogField) {
+	w.Log("info", content, fields...)
+}
+
+// Error 写入 error 级别日志
+func (w *GRPCWriter) Error(content any, fields ...LogField) {
+	w.Log("error", content, fields...)
+}
+
+// Debug 写入 debug 级别日志
+func (w *GRPCWriter) Debug(content any, fields ...LogField) {
+	w.Log("debug", content, fields...)
+}
+
+// Warn 写入 warn 级别日志
+func (w *GRPCWriter) Warn(content any, fields ...LogField) {
+	w.Log("warn", content, fields...)
+}
+
+// Infof 写入 info 级别格式化日志
+func (w *GRPCWriter) Infof(format string, args ...any) {
+	w.Log("info", fmt.Sprintf(format, args...))
+}
+
+// Errorf 写入 error 级别格式化日志
+func (w *GRPCWriter) Errorf(format string, args ...any) {
+	w.Log("error", fmt.Sprintf(format, args...))
+}
+
+// Debugf 写入 debug 级别格式化日志
+func (w *GRPCWriter) Debugf(format string, args ...any) {
+	w.Log("debug", fmt.Sprintf(format, args...))
+}
+
+// Warnf 写入 warn 级别格式化日志
+func (w *GRPCWriter) Warnf(format string, args ...any) {
+	w.Log("warn", fmt.Sprintf(format, args...))
+}
+
+// Logf 写入格式化日志
+func (w *GRPCWriter) Logf(level string, format string, args ...any) {
+	w.Log(level, fmt.Sprintf(format, args...))
+}
+
+// Flush 将缓冲区中的日志异步发送到日志流
+func (w *GRPCWriter) Flush() {
+	w.batch.flush()
+}
+
+// sendEntries 按顺序发送日志，流出错时关闭并按指数退避重连，最多重连 maxReconnects 次
+// 同一批次中失败的条目在重连后从该条重新发送，批次之间也只有一个在发送，因此日志按写入顺序到达
+// 配置了 Serializer 时先逐条编码，编码失败的条目跳过并在返回的错误中报告
+func (w *GRPCWriter) sendEntries(entries []LogEntry) error {
+	var payloads [][]byte
+	var errs []error
+	if w.serializer != nil {
+		payloads = make([][]byte, 0, len(entries))
+		for _, entry := range entries {
+			data, err := w.serializer.Marshal(entry)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to encode log entry: %w", err))
+				continue
+			}
+			payloads = append(payloads, data)
+		}
+	}
+	total := len(entries)
+	if payloads != nil {
+		total = len(payloads)
+	}
+
+	w.streamMux.Lock()
+	defer w.streamMux.Unlock()
+
+	reconnects := 0
+	for i := 0; i < total; {
+		if w.stream == nil {
+			stream, err := w.open(w.ctx)
+			if err != nil {
+				if reconnects < w.maxReconnects {
+					reconnects++
+					w.waitBackoff()
+					continue
+				}
+				w.growBackoff()
+				errs = append(errs, fmt.Errorf("failed to open grpc stream, %d entries not sent: %w", total-i, err))
+				return errors.Join(errs...)
+			}
+			if _, ok := stream.(GRPCRawLogStream); payloads != nil && !ok {
+				_ = stream.Close()
+				errs = append(errs, fmt.Errorf("grpc stream %T does not implement GRPCRawLogStream required by Serializer, %d entries not sent", stream, total-i))
+				return errors.Join(errs...)
+			}
+			w.stream = stream
+		}
+
+		var err error
+		if payloads != nil {
+			err = w.stream.(GRPCRawLogStream).SendRaw(payloads[i])
+		} else {
+			err = w.stream.Send(entries[i])
+		}
+		if err != nil {
+			_ = w.stream.Close()
+			w.stream = nil
+			if reconnects < w.maxReconnects {
+				reconnects++
+				w.waitBackoff()
+				continue
+			}
+			w.growBackoff()
+			errs = append(errs, fmt.Errorf("failed to send to grpc stream, %d entries not sent: %w", total-i, err))
+			return errors.Join(errs...)
+		}
+		w.backoff = 0
+		i++
+	}
+	return errors.Join(errs...)
+}
+
+// waitBackoff 重连前等待当前退避时间，随后将其翻倍；写入器关闭时立即返回（调用时已持有 streamMux）
+func (w *GRPCWriter) waitBackoff() {
+	w.growBackoff()
+	timer := time.NewTimer(w.backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-w.ctx.Done():
+	}
+}
+
+// growBackoff 将退避时间推进到下一级：首次为 minBackoff，之后翻倍直到 maxBackoff（调用时已持有 streamMux）
+// 放弃批次时同样推进，下游持续不可用时后续批次的重连间隔继续增长
+func (w *GRPCWriter) growBackoff() {
+	if w.backoff == 0 {
+		w.backoff = w.minBackoff
+		return
+	}
+	w.backoff = min(2*w.backoff, w.maxBackoff)
+}
+
+// Close 关闭写入器，发送所有缓冲的日志后关闭日志流
+// 关闭后的写入契约与 PostgresqlWriter.Close 相同
+func (w *GRPCWriter) Close() error {
+	if err := w.batch.close(); err != nil {
+		return err
+	}
+
+	w.streamMux.Lock()
+	defer w.streamMux.Unlock()
+	defer w.cancel()
+	if w.stream == nil {
+		return nil
+	}
+	err := w.stream.Close()
+	w.stream = nil
+	return err
+}
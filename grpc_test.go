@@ -0,0 +1,198 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStream 测试用的日志流，记录发送的条目，failAfter 大于 0 时第 failAfter 次发送失败
+type fakeStream struct {
+	mu        sync.Mutex
+	sent      []LogEntry
+	raw       [][]byte
+	failAfter int
+	sends     int
+	closed    bool
+}
+
+func (s *fakeStream) Send(entry LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sends++
+	if s.failAfter > 0 && s.sends == s.failAfter {
+		return errors.New("stream broken")
+	}
+	s.sent = append(s.sent, entry)
+	return nil
+}
+
+func (s *fakeStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+// rawStream 实现 GRPCRawLogStream 的测试流
+type rawStream struct {
+	fakeStream
+}
+
+func (s *rawStream) SendRaw(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.raw = append(s.raw, data)
+	return nil
+}
+
+func TestGRPCWriterSerializerRoundTrip(t *testing.T) {
+	stream := &rawStream{}
+	w, err := NewGRPCWriter(&GRPCConfig{
+		Open:       func(ctx context.Context) (GRPCLogStream, error) { return stream, nil },
+		Serializer: gobSerializer,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := sampleEntry()
+	w.AddEntry(entry)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.sent) != 0 || len(stream.raw) != 1 {
+		t.Fatalf("sent %d entries and %d payloads, want 0 and 1", len(stream.sent), len(stream.raw))
+	}
+	if got := decodeGob(t, stream.raw[0]); !reflect.DeepEqual(got, entry) {
+		t.Errorf("decoded = %+v, want %+v", got, entry)
+	}
+}
+
+func TestGRPCWriterSerializerRequiresRawStream(t *testing.T) {
+	errs := make(chan error, 1)
+	w, err := NewGRPCWriter(&GRPCConfig{
+		Open:       func(ctx context.Context) (GRPCLogStream, error) { return &fakeStream{}, nil },
+		Serializer: JSONSerializer{},
+		OnError:    func(err error) { errs <- err },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Info("hello")
+	w.Close()
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "GRPCRawLogStream") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	default:
+		t.Fatal("expected an error for a stream without SendRaw")
+	}
+}
+
+func TestGRPCWriterReconnectPreservesOrder(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		streams []*fakeStream
+	)
+	w, err := NewGRPCWriter(&GRPCConfig{
+		Open: func(ctx context.Context) (GRPCLogStream, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			// 第一个流在第 3 次发送时断开，之后的流正常
+			s := &fakeStream{}
+			if len(streams) == 0 {
+				s.failAfter = 3
+			}
+			streams = append(streams, s)
+			return s, nil
+		},
+		BufferSize:       4,
+		MaxReconnects:    1,
+		ReconnectBackoff: time.Millisecond,
+		OnError:          func(err error) { t.Errorf("unexpected error: %v", err) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		w.Info(strconv.Itoa(i))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(streams) != 2 || !streams[0].closed || !streams[1].closed {
+		t.Fatalf("opened %d streams, want 2 and both closed", len(streams))
+	}
+	var got []string
+	for _, s := range streams {
+		for _, entry := range s.sent {
+			got = append(got, entry.Content)
+		}
+	}
+	if want := "0,1,2,3,4,5,6,7,8,9"; strings.Join(got, ",") != want {
+		t.Errorf("received %s, want %s", strings.Join(got, ","), want)
+	}
+	if w.backoff != 0 {
+		t.Errorf("backoff = %s after successful sends, want reset to 0", w.backoff)
+	}
+}
+
+func TestGRPCWriterReconnectBackoff(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		opens []time.Time
+	)
+	errs := make(chan error, 1)
+	w, err := NewGRPCWriter(&GRPCConfig{
+		Open: func(ctx context.Context) (GRPCLogStream, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			opens = append(opens, time.Now())
+			return nil, errors.New("connection refused")
+		},
+		MaxReconnects:       3,
+		ReconnectBackoff:    10 * time.Millisecond,
+		MaxReconnectBackoff: 25 * time.Millisecond,
+		OnError:             func(err error) { errs <- err },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Info("hello")
+	w.Close()
+
+	if len(opens) != 4 {
+		t.Fatalf("opened %d times, want 1 + 3 reconnects", len(opens))
+	}
+	// 等待时间依次为 10ms、20ms、25ms（上限）
+	for i, want := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 25 * time.Millisecond} {
+		if gap := opens[i+1].Sub(opens[i]); gap < want {
+			t.Errorf("reconnect %d after %s, want at least %s", i+1, gap, want)
+		}
+	}
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "1 entries not sent") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	default:
+		t.Fatal("expected an error after the reconnects were exhausted")
+	}
+}
+
+func TestGRPCWriterBackoffGrowth(t *testing.T) {
+	w := &GRPCWriter{minBackoff: time.Millisecond, maxBackoff: 4 * time.Millisecond}
+	for _, want := range []time.Duration{1, 2, 4, 4} {
+		w.growBackoff()
+		if w.backoff != want*time.Millisecond {
+			t.Errorf("backoff = %s, want %s", w.backoff, want*time.Millisecond)
+		}
+	}
+}
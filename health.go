@@ -0,0 +1,52 @@
+package writer
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// IsHealthy 返回最近一次健康检查是否成功
+// 未设置 HealthCheckInterval 时始终返回 true
+func (w *PostgresqlWriter) IsHealthy() bool {
+	return w.healthy.Load()
+}
+
+// healthLoop 后台健康检查协程，定期 Ping 数据库并在状态变化时通知
+func (w *PostgresqlWriter) healthLoop() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.checkHealth()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// checkHealth 执行一次健康检查：失败时标记为不健康并回调错误，恢复时写入一条 info 日志
+func (w *PostgresqlWriter) checkHealth() {
+	ctx, cancel := context.WithTimeout(context.Background(), w.healthCheckInterval)
+	err := w.db.Ping(ctx)
+	cancel()
+
+	if err != nil {
+		// 只在由健康变为不健康时通知一次，避免故障期间每个周期重复回调
+		if w.healthy.CompareAndSwap(true, false) {
+			w.handleError(fmt.Errorf("database health check failed: %w", err))
+		}
+		return
+	}
+	if w.healthy.CompareAndSwap(false, true) {
+		w.AddEntry(LogEntry{
+			Timestamp: time.Now().Format(timestampLayout),
+			Level:     "info",
+			Content:   "database connection recovered",
+			LogType:   "system",
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flappingDB Ping 结果可在运行中切换的 mockDB
+type flappingDB struct {
+	mockDB
+	down  atomic.Bool
+	pings atomic.Int64
+}
+
+func (d *flappingDB) Ping(ctx context.Context) error {
+	d.pings.Add(1)
+	if d.down.Load() {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestHealthCheckFlapping(t *testing.T) {
+	db := &flappingDB{}
+	var mu sync.Mutex
+	var errs []error
+	w := newTestWriter(t, db, &PostgresConfig{
+		HealthCheckInterval: 5 * time.Millisecond,
+		OnError: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+	})
+	if !w.IsHealthy() {
+		t.Fatal("writer unhealthy after a successful start")
+	}
+
+	db.down.Store(true)
+	waitFor(t, "unhealthy", func() bool { return !w.IsHealthy() })
+	// 故障期间多次 Ping 失败只通知一次
+	pings := db.pings.Load()
+	waitFor(t, "more failed pings", func() bool { return db.pings.Load() > pings+2 })
+	mu.Lock()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "health check failed") {
+		t.Errorf("errors = %v, want one health check failure", errs)
+	}
+	mu.Unlock()
+
+	db.down.Store(false)
+	waitFor(t, "healthy", w.IsHealthy)
+	// 状态先于恢复事件入缓冲区更新
+	waitFor(t, "recovery event", func() bool { return w.BufferLen() == 1 })
+	flushSync(t, w)
+	inserts := db.inserts()
+	if len(inserts) != 1 || argOf(t, w, inserts[0], "content") != "database connection recovered" {
+		t.Errorf("inserts = %v, want one recovery event", inserts)
+	}
+}
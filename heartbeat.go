@@ -0,0 +1,68 @@
+package writer
+
+import (
+	"time"
+)
+
+// heartbeatLoop 后台心跳协程，每个周期写入一条汇总自上次心跳以来运行状态的 stat 日志，prev 为启动时的快照
+func (w *PostgresqlWriter) heartbeatLoop(prev PostgresStats) {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			cur := w.Stats()
+			w.addHeartbeat(heartbeatEntry(prev, cur, w.heartbeatInterval))
+			prev = cur
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// addHeartbeat 将心跳日志直接放入缓冲区：心跳是存活信号，不经过 BeforeWrite、采样和限流
+func (w *PostgresqlWriter) addHeartbeat(entry LogEntry) {
+	w.bufferMux.Lock()
+	defer w.bufferMux.Unlock()
+	if w.closed {
+		return
+	}
+	if w.chainedLocked(entry) {
+		w.chainEntryLocked(&entry)
+	}
+	w.buffer = append(w.buffer, entry)
+}
+
+// heartbeatEntry 根据前后两次运行状态快照构造心跳日志，计数均为两次快照之间的增量
+func heartbeatEntry(prev, cur PostgresStats, interval time.Duration) LogEntry {
+	levels := make(map[string]int64, len(cur.Levels))
+	for level, n := range cur.Levels {
+		if d := n - prev.Levels[level]; d > 0 {
+			levels[level] = d
+		}
+	}
+
+	fields := map[string]interface{}{
+		"interval": interval.String(),
+		"levels":   levels,
+		"written":  cur.Written - prev.Written,
+		"failed":   cur.Failed - prev.Failed,
+		"dropped":  cur.Suppressed - prev.Suppressed,
+		"buffered": cur.Buffered,
+		"flushes":  cur.Flushes - prev.Flushes,
+	}
+	if flushes := cur.Flushes - prev.Flushes; flushes > 0 {
+		avg := (cur.FlushTimeTotal - prev.FlushTimeTotal) / time.Duration(flushes)
+		fields["flush_avg_ms"] = float64(avg) / float64(time.Millisecond)
+	}
+
+	return LogEntry{
+		Timestamp: time.Now().Format(timestampLayout),
+		Level:     "stat",
+		Content:   "heartbeat",
+		LogType:   "system",
+		Fields:    fields,
+	}
+}
@@ -0,0 +1,67 @@
+package writer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeartbeatEntryReportsDeltas(t *testing.T) {
+	prev := PostgresStats{Levels: map[string]int64{"info": 5}, Written: 5, Flushes: 1, FlushTimeTotal: 10 * time.Millisecond}
+	cur := PostgresStats{
+		Levels:         map[string]int64{"info": 8, "error": 1},
+		Written:        8,
+		Failed:         1,
+		Suppressed:     2,
+		Buffered:       3,
+		Flushes:        3,
+		FlushTimeTotal: 14 * time.Millisecond,
+	}
+	entry := heartbeatEntry(prev, cur, time.Minute)
+	if entry.Level != "stat" || entry.LogType != "system" || entry.Content != "heartbeat" {
+		t.Errorf("entry = %+v, want a stat heartbeat of log_type system", entry)
+	}
+	levels := entry.Fields["levels"].(map[string]int64)
+	if len(levels) != 2 || levels["info"] != 3 || levels["error"] != 1 {
+		t.Errorf("levels = %v, want info:3 error:1", levels)
+	}
+	want := map[string]any{"written": int64(3), "failed": int64(1), "dropped": int64(2), "buffered": 3, "flushes": int64(2), "flush_avg_ms": 2.0, "interval": "1m0s"}
+	for k, v := range want {
+		if entry.Fields[k] != v {
+			t.Errorf("%s = %v (%T), want %v (%T)", k, entry.Fields[k], entry.Fields[k], v, v)
+		}
+	}
+}
+
+func TestHeartbeatFiresWithCounts(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{HeartbeatInterval: 20 * time.Millisecond})
+	w.Info("a")
+	w.Info("b")
+	w.Info("c")
+	w.Error("d")
+	flushSync(t, w)
+
+	// 日志可能跨两个心跳周期，累加所有心跳中的计数
+	counts := make(map[string]float64)
+	var written float64
+	waitFor(t, "heartbeats covering the batch", func() bool {
+		if _, err := w.FlushSync(); err != nil {
+			t.Fatal(err)
+		}
+		for _, call := range db.inserts() {
+			if argOf(t, w, call, "content") != "heartbeat" {
+				continue
+			}
+			fields := fieldsOf(t, w, call)
+			levels, _ := fields["levels"].(map[string]any)
+			for _, level := range []string{"info", "error"} {
+				n, _ := levels[level].(float64)
+				counts[level] += n
+			}
+			n, _ := fields["written"].(float64)
+			written += n
+		}
+		db.reset()
+		return counts["info"] == 3 && counts["error"] == 1 && written >= 4
+	})
+}
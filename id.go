@@ -0,0 +1,76 @@
+package writer
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// NewUUID 生成一个随机 UUID（v4），可作为 IDGenerator 使用
+func NewUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+	return formatUUID(b)
+}
+
+// uuidV7State 保证同一进程内生成的 v7 UUID 单调递增
+var uuidV7State struct {
+	mu     sync.Mutex
+	lastMs int64
+	seq    uint16 // 同一毫秒内的计数器，占用 12 位 rand_a
+}
+
+// NewUUIDv7 生成一个按时间排序的 UUID（v7）：前 48 位为毫秒时间戳，同一毫秒内以 12 位计数器递增，其余为随机数
+// 同一进程内生成的值严格递增（时钟回拨时沿用上一次的时间戳），可作为 IDGenerator 使用
+func NewUUIDv7() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+
+	s := &uuidV7State
+	s.mu.Lock()
+	ms := time.Now().UnixMilli()
+	if ms > s.lastMs {
+		s.lastMs = ms
+		// 计数器从随机值开始，只取低 11 位，给同一毫秒内的递增留出空间
+		s.seq = binary.BigEndian.Uint16(b[6:8]) & 0x7ff
+	} else {
+		s.seq++
+		if s.seq > 0xfff {
+			// 计数器用尽，借用下一毫秒
+			s.lastMs++
+			s.seq = 0
+		}
+	}
+	ms, seq := s.lastMs, s.seq
+	s.mu.Unlock()
+
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+	b[6] = 0x70 | byte(seq>>8) // version 7
+	b[7] = byte(seq)
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+	return formatUUID(b)
+}
+
+// formatUUID 将 16 字节格式化为标准的 8-4-4-4-12 形式
+func formatUUID(b [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
@@ -0,0 +1,52 @@
+package writer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var uuidV7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewUUIDv7Monotonic(t *testing.T) {
+	before := time.Now().UnixMilli()
+	prev := ""
+	// 远多于同一毫秒内计数器的初始余量，覆盖计数器借用下一毫秒的情况
+	for i := 0; i < 10000; i++ {
+		id := NewUUIDv7()
+		if !uuidV7Pattern.MatchString(id) {
+			t.Fatalf("%q is not a v7 UUID", id)
+		}
+		if id <= prev {
+			t.Fatalf("id %d = %s, not greater than %s", i, id, prev)
+		}
+		prev = id
+	}
+
+	ms, err := strconv.ParseInt(strings.ReplaceAll(prev[:13], "-", ""), 16, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after := time.Now().UnixMilli(); ms < before || ms > after+10 {
+		t.Errorf("timestamp %d outside [%d, %d]", ms, before, after)
+	}
+}
+
+func TestUUIDv7PrimaryKey(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{PrimaryKey: PrimaryKeyUUIDv7})
+	if ddl := execSQL(db.execs("CREATE TABLE")); !strings.Contains(ddl, "id UUID PRIMARY KEY") {
+		t.Errorf("DDL = %s, want a UUID primary key", ddl)
+	}
+	w.Info("first")
+	w.Info("second")
+	flushSync(t, w)
+
+	inserts := db.inserts()
+	first, second := argOf(t, w, inserts[0], "id").(string), argOf(t, w, inserts[1], "id").(string)
+	if !uuidV7Pattern.MatchString(first) || second <= first {
+		t.Errorf("ids = %s, %s; want increasing v7 UUIDs", first, second)
+	}
+}
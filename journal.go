@@ -0,0 +1,276 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultJournalSocket systemd-journald 原生协议的 socket 路径
+const defaultJournalSocket = "/run/systemd/journal/socket"
+
+// JournalConfig systemd journal Writer 配置
+type JournalConfig struct {
+	SocketPath  string                     `json:"socket_path"` // journald socket 路径（默认 /run/systemd/journal/socket）
+	Identifier  string                     `json:"identifier"`  // SYSLOG_IDENTIFIER（默认为可执行文件名）
+	BeforeWrite func(entry *LogEntry) bool `json:"-"`           // 写入前的钩子（可选），可修改条目，返回 false 丢弃该条日志
+	OnError     func(err error)            `json:"-"`           // 发送失败回调（可选）
+}
+
+// JournalWriter 通过原生协议将日志写入 systemd journal（不依赖 cgo，仅支持 Linux）
+// 级别映射为 syslog 优先级，特殊字段及 fields 写为大写的 journal 字段（如 trace → TRACE）
+// 与 Writer 自身写入的字段（MESSAGE、PRIORITY 等）同名的普通字段加 FIELD_ 前缀，下划线开头的字段（journald 的受信字段）被丢弃
+// 单条日志超出 socket 数据报上限时，按 journald 协议写入临时文件并传递文件描述符
+type JournalWriter struct {
+	identifier  string
+	onError     func(err error)
+	beforeWrite func(entry *LogEntry) bool
+
+	conn    net.Conn
+	connMux sync.Mutex // 保护 conn 和 closed
+	closed  bool
+}
+
+// NewJournalWriter 创建一个 systemd journal 日志写入器
+// config: 配置项（可选，传 nil 使用默认配置）；非 Linux 平台或 journald 不可用时返回错误
+func NewJournalWriter(config *JournalConfig) (*JournalWriter, error) {
+	if config == nil {
+		config = &JournalConfig{}
+	}
+	socketPath := config.SocketPath
+	if socketPath == "" {
+		socketPath = defaultJournalSocket
+	}
+	identifier := config.Identifier
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+
+	conn, err := dialJournal(socketPath)
+	if err != nil {
+		return nil, err
+	}
+	return &JournalWriter{
+		identifier:  identifier,
+		onError:     config.OnError,
+		beforeWrite: config.BeforeWrite,
+		conn:        conn,
+	}, nil
+}
+
+// journalPriority 将日志级别映射为 syslog 优先级（0 emerg ~ 7 debug），未知级别按 info 处理
+func journalPriority(level string) int {
+	switch level {
+	case "debug":
+		return 7
+	case "warn":
+		return 4
+	case "error", "stack":
+		return 3
+	case "severe":
+		return 2
+	case "alert":
+		return 1
+	default:
+		return 6
+	}
+}
+
+// journalReservedFields JournalWriter 自身写入的字段名
+var journalReservedFields = map[string]bool{
+	"MESSAGE": true, "PRIORITY": true, "SYSLOG_IDENTIFIER": true, "LEVEL": true,
+	"LOG_TYPE": true, "DURATION": true, "TRACE": true, "SPAN": true,
+	"USERNAME": true, "COMPONENT": true, "ENTRY_ID": true, "USER_ID": true,
+}
+
+// journalUserFieldPrefix 与 journalReservedFields 冲突的普通字段的前缀
+const journalUserFieldPrefix = "FIELD_"
+
+// journalFieldName 将字段名转换为合法的 journal 字段名：大写字母、数字和下划线，不能以下划线或数字开头
+// 下划线开头的字段名由 journald 保留给受信字段（如 _PID），返回空字符串表示丢弃；与保留字段同名时加 FIELD_ 前缀
+func journalFieldName(key string) string {
+	if strings.HasPrefix(key, "_") {
+		return ""
+	}
+	var b strings.Builder
+	for _, r := range strings.ToUpper(key) {
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	name := strings.TrimLeft(b.String(), "_0123456789")
+	if journalReservedFields[name] {
+		name = journalUserFieldPrefix + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// encodeJournalEntry 按 journald 原生协议编码日志条目
+func (w *JournalWriter) encodeJournalEntry(entry LogEntry) []byte {
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", entry.Content)
+	writeJournalField(&buf, "PRIORITY", strconv.Itoa(journalPriority(entry.Level)))
+	writeJournalField(&buf, "SYSLOG_IDENTIFIER", w.identifier)
+	writeJournalField(&buf, "LEVEL", entry.Level)
+
+	special := []struct{ name, value string }{
+		{"LOG_TYPE", entry.LogType},
+		{"DURATION", entry.Duration},
+		{"TRACE", entry.Trace},
+		{"SPAN", entry.Span},
+		{"USERNAME", entry.Username},
+		{"COMPONENT", entry.Component},
+		{"ENTRY_ID", entry.EntryID},
+	}
+	for _, f := range special {
+		if f.value != "" {
+			writeJournalField(&buf, f.name, f.value)
+		}
+	}
+	if entry.UserID != nil {
+		writeJournalField(&buf, "USER_ID", strconv.FormatInt(*entry.UserID, 10))
+	}
+
+	keys := make([]string, 0, len(entry.Fields))
+	for key := range entry.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if name := journalFieldName(key); name != "" {
+			writeJournalField(&buf, name, formatFieldValue(entry.Fields[key]))
+		}
+	}
+	return buf.Bytes()
+}
+
+// writeJournalField 写入一个字段：单行值为 KEY=value，含换行的值使用长度前缀的二进制格式
+func writeJournalField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(name)
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteString(name)
+	buf.WriteByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.Write(size[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// AddEntry 将日志条目发送到 journal
+func (w *JournalWriter) AddEntry(entry LogEntry) {
+	if !applyBeforeWrite(w.beforeWrite, &entry) {
+		return
+	}
+	data := w.encodeJournalEntry(entry)
+
+	w.connMux.Lock()
+	if w.closed {
+		w.connMux.Unlock()
+		w.handleError(ErrWriterClosed)
+		return
+	}
+	err := sendJournal(w.conn, data)
+	w.connMux.Unlock()
+	if err != nil {
+		w.handleError(fmt.Errorf("failed to write journal entry: %w", err))
+	}
+}
+
+// Log 写入日志（核心方法）
+func (w *JournalWriter) Log(level string, content any, fields ...LogField) {
+	w.AddEntry(newLogEntry(level, content, fields))
+}
+
+// Named 返回带组件名的派生 Writer，与当前 Writer 共享连接
+func (w *JournalWriter) Named(component string) Writer {
+	return newDerivedWriter(w, component)
+}
+
+// With 返回附加默认字段的派生 Writer，每条日志都会带上这些字段（调用时传入的同名字段优先）
+func (w *JournalWriter) With(fields ...LogField) Writer {
+	return newFieldsWriter(w, fields)
+}
+
+// Info 写入 info 级别日志
+func (w *JournalWriter) Info(content any, fields ...LogField) {
+	w.Log("info", content, fields...)
+}
+
+// Error 写入 error 级别日志
+func (w *JournalWriter) Error(content any, fields ...LogField) {
+	w.Log("error", content, fields...)
+}
+
+// Debug 写入 debug 级别日志
+func (w *JournalWriter) Debug(content any, fields ...LogField) {
+	w.Log("debug", content, fields...)
+}
+
+// Warn 写入 warn 级别日志
+func (w *JournalWriter) Warn(content any, fields ...LogField) {
+	w.Log("warn", content, fields...)
+}
+
+// Infof 写入 info 级别格式化日志
+func (w *JournalWriter) Infof(format string, args ...any) {
+	w.Log("info", fmt.Sprintf(format, args...))
+}
+
+// Errorf 写入 error 级别格式化日志
+func (w *JournalWriter) Errorf(format string, args ...any) {
+	w.Log("error", fmt.Sprintf(format, args...))
+}
+
+// Debugf 写入 debug 级别格式化日志
+func (w *JournalWriter) Debugf(format string, args ...any) {
+	w.Log("debug", fmt.Sprintf(format, args...))
+}
+
+// Warnf 写入 warn 级别格式化日志
+func (w *JournalWriter) Warnf(format string, args ...any) {
+	w.Log("warn", fmt.Sprintf(format, args...))
+}
+
+// Logf 写入格式化日志
+func (w *JournalWriter) Logf(level string, format string, args ...any) {
+	w.Log(level, fmt.Sprintf(format, args...))
+}
+
+// Flush 日志逐条直接发送，无需刷新
+func (w *JournalWriter) Flush() {}
+
+// handleError 将错误交给 OnError 回调
+func (w *JournalWriter) handleError(err error) {
+	if w.onError != nil {
+		w.onError(err)
+	}
+}
+
+// Close 关闭与 journald 的连接
+func (w *JournalWriter) Close() error {
+	w.connMux.Lock()
+	defer w.connMux.Unlock()
+	if w.closed {
+		return ErrWriterClosed
+	}
+	w.closed = true
+	return w.conn.Close()
+}
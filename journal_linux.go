@@ -0,0 +1,63 @@
+//go:build linux
+
+package writer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// dialJournal 连接 journald 的 unixgram socket
+func dialJournal(socketPath string) (net.Conn, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %w", err)
+	}
+	return conn, nil
+}
+
+// sendJournal 发送一条数据报；超出 socket 数据报上限（EMSGSIZE、ENOBUFS）时，
+// 与 sd_journal_send 相同，将内容写入已删除的临时文件（优先 /dev/shm），通过 SCM_RIGHTS 把文件描述符交给 journald
+func sendJournal(conn net.Conn, data []byte) error {
+	_, err := conn.Write(data)
+	if err == nil || !(errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)) {
+		return err
+	}
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return err
+	}
+
+	file, tmpErr := os.CreateTemp("/dev/shm", "journal-")
+	if tmpErr != nil {
+		file, tmpErr = os.CreateTemp("", "journal-")
+	}
+	if tmpErr != nil {
+		return fmt.Errorf("entry of %d bytes exceeds the datagram limit and no temporary file is available: %w", len(data), tmpErr)
+	}
+	defer file.Close()
+	os.Remove(file.Name())
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write oversized journal entry to temporary file: %w", err)
+	}
+	// 已连接的 unixgram 不能使用 WriteMsgUnix，直接在底层描述符上 sendmsg
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return err
+	}
+	rights := syscall.UnixRights(int(file.Fd()))
+	var sendErr error
+	if err := raw.Write(func(fd uintptr) bool {
+		sendErr = syscall.Sendmsg(int(fd), nil, rights, nil, 0)
+		return sendErr != syscall.EAGAIN
+	}); err != nil {
+		return err
+	}
+	if sendErr != nil {
+		return fmt.Errorf("failed to pass oversized journal entry to journald: %w", sendErr)
+	}
+	return nil
+}
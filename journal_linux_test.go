@@ -0,0 +1,64 @@
+package writer
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestJournalOversizedEntryPassesFile(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var errs []error
+	w, err := NewJournalWriter(&JournalConfig{SocketPath: socket, Identifier: "app", OnError: func(err error) { errs = append(errs, err) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	big := strings.Repeat("x", 4<<20)
+	w.Info("huge", Field("payload", big))
+	if len(errs) != 0 {
+		t.Fatalf("errors = %v", errs)
+	}
+
+	buf := make([]byte, 1024)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("datagram carries %d bytes, want an empty datagram with a descriptor", n)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("control messages = %v, %v", msgs, err)
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("descriptors = %v, %v", fds, err)
+	}
+	file := os.NewFile(uintptr(fds[0]), "journal-entry")
+	defer file.Close()
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "MESSAGE=huge\nPRIORITY=6\n") || !strings.HasSuffix(string(data), "PAYLOAD="+big+"\n") {
+		t.Errorf("file holds %d bytes that are not the encoded entry", len(data))
+	}
+}
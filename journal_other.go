@@ -0,0 +1,19 @@
+//go:build !linux
+
+package writer
+
+import (
+	"fmt"
+	"net"
+)
+
+// dialJournal 非 Linux 平台没有 journald
+func dialJournal(socketPath string) (net.Conn, error) {
+	return nil, fmt.Errorf("systemd journal is only supported on linux")
+}
+
+// sendJournal 发送一条数据报
+func sendJournal(conn net.Conn, data []byte) error {
+	_, err := conn.Write(data)
+	return err
+}
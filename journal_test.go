@@ -0,0 +1,71 @@
+package writer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJournalPriority(t *testing.T) {
+	tests := map[string]int{
+		"debug":  7,
+		"info":   6,
+		"slow":   6,
+		"stat":   6,
+		"warn":   4,
+		"error":  3,
+		"stack":  3,
+		"severe": 2,
+		"alert":  1,
+		"custom": 6,
+	}
+	for level, want := range tests {
+		if got := journalPriority(level); got != want {
+			t.Errorf("journalPriority(%q) = %d, want %d", level, got, want)
+		}
+	}
+}
+
+func TestJournalFieldName(t *testing.T) {
+	tests := map[string]string{
+		"tenant_id":         "TENANT_ID",
+		"http.status":       "HTTP_STATUS",
+		"2fa-method":        "FA_METHOD",
+		"message":           "FIELD_MESSAGE",
+		"priority":          "FIELD_PRIORITY",
+		"syslog_identifier": "FIELD_SYSLOG_IDENTIFIER",
+		"level":             "FIELD_LEVEL",
+		"_pid":              "",
+		"_SYSTEMD_UNIT":     "",
+		"--":                "",
+	}
+	for key, want := range tests {
+		if got := journalFieldName(key); got != want {
+			t.Errorf("journalFieldName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestJournalEncodeReservedFields(t *testing.T) {
+	w := &JournalWriter{identifier: "app"}
+	entry := newLogEntry("warn", "disk low", []LogField{
+		Field("message", "user message"),
+		Field("priority", "high"),
+		Field("syslog_identifier", "spoofed"),
+		Field("_PID", 1),
+		Field("mount", "/var"),
+	})
+	got := strings.Split(strings.TrimSuffix(string(w.encodeJournalEntry(entry)), "\n"), "\n")
+	want := []string{
+		"MESSAGE=disk low",
+		"PRIORITY=4",
+		"SYSLOG_IDENTIFIER=app",
+		"LEVEL=warn",
+		"FIELD_MESSAGE=user message",
+		"MOUNT=/var",
+		"FIELD_PRIORITY=high",
+		"FIELD_SYSLOG_IDENTIFIER=spoofed",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("encoded:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,116 @@
+package writer
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// logEntryDescriptions LogEntry 各 JSON 字段的含义（括号中为对应的数据库列）
+var logEntryDescriptions = map[string]string{
+	"@timestamp": "日志时间（RFC3339，timestamp 列）",
+	"entry_id":   "日志条目唯一标识，配置 IDGenerator 时生成（entry_id 列）",
+	"level":      "日志级别，如 debug、info、warn、error、severe（level 列）",
+	"content":    "日志内容（content 列）",
+	"log_type":   "日志类型，如 user、system（log_type 列）",
+	"duration":   "耗时（duration 列）",
+	"trace":      "链路追踪 ID（trace 列）",
+	"span":       "span ID（span 列）",
+	"user_id":    "用户 ID（user_id 列）",
+	"username":   "用户名（username 列）",
+	"component":  "组件名，通过 Named 设置（component 列）",
+	"expires_at": "过期时间（RFC3339），通过 ttl 字段设置，供过期清理使用（expires_at 列）",
+	"fields":     "其余自定义字段，键为字段名，值为任意 JSON（fields 列，按 FieldStorage 存储）",
+	"prev_hash":  "审计哈希链中上一条的 hash，开启 AuditChain 时生成（prev_hash 列）",
+	"hash":       "审计哈希链中本条的 hash（hash 列）",
+}
+
+// logEntryFormats 需要标注 format 的字段
+var logEntryFormats = map[string]string{
+	"@timestamp": "date-time",
+	"expires_at": "date-time",
+}
+
+// LogEntryJSONSchema 返回描述 LogEntry JSON 结构（默认 Serializer 的输出，Elastic、gRPC、文件等 Writer 使用）的 JSON Schema（draft 2020-12）
+// 属性由 LogEntry 的 json 标签反射生成，不带 omitempty 的字段列为必填；可直接嵌入 OpenAPI 3.1 的 components.schemas
+func LogEntryJSONSchema() []byte {
+	t := reflect.TypeOf(LogEntry{})
+	properties := make(map[string]any, t.NumField())
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := jsonSchemaType(f.Type)
+		if desc, ok := logEntryDescriptions[name]; ok {
+			prop["description"] = desc
+		}
+		if format, ok := logEntryFormats[name]; ok {
+			prop["format"] = format
+		}
+		properties[name] = prop
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"title":                "LogEntry",
+		"description":          "pg-log-writter 日志条目",
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	data, _ := json.MarshalIndent(schema, "", "  ")
+	return data
+}
+
+// jsonSchemaType 返回 Go 类型对应的 JSON Schema 类型描述，指针类型允许 null
+func jsonSchemaType(t reflect.Type) map[string]any {
+	nullable := false
+	if t.Kind() == reflect.Pointer {
+		nullable = true
+		t = t.Elem()
+	}
+
+	var typ string
+	prop := map[string]any{}
+	switch t.Kind() {
+	case reflect.String:
+		typ = "string"
+	case reflect.Bool:
+		typ = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		typ = "integer"
+	case reflect.Float32, reflect.Float64:
+		typ = "number"
+	case reflect.Map:
+		typ = "object"
+		prop["additionalProperties"] = true
+	case reflect.Slice, reflect.Array:
+		typ = "array"
+		prop["items"] = jsonSchemaType(t.Elem())
+	default:
+		// 无法确定类型时不约束
+		return prop
+	}
+
+	if nullable {
+		prop["type"] = []string{typ, "null"}
+	} else {
+		prop["type"] = typ
+	}
+	return prop
+}
@@ -0,0 +1,62 @@
+package writer
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// jsonSchema LogEntryJSONSchema 输出中测试用到的部分
+type jsonSchema struct {
+	Properties map[string]map[string]any `json:"properties"`
+	Required   []string                  `json:"required"`
+}
+
+func TestLogEntryJSONSchemaListsFields(t *testing.T) {
+	var schema jsonSchema
+	if err := json.Unmarshal(LogEntryJSONSchema(), &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	// 以所有字段都非空的条目序列化结果为准，属性名必须与实际输出的 JSON 键一致
+	uid := int64(1)
+	full := LogEntry{
+		Timestamp: "t", EntryID: "e", Level: "info", Content: "c", LogType: "user", Duration: "1s",
+		Trace: "t", Span: "s", UserID: &uid, Username: "u", Component: "api", ExpiresAt: "x",
+		Fields: map[string]interface{}{"k": "v"}, PrevHash: "p", Hash: "h",
+	}
+	data, _ := json.Marshal(full)
+	var encoded map[string]any
+	json.Unmarshal(data, &encoded)
+	if reflect.TypeOf(full).NumField() != len(encoded) {
+		t.Fatalf("test entry leaves %d fields empty", reflect.TypeOf(full).NumField()-len(encoded))
+	}
+	for name := range encoded {
+		prop, ok := schema.Properties[name]
+		if !ok {
+			t.Errorf("schema is missing property %q", name)
+			continue
+		}
+		if prop["description"] == nil {
+			t.Errorf("property %q has no description", name)
+		}
+	}
+	if len(schema.Properties) != len(encoded) {
+		t.Errorf("schema has %d properties, want %d", len(schema.Properties), len(encoded))
+	}
+
+	slices.Sort(schema.Required)
+	if want := []string{"@timestamp", "content", "level"}; !slices.Equal(schema.Required, want) {
+		t.Errorf("required = %v, want %v", schema.Required, want)
+	}
+	if got := schema.Properties["user_id"]["type"]; !reflect.DeepEqual(got, []any{"integer", "null"}) {
+		t.Errorf("user_id type = %v, want integer or null", got)
+	}
+	if got := schema.Properties["@timestamp"]["format"]; got != "date-time" {
+		t.Errorf("@timestamp format = %v, want date-time", got)
+	}
+	if got := schema.Properties["fields"]["type"]; got != "object" {
+		t.Errorf("fields type = %v, want object", got)
+	}
+}
@@ -0,0 +1,84 @@
+package writer
+
+import (
+	"fmt"
+	"sync"
+)
+
+// UnknownLevelPolicy 级别不在已知集合（内置级别加自定义级别）中时的处理方式
+type UnknownLevelPolicy string
+
+const (
+	// UnknownLevelAccept 原样保留（未启用 LevelEnum 时的默认值）
+	UnknownLevelAccept UnknownLevelPolicy = "accept"
+	// UnknownLevelMap 替换为 UnknownLevel（为空时为 info）
+	UnknownLevelMap UnknownLevelPolicy = "map"
+	// UnknownLevelReject 丢弃该条日志并返回 ErrUnknownLevel
+	UnknownLevelReject UnknownLevelPolicy = "reject"
+	// UnknownLevelWarn 原样保留，每个未知级别首次出现时报告一次
+	UnknownLevelWarn UnknownLevelPolicy = "warn"
+)
+
+// defaultUnknownLevel map 策略未指定 UnknownLevel 时的替换值
+const defaultUnknownLevel = "info"
+
+// levelPolicy 按 UnknownLevelPolicy 处理未知级别，accept 策略时为 nil
+type levelPolicy struct {
+	policy      UnknownLevelPolicy
+	known       map[string]struct{}
+	replacement string
+	warned      sync.Map // 已报告过的未知级别
+}
+
+// newLevelPolicy 创建级别策略，known 为已知级别；accept 策略返回 nil
+func newLevelPolicy(policy UnknownLevelPolicy, known []string, replacement string) *levelPolicy {
+	if policy == "" || policy == UnknownLevelAccept {
+		return nil
+	}
+	if policy == UnknownLevelMap && replacement == "" {
+		replacement = defaultUnknownLevel
+	}
+	p := &levelPolicy{
+		policy:      policy,
+		known:       make(map[string]struct{}, len(known)+1),
+		replacement: replacement,
+	}
+	for _, level := range known {
+		p.known[level] = struct{}{}
+	}
+	if replacement != "" {
+		p.known[replacement] = struct{}{}
+	}
+	return p
+}
+
+// resolve 返回处理后的级别；keep 为 false 表示丢弃该条日志，err 非空时需要报告（warn 策略下每个级别只报告一次）
+func (p *levelPolicy) resolve(level string) (resolved string, keep bool, err error) {
+	if p == nil {
+		return level, true, nil
+	}
+	if _, ok := p.known[level]; ok {
+		return level, true, nil
+	}
+	switch p.policy {
+	case UnknownLevelMap:
+		return p.replacement, true, nil
+	case UnknownLevelWarn:
+		if _, warned := p.warned.LoadOrStore(level, struct{}{}); warned {
+			return level, true, nil
+		}
+		return level, true, fmt.Errorf("%w: %q", ErrUnknownLevel, level)
+	default:
+		return level, false, fmt.Errorf("%w: %q", ErrUnknownLevel, level)
+	}
+}
+
+// validUnknownLevelPolicy 判断策略取值是否合法
+func validUnknownLevelPolicy(policy UnknownLevelPolicy) bool {
+	switch policy {
+	case "", UnknownLevelAccept, UnknownLevelMap, UnknownLevelReject, UnknownLevelWarn:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,328 @@
+package writer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LokiWriter 通过 push API 将日志批量写入 Grafana Loki
+// 标签只使用低基数的 level、log_type、service 及配置的静态标签，trace、user_id 等高基数字段写入日志行
+type LokiWriter struct {
+	client      *http.Client
+	pushURL     string
+	service     string
+	labels      map[string]string
+	tenantID    string
+	headers     map[string]string
+	username    string
+	password    string
+	serializer  Serializer // 为空时日志行使用默认的 logfmt 格式
+	beforeWrite func(entry *LogEntry) bool
+
+	batch *batcher
+}
+
+// LokiConfig Loki Writer 配置
+type LokiConfig struct {
+	URL           string                     `json:"url"`            // Loki 地址，如 http://localhost:3100（自动追加 /loki/api/v1/push）
+	Service       string                     `json:"service"`        // service 标签（可选）
+	Labels        map[string]string          `json:"labels"`         // 附加的静态标签（可选，如 env、region），不要放入高基数的值
+	TenantID      string                     `json:"tenant_id"`      // 多租户模式下的租户 ID，通过 X-Scope-OrgID 请求头发送（可选）
+	Headers       map[string]string          `json:"headers"`        // 附加请求头（可选）
+	Username      string                     `json:"username"`       // Basic Auth 用户名（可选）
+	Password      string                     `json:"password"`       // Basic Auth 密码（可选）
+	BufferSize    int                        `json:"buffer_size"`    // 缓冲区大小
+	FlushInterval time.Duration              `json:"flush_interval"` // 刷新间隔
+	MaxInFlight   int                        `json:"max_in_flight"`  // 同时进行的 push 请求数上限（默认 2），达到上限且缓冲区已满时写日志阻塞
+	Timeout       time.Duration              `json:"timeout"`        // 单次 push 请求超时
+	HTTPClient    *http.Client               `json:"-"`              // 自定义 HTTP 客户端（可选）
+	Serializer    Serializer                 `json:"-"`              // 日志行的序列化方式（可选，默认为内容加 logfmt 字段；如 JSONSerializer 可配合 LogQL 的 | json 使用）
+	BeforeWrite   func(entry *LogEntry) bool `json:"-"`              // 写入前的钩子（可选），可修改条目，返回 false 丢弃该条日志
+	OnError       func(err error)            `json:"-"`              // 写入失败回调（可选）
+}
+
+// DefaultLokiConfig 返回默认 Loki 配置
+func DefaultLokiConfig() *LokiConfig {
+	return &LokiConfig{
+		URL:           "http://localhost:3100",
+		BufferSize:    100,
+		FlushInterval: 5 * time.Second,
+		Timeout:       30 * time.Second,
+	}
+}
+
+// lokiPushRequest push API 请求体
+type lokiPushRequest struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+// lokiStream 一组标签相同的日志，values 的每一项为 [纳秒时间戳, 日志行]
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// NewLokiWriter 创建一个 Loki 日志写入器
+// config: 配置项（可选，传 nil 使用默认配置）
+func NewLokiWriter(config *LokiConfig) (*LokiWriter, error) {
+	if config == nil {
+		config = DefaultLokiConfig()
+	}
+	if config.URL == "" {
+		return nil, fmt.Errorf("loki url is required")
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		client = &http.Client{Timeout: timeout}
+	}
+
+	w := &LokiWriter{
+		client:      client,
+		pushURL:     strings.TrimRight(config.URL, "/") + "/loki/api/v1/push",
+		service:     config.Service,
+		labels:      config.Labels,
+		tenantID:    config.TenantID,
+		headers:     config.Headers,
+		username:    config.Username,
+		password:    config.Password,
+		serializer:  config.Serializer,
+		beforeWrite: config.BeforeWrite,
+	}
+	w.batch = newBatcher(config.BufferSize, config.FlushInterval, config.MaxInFlight, w.writeEntries, config.OnError)
+	return w, nil
+}
+
+// AddEntry 添加一条日志到缓冲区
+// 写入器关闭后调用会丢弃该条目，并通过 OnError 回调返回 ErrWriterClosed
+func (w *LokiWriter) AddEntry(entry LogEntry) {
+	if !applyBeforeWrite(w.beforeWrite, &entry) {
+		return
+	}
+	w.batch.add(entry)
+}
+
+// Log 写入日志（核心方法）
+func (w *LokiWriter) Log(level string, content any, fields ...LogField) {
+	w.AddEntry(newLogEntry(level, content, fields))
+}
+
+// Named 返回带组件名的派生 Writer，与当前 Writer 共享缓冲区
+func (w *LokiWriter) Named(component string) Writer {
+	return newDerivedWriter(w, component)
+}
+
+// With 返回附加默认字段的派生 Writer，每条日志都会带上这些字段（调用时传入的同名字段优先）
+func (w *LokiWriter) With(fields ...LogField) Writer {
+	return newFieldsWriter(w, fields)
+}
+
+// Info 写入 info 级别日志
+func (w *LokiWriter) Info(content any, fields ...LogField) {
+	w.Log("info", content, fields...)
+}
+
+// Error 写入 error 级别日志
+func (w *LokiWriter) Error(content any, fields ...LogField) {
+	w.Log("error", content, fields...)
+}
+
+// Debug 写入 debug 级别日志
+func (w *LokiWriter) Debug(content any, fields ...LogField) {
+	w.Log("debug", content, fields...)
+}
+
+// Warn 写入 warn 级别日志
+func (w *LokiWriter) Warn(content any, fields ...LogField) {
+	w.Log("warn", content, fields...)
+}
+
+// Infof 写入 info 级别格式化日志
+func (w *LokiWriter) Infof(format string, args ...any) {
+	w.Log("info", fmt.Sprintf(format, args...))
+}
+
+// Errorf 写入 error 级别格式化日志
+func (w *LokiWriter) Errorf(format string, args ...any) {
+	w.Log("error", fmt.Sprintf(format, args...))
+}
+
+// Debugf 写入 debug 级别格式化日志
+func (w *LokiWriter) Debugf(format string, args ...any) {
+	w.Log("debug", fmt.Sprintf(format, args...))
+}
+
+// Warnf 写入 warn 级别格式化日志
+func (w *LokiWriter) Warnf(format string, args ...any) {
+	w.Log("warn", fmt.Sprintf(format, args...))
+}
+
+// Logf 写入格式化日志
+func (w *LokiWriter) Logf(level string, format string, args ...any) {
+	w.Log(level, fmt.Sprintf(format, args...))
+}
+
+// Flush 将缓冲区中的日志异步提交到Loki
+func (w *LokiWriter) Flush() {
+	w.batch.flush()
+}
+
+// streamLabels 返回日志条目的标签集合
+func (w *LokiWriter) streamLabels(entry LogEntry) map[string]string {
+	labels := make(map[string]string, len(w.labels)+3)
+	for k, v := range w.labels {
+		labels[k] = v
+	}
+	if w.service != "" {
+		labels["service"] = w.service
+	}
+	labels["level"] = entry.Level
+	if entry.LogType != "" {
+		labels["log_type"] = entry.LogType
+	}
+	return labels
+}
+
+// lokiLine 返回日志行：内容在前，其余属性和字段以 logfmt 追加在后（log_type 已作为标签，不再重复）
+func lokiLine(entry LogEntry) string {
+	var b strings.Builder
+	for _, kv := range entryFields(entry) {
+		if kv.Key == "log_type" {
+			continue
+		}
+		writeLogfmtPair(&b, kv.Key, formatFieldValue(kv.Value))
+	}
+	if b.Len() == 0 {
+		return entry.Content
+	}
+	return entry.Content + " " + b.String()
+}
+
+// line 返回日志条目的日志行，配置了 Serializer 时使用其输出
+func (w *LokiWriter) line(entry LogEntry) (string, error) {
+	if w.serializer == nil {
+		return lokiLine(entry), nil
+	}
+	data, err := w.serializer.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// buildPushBody 按标签集合将日志分组为 streams，同一 stream 内按时间排序
+func (w *LokiWriter) buildPushBody(entries []LogEntry) (lokiPushRequest, error) {
+	times := make([]int64, len(entries))
+	order := make([]int, len(entries))
+	for i, entry := range entries {
+		ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+		if err != nil {
+			ts = time.Now()
+		}
+		times[i] = ts.UnixNano()
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return times[order[a]] < times[order[b]] })
+
+	var streams []lokiStream
+	index := make(map[string]int)
+	for _, n := range order {
+		entry := entries[n]
+		labels := w.streamLabels(entry)
+		key := labelsKey(labels)
+		i, ok := index[key]
+		if !ok {
+			i = len(streams)
+			index[key] = i
+			streams = append(streams, lokiStream{Stream: labels})
+		}
+		line, err := w.line(entry)
+		if err != nil {
+			return lokiPushRequest{}, err
+		}
+		streams[i].Values = append(streams[i].Values, [2]string{strconv.FormatInt(times[n], 10), line})
+	}
+	return lokiPushRequest{Streams: streams}, nil
+}
+
+// labelsKey 返回标签集合的规范化表示，用于分组
+func labelsKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[k]))
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// writeEntries 将日志条目编码为 gzip 压缩的 JSON 并发送到 push API
+func (w *LokiWriter) writeEntries(entries []LogEntry) error {
+	push, err := w.buildPushBody(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode log line: %w", err)
+	}
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	if err := json.NewEncoder(gz).Encode(push); err != nil {
+		return fmt.Errorf("failed to encode push body: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress push body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, w.pushURL, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create push request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	if w.tenantID != "" {
+		req.Header.Set("X-Scope-OrgID", w.tenantID)
+	}
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+	if w.username != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send push request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("push request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Close 关闭写入器，等待所有缓冲的日志写入完成
+// 关闭后的写入契约与 PostgresqlWriter.Close 相同
+func (w *LokiWriter) Close() error {
+	return w.batch.close()
+}
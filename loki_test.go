@@ -0,0 +1,91 @@
+package writer
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// lokiServer 启动记录 push 请求体的测试服务
+func lokiServer(t *testing.T) (*httptest.Server, chan lokiPushRequest) {
+	t.Helper()
+	pushes := make(chan lokiPushRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/push" || r.Header.Get("Content-Encoding") != "gzip" {
+			t.Errorf("unexpected request %s (encoding %q)", r.URL.Path, r.Header.Get("Content-Encoding"))
+		}
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var push lokiPushRequest
+		if err := json.NewDecoder(gz).Decode(&push); err != nil {
+			t.Error(err)
+		}
+		pushes <- push
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, pushes
+}
+
+func TestLokiWriterSerializerRoundTrip(t *testing.T) {
+	srv, pushes := lokiServer(t)
+	w, err := NewLokiWriter(&LokiConfig{URL: srv.URL, Serializer: gobSerializer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := sampleEntry()
+	w.AddEntry(entry)
+	w.Close()
+
+	push := <-pushes
+	if len(push.Streams) != 1 || len(push.Streams[0].Values) != 1 {
+		t.Fatalf("unexpected push body: %+v", push)
+	}
+	if got := decodeGob(t, []byte(push.Streams[0].Values[0][1])); !reflect.DeepEqual(got, entry) {
+		t.Errorf("decoded = %+v, want %+v", got, entry)
+	}
+}
+
+func TestLokiWriterPayloadShape(t *testing.T) {
+	srv, pushes := lokiServer(t)
+	w, err := NewLokiWriter(&LokiConfig{URL: srv.URL, Service: "api", Labels: map[string]string{"env": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := int64(7)
+	w.AddEntry(LogEntry{Timestamp: "2025-01-02T03:04:05Z", Level: "info", Content: "first", Trace: "t-1", UserID: &uid})
+	w.AddEntry(LogEntry{Timestamp: "2025-01-02T03:04:06Z", Level: "error", Content: "boom", LogType: "request", Fields: map[string]any{"code": 500}})
+	w.AddEntry(LogEntry{Timestamp: "2025-01-02T03:04:07Z", Level: "info", Content: "second", Trace: "t-2"})
+	w.Close()
+
+	push := <-pushes
+	if len(push.Streams) != 2 {
+		t.Fatalf("got %d streams, want 2 (one per label set): %+v", len(push.Streams), push)
+	}
+	info, errStream := push.Streams[0], push.Streams[1]
+	wantInfo := map[string]string{"env": "test", "service": "api", "level": "info"}
+	if !reflect.DeepEqual(info.Stream, wantInfo) {
+		t.Errorf("info labels = %v, want %v", info.Stream, wantInfo)
+	}
+	wantErr := map[string]string{"env": "test", "service": "api", "level": "error", "log_type": "request"}
+	if !reflect.DeepEqual(errStream.Stream, wantErr) {
+		t.Errorf("error labels = %v, want %v", errStream.Stream, wantErr)
+	}
+
+	wantValues := [][2]string{
+		{"1735787045000000000", "first trace=t-1 user_id=7"},
+		{"1735787047000000000", "second trace=t-2"},
+	}
+	if !reflect.DeepEqual(info.Values, wantValues) {
+		t.Errorf("info values = %v, want %v", info.Values, wantValues)
+	}
+	if got := errStream.Values; len(got) != 1 || got[0][1] != "boom code=500" {
+		t.Errorf("error values = %v, want line %q without log_type", got, "boom code=500")
+	}
+}
@@ -0,0 +1,408 @@
+package writer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// execCall mockDB 记录的一次 Exec 调用
+type execCall struct {
+	sql  string
+	args []any
+}
+
+// mockDB 记录所有语句的 DBExecutor，execFunc 非空时决定 Exec 的返回值（可用于阻塞或注入错误）
+type mockDB struct {
+	mu       sync.Mutex
+	calls    []execCall
+	execFunc func(ctx context.Context, sql string, args []any) error
+	pingErr  error
+	closed   bool
+}
+
+func (m *mockDB) Exec(ctx context.Context, sql string, args ...any) error {
+	m.mu.Lock()
+	m.calls = append(m.calls, execCall{sql: sql, args: args})
+	fn := m.execFunc
+	m.mu.Unlock()
+	if fn != nil {
+		return fn(ctx, sql, args)
+	}
+	return nil
+}
+
+func (m *mockDB) Ping(ctx context.Context) error {
+	return m.pingErr
+}
+
+func (m *mockDB) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
+
+// execs 返回以 prefix 开头的语句（如 "INSERT"、"CREATE TABLE"）
+func (m *mockDB) execs(prefix string) []execCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []execCall
+	for _, c := range m.calls {
+		if strings.HasPrefix(c.sql, prefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// inserts 返回所有 INSERT 语句
+func (m *mockDB) inserts() []execCall {
+	return m.execs("INSERT")
+}
+
+// reset 清空已记录的语句
+func (m *mockDB) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+// mockRows 以内存中的行实现 Rows
+type mockRows struct {
+	rows [][]any
+	pos  int
+}
+
+func (r *mockRows) Next() bool {
+	r.pos++
+	return r.pos <= len(r.rows)
+}
+
+func (r *mockRows) Scan(dest ...any) error {
+	row := r.rows[r.pos-1]
+	if len(dest) != len(row) {
+		return errors.New("mockRows: column count mismatch")
+	}
+	for i, v := range row {
+		if err := assignScan(dest[i], v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *mockRows) Err() error   { return nil }
+func (r *mockRows) Close() error { return nil }
+
+// assignScan 按目标类型写入扫描结果，支持测试中用到的类型
+func assignScan(dest, v any) error {
+	switch d := dest.(type) {
+	case *string:
+		*d, _ = v.(string)
+	case **string:
+		if v == nil {
+			*d = nil
+		} else {
+			s := v.(string)
+			*d = &s
+		}
+	case *int:
+		*d, _ = v.(int)
+	case *int64:
+		*d, _ = v.(int64)
+	case **int64:
+		if v == nil {
+			*d = nil
+		} else {
+			n := v.(int64)
+			*d = &n
+		}
+	case *bool:
+		*d, _ = v.(bool)
+	case *time.Time:
+		*d, _ = v.(time.Time)
+	case **time.Time:
+		if v == nil {
+			*d = nil
+		} else {
+			ts := v.(time.Time)
+			*d = &ts
+		}
+	case *[]byte:
+		switch b := v.(type) {
+		case []byte:
+			*d = b
+		case string:
+			*d = []byte(b)
+		}
+	case *any:
+		*d = v
+	default:
+		return errors.New("mockRows: unsupported scan type")
+	}
+	return nil
+}
+
+// queryDB 同时实现 DBExecutor 和 DBQuerier，queryFunc 返回查询结果
+type queryDB struct {
+	mockDB
+	qmu       sync.Mutex
+	queries   []execCall
+	queryFunc func(sql string, args []any) ([][]any, error)
+}
+
+func (q *queryDB) Query(ctx context.Context, sql string, args ...any) (Rows, error) {
+	q.qmu.Lock()
+	q.queries = append(q.queries, execCall{sql: sql, args: args})
+	q.qmu.Unlock()
+	if q.queryFunc == nil {
+		return &mockRows{}, nil
+	}
+	rows, err := q.queryFunc(sql, args)
+	if err != nil {
+		return nil, err
+	}
+	return &mockRows{rows: rows}, nil
+}
+
+// lastQuery 返回最近一次查询
+func (q *queryDB) lastQuery() execCall {
+	q.qmu.Lock()
+	defer q.qmu.Unlock()
+	if len(q.queries) == 0 {
+		return execCall{}
+	}
+	return q.queries[len(q.queries)-1]
+}
+
+// newTestWriter 创建写入 mock 的 PostgresqlWriter，默认手动刷新，测试结束时关闭
+func newTestWriter(t testing.TB, db DBExecutor, config *PostgresConfig) *PostgresqlWriter {
+	t.Helper()
+	if config == nil {
+		config = &PostgresConfig{}
+	}
+	if config.TableName == "" {
+		config.TableName = "logs"
+	}
+	if config.BufferSize == 0 {
+		config.BufferSize = 100
+	}
+	if config.FlushInterval == 0 {
+		config.ManualFlush = true
+	}
+	w, err := NewPostgresqlWriter(db, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return w
+}
+
+// argOf 返回 INSERT 语句中 column 列的参数
+func argOf(t testing.TB, w *PostgresqlWriter, call execCall, column string) any {
+	t.Helper()
+	for i, c := range w.insertColumns {
+		if c == column {
+			return call.args[i]
+		}
+	}
+	t.Fatalf("column %q not in insert columns %v", column, w.insertColumns)
+	return nil
+}
+
+// fieldsOf 解析 INSERT 语句中 fields 列的 JSON
+func fieldsOf(t testing.TB, w *PostgresqlWriter, call execCall) map[string]any {
+	t.Helper()
+	var fields map[string]any
+	if err := json.Unmarshal(argOf(t, w, call, "fields").([]byte), &fields); err != nil {
+		t.Fatalf("fields is not JSON: %v", err)
+	}
+	return fields
+}
+
+// flushSync 同步刷新并在出错时终止测试
+func flushSync(t testing.TB, w *PostgresqlWriter) int {
+	t.Helper()
+	n, err := w.FlushSync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// memoryWriter 在内存中记录条目的 Writer，用作 Fallback 等下游
+type memoryWriter struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	flushes int
+	closed  bool
+}
+
+func (m *memoryWriter) Info(content any, fields ...LogField)  { m.Log("info", content, fields...) }
+func (m *memoryWriter) Error(content any, fields ...LogField) { m.Log("error", content, fields...) }
+func (m *memoryWriter) Debug(content any, fields ...LogField) { m.Log("debug", content, fields...) }
+func (m *memoryWriter) Warn(content any, fields ...LogField)  { m.Log("warn", content, fields...) }
+func (m *memoryWriter) Log(level string, content any, fields ...LogField) {
+	m.AddEntry(newLogEntry(level, content, fields))
+}
+func (m *memoryWriter) Infof(format string, args ...any)  { m.Logf("info", format, args...) }
+func (m *memoryWriter) Errorf(format string, args ...any) { m.Logf("error", format, args...) }
+func (m *memoryWriter) Debugf(format string, args ...any) { m.Logf("debug", format, args...) }
+func (m *memoryWriter) Warnf(format string, args ...any)  { m.Logf("warn", format, args...) }
+func (m *memoryWriter) Logf(level string, format string, args ...any) {
+	m.Log(level, fmt.Sprintf(format, args...))
+}
+func (m *memoryWriter) AddEntry(entry LogEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, entry)
+}
+func (m *memoryWriter) Named(component string) Writer  { return newDerivedWriter(m, component) }
+func (m *memoryWriter) With(fields ...LogField) Writer { return newFieldsWriter(m, fields) }
+func (m *memoryWriter) Flush() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.flushes++
+}
+func (m *memoryWriter) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrWriterClosed
+	}
+	m.closed = true
+	return nil
+}
+
+// all 返回已记录条目的副本
+func (m *memoryWriter) all() []LogEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]LogEntry(nil), m.entries...)
+}
+
+// waitFor 轮询等待 cond 成立，超时后终止测试（用于异步刷新）
+func waitFor(t testing.TB, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// nopDB 不记录语句的 DBExecutor，用于基准测试
+type nopDB struct{}
+
+func (nopDB) Exec(ctx context.Context, sql string, args ...any) error { return nil }
+func (nopDB) Ping(ctx context.Context) error                          { return nil }
+func (nopDB) Close() error                                            { return nil }
+
+// execSQL 拼接语句文本，便于在断言中查找
+func execSQL(calls []execCall) string {
+	sqls := make([]string, len(calls))
+	for i, c := range calls {
+		sqls[i] = c.sql
+	}
+	return strings.Join(sqls, "\n")
+}
+
+// logRow 构造一行查询结果，列顺序与 scanEntry 一致；fields 为 JSON 文本（空字符串表示 NULL）
+func logRow(ts time.Time, level, content, fields string) []any {
+	row := []any{ts, level, content, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil}
+	if fields != "" {
+		row[12] = fields
+	}
+	return row
+}
+
+// slowWriter Info、Log 和 AddEntry 阻塞到 release 关闭的 memoryWriter
+type slowWriter struct {
+	memoryWriter
+	release chan struct{}
+}
+
+func (s *slowWriter) Info(content any, fields ...LogField) { s.Log("info", content, fields...) }
+func (s *slowWriter) Log(level string, content any, fields ...LogField) {
+	s.AddEntry(newLogEntry(level, content, fields))
+}
+func (s *slowWriter) AddEntry(entry LogEntry) {
+	<-s.release
+	s.memoryWriter.AddEntry(entry)
+}
+
+// fakeClock 手动推进的时钟，newTimer 创建的定时器只在 advance 越过截止时间时触发
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+	resets int // 定时器被启动或重置的次数，用于等待后台协程处理完通知
+}
+
+// fakeTimer fakeClock 创建的定时器
+type fakeTimer struct {
+	clock    *fakeClock
+	c        chan time.Time
+	deadline time.Time
+	active   bool
+}
+
+func (c *fakeClock) newTimer(d time.Duration) flushTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1), deadline: c.now.Add(d), active: true}
+	c.timers = append(c.timers, t)
+	c.resets++
+	return t
+}
+
+// advance 推进时钟并触发到期的定时器
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		if t.active && !t.deadline.After(c.now) {
+			t.active = false
+			select {
+			case t.c <- c.now:
+			default:
+			}
+		}
+	}
+}
+
+// resetCount 返回定时器被启动或重置的次数
+func (c *fakeClock) resetCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resets
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Reset(d time.Duration) {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.deadline = t.clock.now.Add(d)
+	t.active = true
+	t.clock.resets++
+}
+
+func (t *fakeTimer) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.active = false
+	select {
+	case <-t.c:
+	default:
+	}
+}
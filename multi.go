@@ -1,7 +1,12 @@
 package writer
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Writer 日志写入器接口（不依赖 go-zero）
@@ -10,6 +15,7 @@ type Writer interface {
 	Error(content any, fields ...LogField)
 	Debug(content any, fields ...LogField)
 	Warn(content any, fields ...LogField)
+	// Log 写入一条日志；实现不得在返回后继续持有 fields 切片（派生 Writer 会复用该切片），需要异步处理时先复制
 	Log(level string, content any, fields ...LogField)
 	// 格式化输出方法
 	Infof(format string, args ...any)
@@ -17,12 +23,72 @@ type Writer interface {
 	Debugf(format string, args ...any)
 	Warnf(format string, args ...any)
 	Logf(level string, format string, args ...any)
+	// AddEntry 直接提交已构造好的日志条目（不进行字段提取），用于对接其他日志系统
+	AddEntry(entry LogEntry)
+	// Named 返回附加 component 字段的派生 Writer，嵌套调用以点号连接（如 auth.oauth）
+	Named(component string) Writer
+	// With 返回附加默认字段的派生 Writer，可嵌套调用逐级累加，同名字段以最近一级（及调用时传入的字段）为准
+	With(fields ...LogField) Writer
+	// Flush 将缓冲的日志提交到下游（无状态的 Writer 为空操作）
+	Flush()
 	Close() error
 }
 
+// MsgWriter 可选接口：只有消息、没有字段时的快速路径，内容以 string 传入，避免装箱为 any 的内存分配
+// PostgresqlWriter、ConsoleWriter、MultiWriter 实现了该接口；带字段的日志仍使用 Writer 的可变参数方法
+type MsgWriter interface {
+	LogMsg(level, msg string)
+	InfoMsg(msg string)
+	ErrorMsg(msg string)
+	DebugMsg(msg string)
+	WarnMsg(msg string)
+}
+
+// 编译期检查：所有实现都必须满足 Writer 接口，新增接口方法时在此处暴露遗漏
+var (
+	_ Writer = (*ConsoleWriter)(nil)
+	_ Writer = (*PostgresqlWriter)(nil)
+	_ Writer = (*ElasticWriter)(nil)
+	_ Writer = (*GRPCWriter)(nil)
+	_ Writer = (*FileWriter)(nil)
+	_ Writer = (*JournalWriter)(nil)
+	_ Writer = (*LokiWriter)(nil)
+	_ Writer = (*OTelWriter)(nil)
+	_ Writer = (*MultiWriter)(nil)
+	_ Writer = DisabledWriter{}
+	_ Writer = (*DisabledWriter)(nil)
+	_ Writer = (*TimeoutWriter)(nil)
+	_ Writer = (*TeeWriter)(nil)
+	_ Writer = (*RingWriter)(nil)
+	_ Writer = (*derivedWriter)(nil)
+
+	_ MsgWriter = (*PostgresqlWriter)(nil)
+	_ MsgWriter = (*ConsoleWriter)(nil)
+	_ MsgWriter = (*MultiWriter)(nil)
+)
+
+// ErrWriterTimeout Writer 未在 Timeout 内完成调用时返回（由 MultiWriter 和 TimeoutWriter 通过回调通知）
+var ErrWriterTimeout = errors.New("writer timed out")
+
+// MultiWriterConfig MultiWriter 配置
+type MultiWriterConfig struct {
+	// Timeout 单个 Writer 处理一次调用的超时时间（0 表示不限制，依次同步调用）
+	// 设置后各 Writer 并发调用，超时的 Writer 被放弃并通过 OnError 回调返回 ErrWriterTimeout，不影响其他 Writer；
+	// 在其恢复之前，发给它的日志会被丢弃
+	Timeout time.Duration   `json:"timeout"`
+	OnError func(err error) `json:"-"` // 超时回调（可选）
+	// Names 按位置与 writers 对应的名称（可选，可以只给前几个命名），出现在超时、关闭等错误中以定位具体的 Writer，
+	// 如 writer "postgres-primary": ...；未命名的 Writer 以序号和类型标识
+	Names []string `json:"names"`
+}
+
 // MultiWriter 多路复用 Writer，可以同时写入多个 Writer（不依赖 go-zero）
 type MultiWriter struct {
 	writers []Writer
+	names   []string
+	timeout time.Duration
+	onError func(err error)
+	stalled []atomic.Bool // 与 writers 一一对应，标记仍未返回的超时调用
 }
 
 // NewMultiWriter 创建一个多路复用 Writer
@@ -32,87 +98,189 @@ func NewMultiWriter(writers ...Writer) *MultiWriter {
 	}
 }
 
+// NewMultiWriterWithConfig 使用配置创建一个多路复用 Writer
+// config: 配置项（可选，传 nil 等同于 NewMultiWriter）
+func NewMultiWriterWithConfig(config *MultiWriterConfig, writers ...Writer) *MultiWriter {
+	m := NewMultiWriter(writers...)
+	if config == nil {
+		return m
+	}
+	m.timeout = config.Timeout
+	m.onError = config.OnError
+	m.names = config.Names
+	m.stalled = make([]atomic.Bool, len(writers))
+	return m
+}
+
+// each 对每个 Writer 执行 fn；设置了 Timeout 时并发执行并等待至超时
+func (m *MultiWriter) each(fn func(w Writer)) {
+	if m.timeout <= 0 {
+		for _, w := range m.writers {
+			fn(w)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i, w := range m.writers {
+		wg.Add(1)
+		go func(i int, w Writer) {
+			defer wg.Done()
+			if callWithTimeout(m.timeout, &m.stalled[i], func() { fn(w) }) {
+				m.handleError(fmt.Errorf("%s exceeded %s: %w", m.label(i), m.timeout, ErrWriterTimeout))
+			}
+		}(i, w)
+	}
+	wg.Wait()
+}
+
+// label 返回错误信息中标识第 i 个 Writer 的文本
+func (m *MultiWriter) label(i int) string {
+	if i < len(m.names) && m.names[i] != "" {
+		return fmt.Sprintf("writer %q", m.names[i])
+	}
+	return fmt.Sprintf("writer %d (%T)", i, m.writers[i])
+}
+
+// detach 设置了 Timeout 时复制字段切片：超时的 Writer 在调用返回后仍可能读取字段，不能与调用方共享
+func (m *MultiWriter) detach(fields []LogField) []LogField {
+	if m.timeout <= 0 {
+		return fields
+	}
+	return slices.Clone(fields)
+}
+
+// handleError 将错误交给 OnError 回调
+func (m *MultiWriter) handleError(err error) {
+	if m.onError != nil {
+		m.onError(err)
+	}
+}
+
 // Log 写入日志（核心方法）
 func (m *MultiWriter) Log(level string, content any, fields ...LogField) {
-	for _, w := range m.writers {
-		w.Log(level, content, fields...)
-	}
+	fields = m.detach(fields)
+	m.each(func(w Writer) { w.Log(level, content, fields...) })
 }
 
 // Info 写入 info 级别日志
 func (m *MultiWriter) Info(content any, fields ...LogField) {
-	for _, w := range m.writers {
-		w.Info(content, fields...)
-	}
+	fields = m.detach(fields)
+	m.each(func(w Writer) { w.Info(content, fields...) })
 }
 
 // Error 写入 error 级别日志
 func (m *MultiWriter) Error(content any, fields ...LogField) {
-	for _, w := range m.writers {
-		w.Error(content, fields...)
-	}
+	fields = m.detach(fields)
+	m.each(func(w Writer) { w.Error(content, fields...) })
 }
 
 // Debug 写入 debug 级别日志
 func (m *MultiWriter) Debug(content any, fields ...LogField) {
-	for _, w := range m.writers {
-		w.Debug(content, fields...)
-	}
+	fields = m.detach(fields)
+	m.each(func(w Writer) { w.Debug(content, fields...) })
 }
 
 // Warn 写入 warn 级别日志
 func (m *MultiWriter) Warn(content any, fields ...LogField) {
+	fields = m.detach(fields)
+	m.each(func(w Writer) { w.Warn(content, fields...) })
+}
+
+// LogMsg 写入只有消息的日志：下游实现了 MsgWriter 时走其快速路径，否则调用 Log
+// 设置了 Timeout 时与 Log 相同（并发调用需要装箱）
+func (m *MultiWriter) LogMsg(level, msg string) {
+	if m.timeout > 0 {
+		m.Log(level, msg)
+		return
+	}
 	for _, w := range m.writers {
-		w.Warn(content, fields...)
+		if mw, ok := w.(MsgWriter); ok {
+			mw.LogMsg(level, msg)
+		} else {
+			w.Log(level, msg)
+		}
 	}
 }
 
+// InfoMsg 写入只有消息的 info 级别日志
+func (m *MultiWriter) InfoMsg(msg string) {
+	m.LogMsg("info", msg)
+}
+
+// ErrorMsg 写入只有消息的 error 级别日志
+func (m *MultiWriter) ErrorMsg(msg string) {
+	m.LogMsg("error", msg)
+}
+
+// DebugMsg 写入只有消息的 debug 级别日志
+func (m *MultiWriter) DebugMsg(msg string) {
+	m.LogMsg("debug", msg)
+}
+
+// WarnMsg 写入只有消息的 warn 级别日志
+func (m *MultiWriter) WarnMsg(msg string) {
+	m.LogMsg("warn", msg)
+}
+
 // Infof 写入 info 级别格式化日志
 func (m *MultiWriter) Infof(format string, args ...any) {
 	content := fmt.Sprintf(format, args...)
-	for _, w := range m.writers {
-		w.Info(content)
-	}
+	m.each(func(w Writer) { w.Info(content) })
 }
 
 // Errorf 写入 error 级别格式化日志
 func (m *MultiWriter) Errorf(format string, args ...any) {
 	content := fmt.Sprintf(format, args...)
-	for _, w := range m.writers {
-		w.Error(content)
-	}
+	m.each(func(w Writer) { w.Error(content) })
 }
 
 // Debugf 写入 debug 级别格式化日志
 func (m *MultiWriter) Debugf(format string, args ...any) {
 	content := fmt.Sprintf(format, args...)
-	for _, w := range m.writers {
-		w.Debug(content)
-	}
+	m.each(func(w Writer) { w.Debug(content) })
 }
 
 // Warnf 写入 warn 级别格式化日志
 func (m *MultiWriter) Warnf(format string, args ...any) {
 	content := fmt.Sprintf(format, args...)
-	for _, w := range m.writers {
-		w.Warn(content)
-	}
+	m.each(func(w Writer) { w.Warn(content) })
 }
 
 // Logf 写入格式化日志
 func (m *MultiWriter) Logf(level string, format string, args ...any) {
 	content := fmt.Sprintf(format, args...)
-	for _, w := range m.writers {
-		w.Log(level, content)
-	}
+	m.each(func(w Writer) { w.Log(level, content) })
+}
+
+// AddEntry 将日志条目提交到所有 Writer
+func (m *MultiWriter) AddEntry(entry LogEntry) {
+	m.each(func(w Writer) { w.AddEntry(entry) })
 }
 
-// Close 关闭所有 Writer
+// Named 返回带组件名的派生 Writer，日志同时写入所有 Writer
+func (m *MultiWriter) Named(component string) Writer {
+	return newDerivedWriter(m, component)
+}
+
+// With 返回附加默认字段的派生 Writer，每条日志都会带上这些字段（调用时传入的同名字段优先）
+func (m *MultiWriter) With(fields ...LogField) Writer {
+	return newFieldsWriter(m, fields)
+}
+
+// Flush 刷新所有 Writer（设置了 Timeout 时同样受超时限制）
+func (m *MultiWriter) Flush() {
+	m.each(func(w Writer) { w.Flush() })
+}
+
+// Close 先刷新再关闭所有 Writer
 func (m *MultiWriter) Close() error {
+	m.Flush()
+
 	var errs []error
-	for _, w := range m.writers {
+	for i, w := range m.writers {
 		if err := w.Close(); err != nil {
-			errs = append(errs, err)
+			errs = append(errs, fmt.Errorf("%s: %w", m.label(i), err))
 		}
 	}
 	if len(errs) > 0 {
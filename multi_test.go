@@ -0,0 +1,95 @@
+package writer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMultiWriterFlushFansOut(t *testing.T) {
+	db := &mockDB{}
+	pg := newTestWriter(t, db, nil)
+	mem := &memoryWriter{}
+	m := NewMultiWriter(pg, mem, NewDisabledWriter())
+
+	m.Info("buffered")
+	if n := len(db.inserts()); n != 0 {
+		t.Fatalf("got %d inserts before Flush, want 0", n)
+	}
+	m.Flush()
+	waitFor(t, "postgres flush", func() bool { return len(db.inserts()) == 1 })
+	if mem.flushes != 1 {
+		t.Errorf("memory writer flushed %d times, want 1", mem.flushes)
+	}
+}
+
+func TestMultiWriterCloseFlushesFirst(t *testing.T) {
+	mem := &memoryWriter{}
+	m := NewMultiWriter(mem)
+	m.Info("hello")
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if mem.flushes != 1 || !mem.closed {
+		t.Errorf("flushes = %d, closed = %v; want flushed then closed", mem.flushes, mem.closed)
+	}
+}
+
+func TestMultiWriterTimeoutIsolatesSlowWriter(t *testing.T) {
+	slow := &slowWriter{release: make(chan struct{})}
+	fast := &memoryWriter{}
+	errs := &errorLog{}
+	m := NewMultiWriterWithConfig(&MultiWriterConfig{
+		Timeout: 20 * time.Millisecond,
+		Names:   []string{"slow-sink"},
+		OnError: errs.add,
+	}, slow, fast)
+
+	start := time.Now()
+	m.Info("first")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Info blocked for %s behind the slow writer", elapsed)
+	}
+	// 超时的 Writer 恢复之前，发给它的日志被丢弃，其他 Writer 不受影响
+	m.Info("second")
+
+	got := errs.all()
+	if len(got) != 1 || !errors.Is(got[0], ErrWriterTimeout) || !strings.Contains(got[0].Error(), `writer "slow-sink"`) {
+		t.Errorf("errors = %v, want one ErrWriterTimeout naming slow-sink", got)
+	}
+	if n := len(fast.all()); n != 2 {
+		t.Errorf("fast writer got %d entries, want 2", n)
+	}
+
+	close(slow.release)
+	waitFor(t, "slow writer to recover", func() bool { return len(slow.all()) == 1 })
+	waitFor(t, "stall flag to clear", func() bool { return !m.stalled[0].Load() })
+	m.Info("third")
+	if entries := slow.all(); len(entries) != 2 || entries[1].Content != "third" {
+		t.Errorf("slow writer entries = %+v, want first and third", entries)
+	}
+}
+
+func TestMultiWriterCloseErrorNamesWriter(t *testing.T) {
+	primary, unnamed, healthy := &memoryWriter{}, &memoryWriter{}, &memoryWriter{}
+	primary.Close()
+	unnamed.Close()
+	m := NewMultiWriterWithConfig(&MultiWriterConfig{Names: []string{"postgres-primary"}}, primary, unnamed, healthy)
+
+	err := m.Close()
+	if err == nil {
+		t.Fatal("Close succeeded, want errors from the already closed writers")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, `writer "postgres-primary": writer is closed`) {
+		t.Errorf("error %q does not name postgres-primary", msg)
+	}
+	// 未命名的 Writer 以序号和类型标识
+	if !strings.Contains(msg, "writer 1 (*writer.memoryWriter)") {
+		t.Errorf("error %q does not identify the unnamed writer", msg)
+	}
+	if strings.Contains(msg, "writer 2") {
+		t.Errorf("error %q mentions the healthy writer", msg)
+	}
+}
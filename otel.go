@@ -0,0 +1,258 @@
+package writer
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// OTelLogRecord OpenTelemetry Logs 数据模型中的一条日志记录
+// 字段与 OTel 规范一一对应，由 OTelExporter 转换为具体 SDK 或 OTLP 协议的类型，本包不依赖 OTel SDK
+type OTelLogRecord struct {
+	Timestamp         time.Time      // 事件发生时间（LogEntry.Timestamp）
+	ObservedTimestamp time.Time      // 写入器收到日志的时间
+	SeverityNumber    int            // 严重程度数值（1-24，0 表示未指定），见 OTelSeverity
+	SeverityText      string         // 原始级别，如 info、severe
+	Body              string         // 日志内容
+	Attributes        map[string]any // 属性：LogEntry 的独立属性和 fields
+	TraceID           string         // 32 位十六进制的 trace ID，trace 不是合法的 W3C trace ID 时为空（原值保留在 trace 属性中）
+	SpanID            string         // 16 位十六进制的 span ID，规则同 TraceID
+}
+
+// OTelExporter 导出 OTel 日志记录的接口，可基于 OTLP gRPC/HTTP exporter 或 OTel SDK 的 LoggerProvider 实现
+type OTelExporter interface {
+	Export(ctx context.Context, records []OTelLogRecord) error
+}
+
+// OTel 严重程度数值（SeverityNumber），每个区间的第一个值
+const (
+	OTelSeverityUnspecified = 0
+	OTelSeverityTrace       = 1
+	OTelSeverityDebug       = 5
+	OTelSeverityInfo        = 9
+	OTelSeverityWarn        = 13
+	OTelSeverityError       = 17
+	OTelSeverityFatal       = 21
+)
+
+// OTelSeverity 将级别映射为 OTel 严重程度数值，SeverityText 使用原始级别
+// slow、stat 映射为 INFO 区间，alert、severe、stack 映射为 FATAL 区间；未知级别为 0（未指定）
+func OTelSeverity(level string) int {
+	switch level {
+	case "trace":
+		return OTelSeverityTrace
+	case "debug":
+		return OTelSeverityDebug
+	case "info", "stat":
+		return OTelSeverityInfo
+	case "slow":
+		return OTelSeverityInfo + 1
+	case "warn":
+		return OTelSeverityWarn
+	case "error":
+		return OTelSeverityError
+	case "alert", "severe":
+		return OTelSeverityFatal
+	case "stack":
+		return OTelSeverityFatal + 1
+	default:
+		return OTelSeverityUnspecified
+	}
+}
+
+// ToOTelRecord 将日志条目转换为 OTel 日志记录
+// 合法的 trace、span 写入 TraceID、SpanID，entry_id 按语义约定写入 log.record.uid 属性，其余属性和字段原名写入 Attributes
+func ToOTelRecord(entry LogEntry) OTelLogRecord {
+	now := time.Now()
+	ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+	if err != nil {
+		ts = now
+	}
+	record := OTelLogRecord{
+		Timestamp:         ts,
+		ObservedTimestamp: now,
+		SeverityNumber:    OTelSeverity(entry.Level),
+		SeverityText:      entry.Level,
+		Body:              entry.Content,
+	}
+
+	kvs := entryFields(entry)
+	if len(kvs) > 0 {
+		record.Attributes = make(map[string]any, len(kvs))
+	}
+	for _, kv := range kvs {
+		switch {
+		case kv.Key == "trace" && isHexID(entry.Trace, 16):
+			record.TraceID = entry.Trace
+		case kv.Key == "span" && isHexID(entry.Span, 8):
+			record.SpanID = entry.Span
+		case kv.Key == "entry_id":
+			record.Attributes["log.record.uid"] = kv.Value
+		default:
+			record.Attributes[kv.Key] = kv.Value
+		}
+	}
+	return record
+}
+
+// isHexID 判断 s 是否为 n 字节的十六进制 ID（W3C trace context 要求不能全为 0）
+func isHexID(s string, n int) bool {
+	if len(s) != 2*n {
+		return false
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return false
+	}
+	for _, c := range b {
+		if c != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// OTelWriter 将日志转换为 OTel 日志记录后批量交给 OTelExporter
+type OTelWriter struct {
+	exporter    OTelExporter
+	timeout     time.Duration
+	beforeWrite func(entry *LogEntry) bool
+
+	batch *batcher
+}
+
+// OTelConfig OTel Writer 配置
+type OTelConfig struct {
+	Exporter      OTelExporter               `json:"-"`              // 日志记录导出器（必填）
+	BufferSize    int                        `json:"buffer_size"`    // 缓冲区大小
+	FlushInterval time.Duration              `json:"flush_interval"` // 刷新间隔
+	MaxInFlight   int                        `json:"max_in_flight"`  // 同时进行的 Export 调用数上限（默认 2），达到上限且缓冲区已满时写日志阻塞
+	Timeout       time.Duration              `json:"timeout"`        // 单次 Export 调用的超时
+	BeforeWrite   func(entry *LogEntry) bool `json:"-"`              // 写入前的钩子（可选），可修改条目，返回 false 丢弃该条日志
+	OnError       func(err error)            `json:"-"`              // 导出失败回调（可选）
+}
+
+// DefaultOTelConfig 返回默认 OTel 配置，Exporter 需由调用方设置
+func DefaultOTelConfig() *OTelConfig {
+	return &OTelConfig{
+		BufferSize:    100,
+		FlushInterval: 5 * time.Second,
+		Timeout:       30 * time.Second,
+	}
+}
+
+// NewOTelWriter 创建一个 OTel 日志写入器
+func NewOTelWriter(config *OTelConfig) (*OTelWriter, error) {
+	if config == nil || config.Exporter == nil {
+		return nil, fmt.Errorf("otel exporter is required")
+	}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	w := &OTelWriter{
+		exporter:    config.Exporter,
+		timeout:     timeout,
+		beforeWrite: config.BeforeWrite,
+	}
+	w.batch = newBatcher(config.BufferSize, config.FlushInterval, config.MaxInFlight, w.writeEntries, config.OnError)
+	return w, nil
+}
+
+// AddEntry 添加一条日志到缓冲区
+// 写入器关闭后调用会丢弃该条目，并通过 OnError 回调返回 ErrWriterClosed
+func (w *OTelWriter) AddEntry(entry LogEntry) {
+	if !applyBeforeWrite(w.beforeWrite, &entry) {
+		return
+	}
+	w.batch.add(entry)
+}
+
+// Log 写入日志（核心方法）
+func (w *OTelWriter) Log(level string, content any, fields ...LogField) {
+	w.AddEntry(newLogEntry(level, content, fields))
+}
+
+// Named 返回带组件名的派生 Writer，与当前 Writer 共享缓冲区
+func (w *OTelWriter) Named(component string) Writer {
+	return newDerivedWriter(w, component)
+}
+
+// With 返回附加默认字段的派生 Writer，每条日志都会带上这些字段（调用时传入的同名字段优先）
+func (w *OTelWriter) With(fields ...LogField) Writer {
+	return newFieldsWriter(w, fields)
+}
+
+// Info 写入 info 级别日志
+func (w *OTelWriter) Info(content any, fields ...LogField) {
+	w.Log("info", content, fields...)
+}
+
+// Error 写入 error 级别日志
+func (w *OTelWriter) Error(content any, fields ...LogField) {
+	w.Log("error", content, fields...)
+}
+
+// Debug 写入 debug 级别日志
+func (w *OTelWriter) Debug(content any, fields ...LogField) {
+	w.Log("debug", content, fields...)
+}
+
+// Warn 写入 warn 级别日志
+func (w *OTelWriter) Warn(content any, fields ...LogField) {
+	w.Log("warn", content, fields...)
+}
+
+// Infof 写入 info 级别格式化日志
+func (w *OTelWriter) Infof(format string, args ...any) {
+	w.Log("info", fmt.Sprintf(format, args...))
+}
+
+// Errorf 写入 error 级别格式化日志
+func (w *OTelWriter) Errorf(format string, args ...any) {
+	w.Log("error", fmt.Sprintf(format, args...))
+}
+
+// Debugf 写入 debug 级别格式化日志
+func (w *OTelWriter) Debugf(format string, args ...any) {
+	w.Log("debug", fmt.Sprintf(format, args...))
+}
+
+// Warnf 写入 warn 级别格式化日志
+func (w *OTelWriter) Warnf(format string, args ...any) {
+	w.Log("warn", fmt.Sprintf(format, args...))
+}
+
+// Logf 写入格式化日志
+func (w *OTelWriter) Logf(level string, format string, args ...any) {
+	w.Log(level, fmt.Sprintf(format, args...))
+}
+
+// Flush 将缓冲区中的日志异步提交到导出器
+func (w *OTelWriter) Flush() {
+	w.batch.flush()
+}
+
+// writeEntries 转换日志条目并调用导出器
+func (w *OTelWriter) writeEntries(entries []LogEntry) error {
+	records := make([]OTelLogRecord, len(entries))
+	for i, entry := range entries {
+		records[i] = ToOTelRecord(entry)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	defer cancel()
+	if err := w.exporter.Export(ctx, records); err != nil {
+		return fmt.Errorf("failed to export %d log records: %w", len(records), err)
+	}
+	return nil
+}
+
+// Close 关闭写入器，等待所有缓冲的日志导出完成
+// 关闭后的写入契约与 PostgresqlWriter.Close 相同；导出器的关闭由调用方负责
+func (w *OTelWriter) Close() error {
+	return w.batch.close()
+}
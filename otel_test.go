@@ -0,0 +1,77 @@
+package writer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOTelSeverityMapping(t *testing.T) {
+	tests := map[string]int{
+		"trace":   1,
+		"debug":   5,
+		"info":    9,
+		"stat":    9,
+		"slow":    10,
+		"warn":    13,
+		"error":   17,
+		"alert":   21,
+		"severe":  21,
+		"stack":   22,
+		"verbose": 0,
+	}
+	for level, want := range tests {
+		if got := OTelSeverity(level); got != want {
+			t.Errorf("OTelSeverity(%q) = %d, want %d", level, got, want)
+		}
+	}
+}
+
+func TestOTelWriterMapsEntries(t *testing.T) {
+	exporter := &recordingExporter{}
+	w, err := NewOTelWriter(&OTelConfig{Exporter: exporter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := int64(42)
+	traceID, spanID := "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	w.AddEntry(LogEntry{
+		Timestamp: "2025-01-02T03:04:05.5Z",
+		Level:     "warn",
+		Content:   "disk almost full",
+		Trace:     traceID,
+		Span:      spanID,
+		EntryID:   "e-1",
+		UserID:    &uid,
+		Fields:    map[string]any{"mount": "/var"},
+	})
+	w.AddEntry(LogEntry{Level: "info", Content: "legacy trace", Trace: "req-123"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records := exporter.all()
+	if len(records) != 2 {
+		t.Fatalf("exported %d records, want 2", len(records))
+	}
+	rec := records[0]
+	if !rec.Timestamp.Equal(time.Date(2025, 1, 2, 3, 4, 5, 5e8, time.UTC)) {
+		t.Errorf("Timestamp = %v", rec.Timestamp)
+	}
+	if rec.SeverityNumber != OTelSeverityWarn || rec.SeverityText != "warn" || rec.Body != "disk almost full" {
+		t.Errorf("severity/body = %d %q %q", rec.SeverityNumber, rec.SeverityText, rec.Body)
+	}
+	if rec.TraceID != traceID || rec.SpanID != spanID {
+		t.Errorf("trace context = %q/%q, want %q/%q", rec.TraceID, rec.SpanID, traceID, spanID)
+	}
+	wantAttrs := map[string]any{"log.record.uid": "e-1", "user_id": int64(42), "mount": "/var"}
+	if !reflect.DeepEqual(rec.Attributes, wantAttrs) {
+		t.Errorf("Attributes = %v, want %v", rec.Attributes, wantAttrs)
+	}
+
+	// 不是 W3C 格式的 trace 不进入 trace context，原值保留为属性
+	legacy := records[1]
+	if legacy.TraceID != "" || legacy.Attributes["trace"] != "req-123" {
+		t.Errorf("legacy trace = TraceID %q, attributes %v", legacy.TraceID, legacy.Attributes)
+	}
+}
@@ -3,150 +3,1337 @@ package writer
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // PostgresqlWriter 将日志写入 PostgreSQL 数据库
 type PostgresqlWriter struct {
-	db            DBExecutor
-	tableName     string
-	bufferSize    int
-	flushInterval time.Duration
+	db                 DBExecutor
+	disabled           bool
+	tableName          string
+	tableNameFunc      func(entry LogEntry) string
+	shardTables        sync.Map   // TableNameFunc 生成且已确保存在的表
+	shardMux           sync.Mutex // 串行化按需建表
+	errorTableName     string
+	levelTables        map[string]string
+	staticTables       []string // configuredTables 的结果，构造时计算
+	bufferSize         int
+	flushInterval      time.Duration
+	fieldStorage       FieldStorage
+	placeholderStyle   PlaceholderStyle
+	timestampType      TimestampType
+	primaryKey         PrimaryKeyType
+	auditChain         AuditChainMode
+	auditPrev          string // 由 bufferMux 保护，哈希链当前的链尾
+	useUTC             bool
+	insertColumns      []string
+	disabledColumns    map[string]bool // DisabledColumns，建表和写入时省略
+	contentMaxLength   int
+	durationStorage    DurationStorage
+	onError            func(err error)
+	beforeWrite        func(entry *LogEntry) bool
+	validateBatch      func(entries []LogEntry) error
+	onFlush            func(n int, d time.Duration)
+	fallback           Writer
+	escalationRules    []EscalationRule
+	minLevel           int
+	levelEnum          string
+	levelValues        []string
+	levels             *levelPolicy
+	fieldKeyFunc       func(string) string
+	specialKeys        SpecialKeyMode
+	specialKeyWarned   sync.Map // 已报告过类型不符的特殊字段名
+	captureErrorChain  bool
+	errorColumn        bool
+	parseContentFields bool
+	flushOnError       bool
+	manualFlush        bool
+	errorFlushInterval time.Duration
+	idleFlushInterval  time.Duration
+	idleKick           chan struct{}                    // 空闲刷新模式下 AddEntry 通知刷新协程有新日志
+	newTimer           func(d time.Duration) flushTimer // 空闲刷新使用的定时器，测试时可替换
+	errorFlushTimer    *time.Timer                      // 由 bufferMux 保护，已安排的错误日志快速刷新
+	includeGoroutineID bool
+	includeCaller      bool
+	callerFunction     bool
+	sanitizeStrings    bool
+	maxFieldBytes      int
+	maxFieldsBytes     int
+	fieldOverflow      FieldOverflowMode
+	flattenSeparator   string // 非空表示启用嵌套字段展开
+	flattenMaxDepth    int
+	emptyContent       EmptyContentMode
+	emptyPlaceholder   string
+	content            contentFormatter // NilContent、MaxReaderBytes
+	trimTrailingSpace  bool
+	idGenerator        func() string
+
+	allowedLogTypes    map[string]struct{}
+	defaultLogType     string
+	unknownLogType     string
+	warnUnknownLogType bool
+
+	sampler *traceSampler
+
+	limiter           *tokenBucket
+	limitExemptErrors bool
+	suppressed        atomic.Int64 // 自上次汇总以来被限流丢弃的条数
+	suppressedTotal   atomic.Int64 // 累计被限流丢弃的条数
+
+	highWater         int
+	highWaterDuration time.Duration
+	highWaterSince    time.Time // 由 bufferMux 保护，首次超过高水位的时间
+	highWaterWarned   bool      // 由 bufferMux 保护，本次积压是否已告警
+
+	spill          *diskSpill // 未配置 SpillFile 时为 nil
+	spillThreshold int
+	spilled        atomic.Int64 // 累计写入溢出文件的条数
+
+	inflight atomic.Int64 // 已从缓冲区取出但尚未写完的条数
+
+	maxWrites    int
+	activeWrites int  // 由 bufferMux 保护，正在写库的协程数
+	pendingFlush bool // 由 bufferMux 保护，写入达到上限时被推迟的刷新
+
+	inlineFlushOnSaturation bool
+	inlineFlushes           atomic.Int64 // 因写库协程已满而由调用方同步写入的次数
+
+	blockOnSaturation bool
+	strictOrder       bool
+	writeSlotFree     *sync.Cond   // 与 bufferMux 关联，写库协程释放名额时广播
+	flushWait         atomic.Int64 // 因等待写入名额而阻塞的累计纳秒数
+
+	maxRetries      int
+	retryBackoff    time.Duration
+	retryClassifier func(err error) bool
+	retries         atomic.Int64 // 累计重试次数
+	writeTimeout    time.Duration
+
+	multiRowInsert bool
+
+	written   atomic.Int64 // 累计写入数据库的条数
+	failed    atomic.Int64 // 累计写入失败（含被 ValidateBatch 拒绝）的条数
+	flushes   atomic.Int64 // 累计写库批次数
+	flushTime atomic.Int64 // 写库批次的累计耗时（纳秒）
+
+	heartbeatInterval time.Duration
+
+	levelCounts sync.Map // level -> *atomic.Int64，各级别的 Log 调用次数
+
+	notifyChannel string
+
+	retention         time.Duration
+	retentionInterval time.Duration
+	maintenanceDB     DBExecutor // 维护操作（清理）使用的执行器，默认与 db 相同
+
+	retentionDropPartitions bool
+
+	searchPath    string
+	verifyOnStart bool
+
+	healthCheckInterval time.Duration
+	healthy             atomic.Bool // 最近一次健康检查是否成功，未启用检查时始终为 true
 
 	buffer    []LogEntry
+	batchPool sync.Pool // 复用已写完的批次切片
 	bufferMux sync.Mutex
+	closed    bool // 由 bufferMux 保护，Close 后拒绝新日志
+	started   bool // 由 bufferMux 保护，Start 成功前日志只进入缓冲区
+	startMux  sync.Mutex
 	done      chan struct{}
 	wg        sync.WaitGroup
 }
 
-// NewPostgresqlWriter 创建一个 PostgreSQL 日志写入器
-// db: 实现 DBExecutor 接口的数据库执行器
-// config: 配置项（可选，传 nil 使用默认配置）
-func NewPostgresqlWriter(db DBExecutor, config *PostgresConfig) (*PostgresqlWriter, error) {
-	if db == nil {
-		return nil, fmt.Errorf("db executor is required")
+// NewPostgresqlWriter 创建一个 PostgreSQL 日志写入器
+// db: 实现 DBExecutor 接口的数据库执行器
+// config: 配置项（可选，传 nil 使用默认配置）
+func NewPostgresqlWriter(db DBExecutor, config *PostgresConfig) (*PostgresqlWriter, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db executor is required")
+	}
+
+	if config == nil {
+		config = DefaultPostgresConfig()
+	}
+
+	fieldStorage := config.FieldStorage
+	switch fieldStorage {
+	case "":
+		fieldStorage = FieldStorageJSONB
+	case FieldStorageJSONB, FieldStorageHstore, FieldStorageText:
+	default:
+		return nil, fmt.Errorf("unsupported field storage: %s", fieldStorage)
+	}
+
+	if config.NotifyChannel != "" && !isValidIdentifier(config.NotifyChannel) {
+		return nil, fmt.Errorf("invalid notify channel: %q", config.NotifyChannel)
+	}
+
+	placeholderStyle := config.PlaceholderStyle
+	switch placeholderStyle {
+	case "":
+		placeholderStyle = PlaceholderDollar
+	case PlaceholderDollar, PlaceholderQuestion:
+	default:
+		return nil, fmt.Errorf("unsupported placeholder style: %s", placeholderStyle)
+	}
+
+	if config.MinLevel != "" {
+		if _, ok := levelRanks[config.MinLevel]; !ok {
+			return nil, fmt.Errorf("unsupported min level: %s", config.MinLevel)
+		}
+	}
+
+	searchPath, err := parseSearchPath(config.SearchPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if !validUnknownLevelPolicy(config.UnknownLevelPolicy) {
+		return nil, fmt.Errorf("unsupported unknown level policy: %s", config.UnknownLevelPolicy)
+	}
+	levelPolicyMode := config.UnknownLevelPolicy
+	var levelValues []string
+	if config.LevelEnum != "" {
+		if !isValidIdentifier(config.LevelEnum) {
+			return nil, fmt.Errorf("invalid level enum name: %q", config.LevelEnum)
+		}
+		if levelValues, err = levelEnumValues(config.LevelEnumValues, config.UnknownLevel); err != nil {
+			return nil, err
+		}
+		switch levelPolicyMode {
+		case "":
+			// 枚举列无法存入未知级别：配置了 UnknownLevel 时替换，否则丢弃
+			levelPolicyMode = UnknownLevelReject
+			if config.UnknownLevel != "" {
+				levelPolicyMode = UnknownLevelMap
+			}
+		case UnknownLevelAccept, UnknownLevelWarn:
+			return nil, fmt.Errorf("unknown level policy %s is not compatible with level enum", levelPolicyMode)
+		}
+	} else if levelValues, err = levelEnumValues(config.LevelEnumValues, ""); err != nil {
+		return nil, err
+	}
+
+	switch config.FieldOverflow {
+	case "", FieldOverflowTruncate, FieldOverflowDrop:
+	default:
+		return nil, fmt.Errorf("unsupported field overflow mode: %s", config.FieldOverflow)
+	}
+
+	switch config.SpecialKeys {
+	case "", SpecialKeysExtract, SpecialKeysWarn, SpecialKeysOff:
+	default:
+		return nil, fmt.Errorf("unsupported special keys mode: %s", config.SpecialKeys)
+	}
+
+	switch config.EmptyContent {
+	case "", EmptyContentKeep, EmptyContentSkip, EmptyContentPlaceholder:
+	default:
+		return nil, fmt.Errorf("unsupported empty content mode: %s", config.EmptyContent)
+	}
+
+	timestampType := config.TimestampType
+	switch timestampType {
+	case "":
+		timestampType = TimestampTypeTZ
+	case TimestampTypeTZ, TimestampTypeNoTZ:
+	default:
+		return nil, fmt.Errorf("unsupported timestamp type: %s", timestampType)
+	}
+
+	primaryKey := config.PrimaryKey
+	switch primaryKey {
+	case "":
+		primaryKey = PrimaryKeyBigSerial
+	case PrimaryKeyBigSerial, PrimaryKeyUUIDv7:
+	default:
+		return nil, fmt.Errorf("unsupported primary key type: %s", primaryKey)
+	}
+
+	durationStorage := config.DurationStorage
+	switch durationStorage {
+	case "":
+		durationStorage = DurationStorageText
+	case DurationStorageText, DurationStorageInterval, DurationStorageMilliseconds:
+	default:
+		return nil, fmt.Errorf("unsupported duration storage: %s", durationStorage)
+	}
+
+	if config.ContentMaxLength < 0 || config.ContentMaxLength > maxVarcharLength {
+		return nil, fmt.Errorf("content max length must be between 0 and %d", maxVarcharLength)
+	}
+
+	for _, column := range config.DisabledColumns {
+		if !slices.Contains(optionalColumns, column) {
+			return nil, fmt.Errorf("column %q cannot be disabled", column)
+		}
+	}
+
+	switch config.AuditChain {
+	case "", AuditChainAudit, AuditChainAll:
+	default:
+		return nil, fmt.Errorf("unsupported audit chain mode: %s", config.AuditChain)
+	}
+
+	w := &PostgresqlWriter{
+		db:                      db,
+		disabled:                config.Disabled,
+		tableName:               config.TableName,
+		tableNameFunc:           config.TableNameFunc,
+		errorTableName:          config.ErrorTableName,
+		levelTables:             maps.Clone(config.LevelTables),
+		bufferSize:              config.BufferSize,
+		flushInterval:           config.FlushInterval,
+		fieldStorage:            fieldStorage,
+		placeholderStyle:        placeholderStyle,
+		timestampType:           timestampType,
+		useUTC:                  config.UseUTC,
+		primaryKey:              primaryKey,
+		auditChain:              config.AuditChain,
+		insertColumns:           []string{"timestamp", "level", "content", "log_type", "duration", "trace", "span", "user_id", "username", "fields", "entry_id", "expires_at", "component"},
+		onError:                 config.OnError,
+		beforeWrite:             config.BeforeWrite,
+		validateBatch:           config.ValidateBatch,
+		contentMaxLength:        config.ContentMaxLength,
+		durationStorage:         durationStorage,
+		onFlush:                 config.OnFlush,
+		fallback:                config.Fallback,
+		escalationRules:         config.EscalationRules,
+		minLevel:                levelRank(config.MinLevel),
+		fieldKeyFunc:            config.FieldKeyFunc,
+		specialKeys:             config.SpecialKeys,
+		captureErrorChain:       config.CaptureErrorChain,
+		errorColumn:             config.ErrorColumn,
+		parseContentFields:      config.ParseContentFields,
+		flushOnError:            config.FlushOnError,
+		manualFlush:             config.ManualFlush,
+		errorFlushInterval:      config.ErrorFlushInterval,
+		idleFlushInterval:       config.IdleFlushInterval,
+		newTimer:                newRealTimer,
+		includeGoroutineID:      config.IncludeGoroutineID,
+		includeCaller:           config.IncludeCaller,
+		callerFunction:          config.CallerFunction,
+		sanitizeStrings:         config.SanitizeStrings,
+		maxFieldBytes:           config.MaxFieldBytes,
+		maxFieldsBytes:          config.MaxFieldsBytes,
+		fieldOverflow:           config.FieldOverflow,
+		emptyContent:            config.EmptyContent,
+		emptyPlaceholder:        config.EmptyContentPlaceholder,
+		content:                 newContentFormatter(config.NilContent, config.MaxReaderBytes),
+		trimTrailingSpace:       config.TrimTrailingSpace,
+		idGenerator:             config.IDGenerator,
+		buffer:                  make([]LogEntry, 0, config.BufferSize),
+		done:                    make(chan struct{}),
+		highWater:               config.BufferHighWater,
+		highWaterDuration:       config.BufferHighWaterDuration,
+		notifyChannel:           config.NotifyChannel,
+		retention:               config.Retention,
+		retentionInterval:       config.RetentionInterval,
+		maintenanceDB:           config.MaintenanceDB,
+		retentionDropPartitions: config.RetentionDropPartitions,
+		healthCheckInterval:     config.HealthCheckInterval,
+		heartbeatInterval:       config.HeartbeatInterval,
+		maxWrites:               config.MaxConcurrentWrites,
+		inlineFlushOnSaturation: config.InlineFlushOnSaturation,
+		blockOnSaturation:       config.BlockOnSaturation,
+		strictOrder:             config.StrictOrder,
+		maxRetries:              config.MaxRetries,
+		retryBackoff:            config.RetryBackoff,
+		retryClassifier:         config.RetryClassifier,
+		writeTimeout:            config.WriteTimeout,
+		multiRowInsert:          config.MultiRowInsert,
+		searchPath:              searchPath,
+		verifyOnStart:           config.VerifyTable,
+	}
+	w.writeSlotFree = sync.NewCond(&w.bufferMux)
+	w.staticTables = w.configuredTables()
+	if w.maxWrites <= 0 {
+		w.maxWrites = defaultMaxConcurrentWrites
+	}
+	if w.strictOrder {
+		w.maxWrites = 1
+	}
+	if w.retryBackoff <= 0 {
+		w.retryBackoff = defaultRetryBackoff
+	}
+	if w.writeTimeout <= 0 {
+		w.writeTimeout = defaultWriteTimeout
+	}
+	if w.flushInterval <= 0 {
+		w.flushInterval = DefaultPostgresConfig().FlushInterval
+	}
+	if w.manualFlush {
+		// 手动刷新模式下不按时间刷新
+		w.idleFlushInterval = 0
+		w.errorFlushInterval = 0
+	}
+	if w.idleFlushInterval > 0 {
+		w.idleKick = make(chan struct{}, 1)
+	}
+
+	w.defaultLogType = config.DefaultLogType
+	if len(config.DisabledColumns) > 0 {
+		w.disabledColumns = make(map[string]bool, len(config.DisabledColumns))
+		for _, column := range config.DisabledColumns {
+			w.disabledColumns[column] = true
+		}
+		columns := w.insertColumns[:0]
+		for _, column := range w.insertColumns {
+			if w.hasColumn(column) {
+				columns = append(columns, column)
+			}
+		}
+		w.insertColumns = columns
+	}
+	if primaryKey == PrimaryKeyUUIDv7 {
+		// id 由客户端生成，放在首列
+		w.insertColumns = append([]string{"id"}, w.insertColumns...)
+	}
+	if w.auditChain != "" {
+		w.insertColumns = append(w.insertColumns, "prev_hash", "hash")
+	}
+	if w.errorColumn {
+		w.insertColumns = append(w.insertColumns, ErrorKey)
+	}
+	if config.FlattenFields {
+		w.flattenSeparator = config.FlattenSeparator
+		if w.flattenSeparator == "" {
+			w.flattenSeparator = defaultFlattenSeparator
+		}
+		w.flattenMaxDepth = config.FlattenMaxDepth
+		if w.flattenMaxDepth <= 0 {
+			w.flattenMaxDepth = defaultFlattenMaxDepth
+		}
+	}
+	if config.LevelEnum != "" {
+		w.levelEnum = config.LevelEnum
+		// map 策略未指定 UnknownLevel 时替换为 info，已在内置级别中
+		w.levelValues = levelValues
+	}
+	w.levels = newLevelPolicy(levelPolicyMode, levelValues, config.UnknownLevel)
+	if len(config.AllowedLogTypes) > 0 {
+		w.allowedLogTypes = make(map[string]struct{}, len(config.AllowedLogTypes))
+		for _, t := range config.AllowedLogTypes {
+			w.allowedLogTypes[t] = struct{}{}
+		}
+		w.unknownLogType = config.UnknownLogType
+		w.warnUnknownLogType = config.WarnUnknownLogType
+	}
+
+	if config.TraceSampleRate > 0 && config.TraceSampleRate < 1 {
+		w.sampler = newTraceSampler(config.TraceSampleRate, config.TraceSampleLevels)
+	}
+
+	if config.RateLimit > 0 {
+		w.limiter = newTokenBucket(config.RateLimit, config.RateBurst)
+		w.limitExemptErrors = config.RateLimitExemptErrors
+	}
+
+	if w.maintenanceDB == nil {
+		w.maintenanceDB = db
+	}
+	w.healthy.Store(true)
+
+	// 禁用时不访问数据库，也不启动刷新协程
+	if w.disabled {
+		return w, nil
+	}
+
+	if config.SpillFile != "" {
+		if w.auditChain != "" {
+			return nil, fmt.Errorf("spill file is not compatible with audit chain")
+		}
+		if w.spill, err = openSpill(config.SpillFile); err != nil {
+			return nil, err
+		}
+		w.spillThreshold = config.SpillThreshold
+		if w.spillThreshold <= 0 {
+			w.spillThreshold = 10 * w.bufferSize
+		}
+	}
+
+	// 延迟启动时不访问数据库，由调用方在数据库就绪后调用 Start
+	if config.DeferStart {
+		return w, nil
+	}
+	if err := w.Start(context.Background()); err != nil {
+		if w.spill != nil {
+			w.spill.close()
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
+// Start 连接数据库、确保表存在并启动后台协程；未开启 DeferStart 时 NewPostgresqlWriter 已自动调用
+// Start 之前写入的日志保留在缓冲区中，启动成功后立即写出；失败时可以重试，成功后再次调用返回 ErrWriterStarted
+func (w *PostgresqlWriter) Start(ctx context.Context) error {
+	w.startMux.Lock()
+	defer w.startMux.Unlock()
+
+	w.bufferMux.Lock()
+	started, closed := w.started, w.closed
+	w.bufferMux.Unlock()
+	if closed {
+		return ErrWriterClosed
+	}
+	if started {
+		return ErrWriterStarted
+	}
+	if w.disabled {
+		return nil
+	}
+
+	// 测试连接
+	if err := w.db.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	// 设置 search_path，使未限定 schema 的表建在目标 schema 中
+	if w.searchPath != "" {
+		if err := w.db.Exec(ctx, "SET search_path TO "+w.searchPath); err != nil {
+			return fmt.Errorf("failed to set search_path: %w", err)
+		}
+	}
+
+	// 确保表存在
+	if err := w.ensureTables(ctx); err != nil {
+		return fmt.Errorf("failed to ensure table: %w", err)
+	}
+
+	if w.verifyOnStart {
+		if err := w.VerifyTable(ctx); err != nil {
+			return err
+		}
+	}
+
+	// 恢复审计哈希链的链尾
+	if w.auditChain != "" {
+		if err := w.loadAuditTip(ctx); err != nil {
+			return err
+		}
+	}
+
+	// 链尾确定后再为 Start 之前缓冲的条目建立哈希链，随后立即写出
+	w.bufferMux.Lock()
+	w.started = true
+	for i := range w.buffer {
+		if w.buffer[i].Hash == "" && w.chainedLocked(w.buffer[i]) {
+			w.chainEntryLocked(&w.buffer[i])
+		}
+	}
+	w.flushLocked()
+	w.bufferMux.Unlock()
+
+	// 上次关闭时留在溢出文件中的日志
+	if w.spill != nil {
+		w.Flush()
+	}
+
+	// 启动后台刷新协程（手动刷新模式下不启动）
+	if !w.manualFlush {
+		w.wg.Add(1)
+		go w.flushLoop()
+	}
+
+	// 启动后台清理协程
+	if w.retention > 0 && w.retentionInterval <= 0 {
+		w.retentionInterval = time.Hour
+	}
+	if w.retentionInterval > 0 {
+		w.wg.Add(1)
+		go w.retentionLoop()
+	}
+
+	// 启动健康检查协程
+	if w.healthCheckInterval > 0 {
+		w.wg.Add(1)
+		go w.healthLoop()
+	}
+
+	// 启动心跳协程
+	if w.heartbeatInterval > 0 {
+		w.wg.Add(1)
+		go w.heartbeatLoop(w.Stats())
+	}
+
+	return nil
+}
+
+// ensureTables 确保所有日志表存在
+func (w *PostgresqlWriter) ensureTables(ctx context.Context) error {
+	// hstore 类型依赖扩展
+	if w.fieldStorage == FieldStorageHstore && w.hasColumn("fields") {
+		if err := w.db.Exec(ctx, `CREATE EXTENSION IF NOT EXISTS hstore`); err != nil {
+			return err
+		}
+	}
+
+	if w.levelEnum != "" {
+		if err := w.ensureLevelEnum(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, table := range w.tables() {
+		if err := w.ensureTable(ctx, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureLevelEnum 创建级别枚举类型，已存在时补齐缺失的值
+func (w *PostgresqlWriter) ensureLevelEnum(ctx context.Context) error {
+	values := make([]string, len(w.levelValues))
+	for i, level := range w.levelValues {
+		values[i] = quoteLiteral(level)
+	}
+	// CREATE TYPE 不支持 IF NOT EXISTS，用 DO 块忽略重复创建
+	create := fmt.Sprintf(`DO $$ BEGIN CREATE TYPE %s AS ENUM (%s); EXCEPTION WHEN duplicate_object THEN NULL; END $$`,
+		w.levelEnum, strings.Join(values, ", "))
+	if err := w.db.Exec(ctx, create); err != nil {
+		return fmt.Errorf("failed to create level enum: %w", err)
+	}
+	for _, value := range values {
+		if err := w.db.Exec(ctx, fmt.Sprintf(`ALTER TYPE %s ADD VALUE IF NOT EXISTS %s`, w.levelEnum, value)); err != nil {
+			return fmt.Errorf("failed to extend level enum: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkLevel 按 UnknownLevelPolicy 处理未知级别，返回错误表示该条日志应被丢弃（warn 策略的提示直接交给 OnError）
+func (w *PostgresqlWriter) checkLevel(level string) (string, error) {
+	resolved, keep, err := w.levels.resolve(level)
+	if !keep {
+		return level, err
+	}
+	if err != nil {
+		w.handleError(err)
+	}
+	return resolved, nil
+}
+
+// tables 返回写入器使用的所有日志表（去重）
+func (w *PostgresqlWriter) tables() []string {
+	return append(slices.Clip(w.staticTables), w.shardTableList()...)
+}
+
+// configuredTables 返回配置中确定的表（TableName、ErrorTableName 及 LevelTables 中的表，去重），均在 Start 时创建
+func (w *PostgresqlWriter) configuredTables() []string {
+	tables := []string{w.tableName}
+	if w.errorTableName != "" && w.errorTableName != w.tableName {
+		tables = append(tables, w.errorTableName)
+	}
+	levelTables := slices.Sorted(maps.Values(w.levelTables))
+	for _, table := range slices.Compact(levelTables) {
+		if table != "" && !slices.Contains(tables, table) {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// multiTable 日志是否可能写入多张表，此时每批写入前按表分组
+func (w *PostgresqlWriter) multiTable() bool {
+	return w.tableNameFunc != nil || w.errorTableName != "" || len(w.levelTables) > 0
+}
+
+// columnDef 日志表中的一列
+type columnDef struct {
+	name string
+	typ  string
+}
+
+// maxVarcharLength PostgreSQL VARCHAR(n) 允许的最大长度
+const maxVarcharLength = 10485760
+
+// coreColumns 不能通过 DisabledColumns 关闭的列
+var coreColumns = map[string]bool{"timestamp": true, "level": true, "content": true}
+
+// optionalColumns 可以通过 DisabledColumns 关闭的列
+var optionalColumns = []string{"log_type", "duration", "trace", "span", "user_id", "username", "fields", "entry_id", "expires_at", "component"}
+
+// indexedColumns 自动创建索引的列
+var indexedColumns = []string{"timestamp", "level", "trace", "user_id", "log_type", "username", "entry_id", "expires_at", "component"}
+
+// hasColumn 判断列是否存在于日志表中（未被 DisabledColumns 关闭）
+func (w *PostgresqlWriter) hasColumn(name string) bool {
+	return !w.disabledColumns[name]
+}
+
+// tableColumns 返回日志表的列定义（不含 id），已关闭的列被省略
+func (w *PostgresqlWriter) tableColumns() []columnDef {
+	all := []columnDef{
+		{"timestamp", w.timestampColumnType() + " NOT NULL DEFAULT NOW()"},
+		{"level", w.levelColumnType() + " NOT NULL"},
+		{"content", w.contentColumnType()},
+		{"log_type", "VARCHAR(20)"},
+		{"duration", w.durationColumnType()},
+		{"trace", "VARCHAR(100)"},
+		{"span", "VARCHAR(100)"},
+		{"user_id", "BIGINT"},
+		{"username", "VARCHAR(100)"},
+		{"fields", w.fieldsColumnType()},
+		{"entry_id", "VARCHAR(64)"},
+		{"expires_at", "TIMESTAMPTZ"},
+		{"component", "VARCHAR(100)"},
+	}
+	if w.auditChain != "" {
+		all = append(all, columnDef{"prev_hash", "VARCHAR(64)"}, columnDef{"hash", "VARCHAR(64)"})
+	}
+	if w.errorColumn {
+		all = append(all, columnDef{ErrorKey, "TEXT"})
+	}
+
+	columns := all[:0]
+	for _, col := range all {
+		if w.hasColumn(col.name) {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// ensureTable 确保日志表存在并执行必要的迁移
+func (w *PostgresqlWriter) ensureTable(ctx context.Context, table string) error {
+	columns := w.tableColumns()
+
+	// 创建表（如果不存在）
+	defs := make([]string, 0, len(columns)+1)
+	defs = append(defs, fmt.Sprintf("id %s PRIMARY KEY", w.idColumnType()))
+	for _, col := range columns {
+		defs = append(defs, col.name+" "+col.typ)
+	}
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n\t%s\n)", table, strings.Join(defs, ",\n\t"))
+
+	if err := w.db.Exec(ctx, query); err != nil {
+		return err
+	}
+
+	// 迁移：添加可能缺失的列（用于已存在的表）
+	for _, col := range columns {
+		if coreColumns[col.name] {
+			continue
+		}
+		migration := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s`, table, col.name, col.typ)
+		if err := w.db.Exec(ctx, migration); err != nil {
+			// 忽略迁移错误，继续执行（某些数据库可能不支持 IF NOT EXISTS）
+			continue
+		}
+	}
+
+	// 创建索引
+	indexed := indexedColumns
+	if w.auditChain != "" {
+		// 查找链尾时按 prev_hash 查找后继
+		indexed = append(indexed[:len(indexed):len(indexed)], "prev_hash")
+	}
+	for _, column := range indexed {
+		if !w.hasColumn(column) {
+			continue
+		}
+		idx := fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_%s ON %s(%s)`, table, column, table, column)
+		if err := w.db.Exec(ctx, idx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tableFor 返回日志条目的目标表：TableNameFunc 返回非空值时以其为准，其次为 LevelTables 中该级别的表，
+// 再次为配置了 ErrorTableName 时错误级别写入错误表
+func (w *PostgresqlWriter) tableFor(entry LogEntry) string {
+	if w.tableNameFunc != nil {
+		if table := w.tableNameFunc(entry); table != "" {
+			return table
+		}
+	}
+	if table := w.levelTables[entry.Level]; table != "" {
+		return table
+	}
+	if w.errorTableName != "" && isErrorLevel(entry.Level) {
+		return w.errorTableName
+	}
+	return w.tableName
+}
+
+// idColumnType 返回 id 列的 SQL 类型
+func (w *PostgresqlWriter) idColumnType() string {
+	if w.primaryKey == PrimaryKeyUUIDv7 {
+		return "UUID"
+	}
+	return "BIGSERIAL"
+}
+
+// levelColumnType 返回 level 列的 SQL 类型
+func (w *PostgresqlWriter) levelColumnType() string {
+	if w.levelEnum != "" {
+		return w.levelEnum
+	}
+	return "VARCHAR(20)"
+}
+
+// timestampColumnType 返回 timestamp 列的 SQL 类型
+func (w *PostgresqlWriter) timestampColumnType() string {
+	if w.timestampType == TimestampTypeNoTZ {
+		return "TIMESTAMP"
+	}
+	return "TIMESTAMPTZ"
+}
+
+// dbTime 按配置转换写入数据库的时间
+func (w *PostgresqlWriter) dbTime(t time.Time) time.Time {
+	if w.useUTC {
+		return t.UTC()
+	}
+	return t
+}
+
+// contentColumnType 返回 content 列的 SQL 类型
+func (w *PostgresqlWriter) contentColumnType() string {
+	if w.contentMaxLength > 0 {
+		return fmt.Sprintf("VARCHAR(%d)", w.contentMaxLength)
+	}
+	return "TEXT"
+}
+
+// durationColumnType 返回 duration 列的 SQL 类型
+func (w *PostgresqlWriter) durationColumnType() string {
+	switch w.durationStorage {
+	case DurationStorageInterval:
+		return "INTERVAL"
+	case DurationStorageMilliseconds:
+		return "DOUBLE PRECISION"
+	default:
+		return "VARCHAR(50)"
+	}
+}
+
+// durationArg 返回 duration 列的 INSERT 参数：text 原样写入，其余按已规范化的 Duration 转换，为空时写入 NULL
+func (w *PostgresqlWriter) durationArg(duration string) any {
+	if w.durationStorage == DurationStorageText {
+		return duration
+	}
+	d, ok := parseDurationText(duration)
+	if !ok {
+		return nil
+	}
+	if w.durationStorage == DurationStorageInterval {
+		return fmt.Sprintf("%d microseconds", d.Microseconds())
+	}
+	return float64(d) / float64(time.Millisecond)
+}
+
+// fieldsColumnType 返回 fields 列的 SQL 类型
+func (w *PostgresqlWriter) fieldsColumnType() string {
+	switch w.fieldStorage {
+	case FieldStorageHstore:
+		return "HSTORE"
+	case FieldStorageText:
+		return "TEXT"
+	default:
+		return "JSONB"
+	}
+}
+
+// encodeFields 按存储类型序列化 fields
+func (w *PostgresqlWriter) encodeFields(fields map[string]interface{}) any {
+	switch w.fieldStorage {
+	case FieldStorageHstore:
+		if len(fields) == 0 {
+			return nil
+		}
+		return formatHstore(fields)
+	case FieldStorageText:
+		fieldsJSON, _ := json.Marshal(fields)
+		return string(fieldsJSON)
+	default:
+		fieldsJSON, _ := json.Marshal(fields)
+		return fieldsJSON
+	}
+}
+
+// AddEntry 添加一条日志到缓冲区
+// 写入器关闭后调用会丢弃该条目，并通过 OnError 回调返回 ErrWriterClosed
+func (w *PostgresqlWriter) AddEntry(entry LogEntry) {
+	if w.disabled {
+		return
+	}
+	if !applyBeforeWrite(w.beforeWrite, &entry) {
+		return
+	}
+	if w.sampler != nil && !w.sampler.keep(entry) {
+		return
+	}
+	if !w.allowEntry(entry) {
+		return
+	}
+	if entry.LogType == "" {
+		entry.LogType = w.defaultLogType
+	}
+	level, err := w.checkLevel(entry.Level)
+	if err != nil {
+		w.handleError(err)
+		return
+	}
+	entry.Level = level
+
+	w.bufferMux.Lock()
+	if w.closed {
+		w.bufferMux.Unlock()
+		w.handleError(ErrWriterClosed)
+		return
+	}
+
+	// 回放等场景下已带 hash 的条目保持原样；Start 之前的条目在链尾恢复后再链接
+	if entry.Hash == "" && w.started && w.chainedLocked(entry) {
+		w.chainEntryLocked(&entry)
+	}
+	spilled, spillErr := w.spillLocked(entry)
+	if spillErr != nil {
+		// 写溢出文件失败时条目留在内存中，释放锁后再报告
+		defer w.handleError(spillErr)
+	}
+	if spilled {
+		w.bufferMux.Unlock()
+		return
+	}
+	w.buffer = append(w.buffer, entry)
+
+	// 错误级别日志立即同步写入（连同缓冲区中已有的日志）
+	inline := w.flushOnError && isErrorLevel(entry.Level)
+	if len(w.buffer) >= w.bufferSize && !inline {
+		if w.inlineFlushOnSaturation && w.activeWrites >= w.maxWrites {
+			// 写库协程已满，由调用方同步写入，形成背压
+			inline = true
+			w.inlineFlushes.Add(1)
+		} else {
+			w.flushLocked()
+		}
+	}
+	if !inline && w.idleKick != nil {
+		// 通知刷新协程重置空闲计时，已有未处理的通知时无需重复发送
+		select {
+		case w.idleKick <- struct{}{}:
+		default:
+		}
+	}
+	if !inline && w.errorFlushInterval > 0 && isErrorLevel(entry.Level) {
+		w.scheduleErrorFlushLocked()
+	}
+
+	if inline {
+		// wg 保证 Close 等待同步写入完成
+		w.wg.Add(1)
+		w.bufferMux.Unlock()
+		defer w.wg.Done()
+		if _, err := w.FlushSync(); err != nil {
+			w.handleError(err)
+		}
+		return
+	}
+	w.bufferMux.Unlock()
+
+	w.checkBacklog()
+}
+
+// BufferLen 返回待写入的日志条数（缓冲区中的条数加上正在写入的条数）
+func (w *PostgresqlWriter) BufferLen() int {
+	w.bufferMux.Lock()
+	defer w.bufferMux.Unlock()
+	return w.bufferLenLocked()
+}
+
+// bufferLenLocked 在已持有锁的情况下返回待写入的日志条数
+func (w *PostgresqlWriter) bufferLenLocked() int {
+	return len(w.buffer) + int(w.inflight.Load())
+}
+
+// checkBacklog 检查积压情况，持续超过高水位时通知一次 ErrBufferBackedUp
+func (w *PostgresqlWriter) checkBacklog() {
+	if w.highWater <= 0 {
+		return
+	}
+
+	w.bufferMux.Lock()
+	n := w.bufferLenLocked()
+	if n <= w.highWater {
+		w.highWaterSince = time.Time{}
+		w.highWaterWarned = false
+		w.bufferMux.Unlock()
+		return
+	}
+
+	now := time.Now()
+	if w.highWaterSince.IsZero() {
+		w.highWaterSince = now
+	}
+	since := now.Sub(w.highWaterSince)
+	fire := !w.highWaterWarned && since >= w.highWaterDuration
+	if fire {
+		w.highWaterWarned = true
+	}
+	w.bufferMux.Unlock()
+
+	if fire {
+		w.handleError(fmt.Errorf("%w: %d entries pending for %s (high water %d)", ErrBufferBackedUp, n, since.Round(time.Millisecond), w.highWater))
+	}
+}
+
+// allowEntry 根据限流配置判断是否接收该条目
+// 被限流后首次放行时，会先写入一条汇总日志说明丢弃的条数
+func (w *PostgresqlWriter) allowEntry(entry LogEntry) bool {
+	if w.limiter == nil {
+		return true
+	}
+	if w.limitExemptErrors && isErrorLevel(entry.Level) {
+		return true
+	}
+	if !w.limiter.allow(time.Now()) {
+		w.suppressed.Add(1)
+		w.suppressedTotal.Add(1)
+		return false
+	}
+
+	if n := w.suppressed.Swap(0); n > 0 {
+		w.addSuppressedSummary(n)
+	}
+	return true
+}
+
+// addSuppressedSummary 写入限流汇总日志（不经过限流）
+func (w *PostgresqlWriter) addSuppressedSummary(n int64) {
+	summary := LogEntry{
+		Timestamp: time.Now().Format(timestampLayout),
+		Level:     "warn",
+		Content:   fmt.Sprintf("%d logs suppressed by rate limiter", n),
+		LogType:   "system",
+		Fields:    map[string]interface{}{"suppressed": n},
+	}
+
+	w.bufferMux.Lock()
+	defer w.bufferMux.Unlock()
+	if w.closed {
+		return
+	}
+	w.buffer = append(w.buffer, summary)
+}
+
+// PostgresStats 写入器运行状态快照
+type PostgresStats struct {
+	Buffered      int   // 缓冲区中等待写入的条数
+	InFlight      int   // 已取出、正在写入的条数
+	ActiveWrites  int   // 正在运行的后台写库协程数
+	InlineFlushes int64 // 因写库协程已满而由调用方同步写入的累计次数
+	Suppressed    int64 // 累计被限流丢弃的条数
+
+	// FlushWaitTotal 开启 BlockOnSaturation 时，刷新因写库协程已满而阻塞等待的累计时长；持续增长说明数据库是瓶颈
+	FlushWaitTotal time.Duration
+
+	Retries int64 // 因临时错误重试写入的累计次数（MaxRetries）
+	Spilled int64 // 累计写入溢出文件的条数（SpillFile）
+
+	Written        int64         // 累计写入数据库的条数
+	Failed         int64         // 累计写入失败的条数（含被 ValidateBatch 拒绝的批次）
+	Flushes        int64         // 累计写库批次数
+	FlushTimeTotal time.Duration // 写库批次的累计耗时，与 Flushes 相除得到平均写入延迟
+
+	// Levels 各级别通过 Log 记录的累计条数（按升级规则处理后的级别统计，包含随后被采样或限流丢弃的日志）
+	Levels map[string]int64
+}
+
+// Stats 返回写入器运行状态快照
+func (w *PostgresqlWriter) Stats() PostgresStats {
+	levels := make(map[string]int64)
+	w.levelCounts.Range(func(key, value any) bool {
+		levels[key.(string)] = value.(*atomic.Int64).Load()
+		return true
+	})
+
+	w.bufferMux.Lock()
+	defer w.bufferMux.Unlock()
+	return PostgresStats{
+		Buffered:       len(w.buffer),
+		InFlight:       int(w.inflight.Load()),
+		ActiveWrites:   w.activeWrites,
+		InlineFlushes:  w.inlineFlushes.Load(),
+		Suppressed:     w.suppressedTotal.Load(),
+		FlushWaitTotal: time.Duration(w.flushWait.Load()),
+		Retries:        w.retries.Load(),
+		Spilled:        w.spilled.Load(),
+		Written:        w.written.Load(),
+		Failed:         w.failed.Load(),
+		Flushes:        w.flushes.Load(),
+		FlushTimeTotal: time.Duration(w.flushTime.Load()),
+		Levels:         levels,
 	}
+}
 
-	if config == nil {
-		config = DefaultPostgresConfig()
+// SuppressedCount 返回累计被限流丢弃的日志条数
+func (w *PostgresqlWriter) SuppressedCount() int64 {
+	return w.suppressedTotal.Load()
+}
+
+// handleError 将错误交给 OnError 回调
+func (w *PostgresqlWriter) handleError(err error) {
+	if w.onError != nil {
+		w.onError(err)
 	}
+}
 
-	// 测试连接
-	if err := db.Ping(context.Background()); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+// Log 写入日志（核心方法）
+func (w *PostgresqlWriter) Log(level string, content any, fields ...LogField) {
+	// 级别过滤最先进行：不加锁、不格式化
+	if !w.Enabled(level) {
+		return
 	}
+	cause, _ := content.(error)
+	w.log(level, w.content.format(content), cause, fields)
+}
 
-	w := &PostgresqlWriter{
-		db:            db,
-		tableName:     config.TableName,
-		bufferSize:    config.BufferSize,
-		flushInterval: config.FlushInterval,
-		buffer:        make([]LogEntry, 0, config.BufferSize),
-		done:          make(chan struct{}),
+// log 构造条目并放入缓冲区，调用方已完成级别过滤；cause 为内容本身是 error 时的原始错误
+func (w *PostgresqlWriter) log(level, text string, cause error, fields []LogField) {
+	entry := w.buildEntry(level, text, cause, fields)
+	if w.trimTrailingSpace {
+		entry.Content = trimTrailingSpace(entry.Content)
+	}
+	if !applyEmptyContent(&entry.Content, w.emptyContent, w.emptyPlaceholder) {
+		return
 	}
+	w.countLevel(entry.Level)
+	w.AddEntry(entry)
+}
 
-	// 确保表存在
-	if err := w.ensureTable(context.Background()); err != nil {
-		return nil, fmt.Errorf("failed to ensure table: %w", err)
+// LogMsg 写入只有消息、没有字段的日志；内容为 string 时不经过 any 装箱，级别被过滤时没有任何内存分配
+func (w *PostgresqlWriter) LogMsg(level, msg string) {
+	if !w.Enabled(level) {
+		return
 	}
+	w.log(level, msg, nil, nil)
+}
 
-	// 启动后台刷新协程
-	w.wg.Add(1)
-	go w.flushLoop()
+// countLevel 按级别累加 Log 调用次数
+func (w *PostgresqlWriter) countLevel(level string) {
+	counter, ok := w.levelCounts.Load(level)
+	if !ok {
+		counter, _ = w.levelCounts.LoadOrStore(level, new(atomic.Int64))
+	}
+	counter.(*atomic.Int64).Add(1)
+}
 
-	return w, nil
+// Enabled 判断该级别的日志是否会被记录（未禁用且不低于 MinLevel），不加锁、不分配内存
+// 构造开销较大的日志前可先调用此方法判断
+func (w *PostgresqlWriter) Enabled(level string) bool {
+	return !w.disabled && levelRank(level) >= w.minLevel
 }
 
-// ensureTable 确保日志表存在并执行必要的迁移
-func (w *PostgresqlWriter) ensureTable(ctx context.Context) error {
-	// 创建表（如果不存在）
-	query := fmt.Sprintf(`
-		CREATE TABLE IF NOT EXISTS %s (
-			id BIGSERIAL PRIMARY KEY,
-			timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-			level VARCHAR(20) NOT NULL,
-			content TEXT,
-			log_type VARCHAR(20),
-			duration VARCHAR(50),
-			trace VARCHAR(100),
-			span VARCHAR(100),
-			user_id BIGINT,
-			username VARCHAR(100),
-			fields JSONB
-		)
-	`, w.tableName)
+// LogTx 使用调用方的事务同步写入一条日志，随事务一起提交或回滚
+// 该方法绕过缓冲区、批量写入和限流，直接在 tx 上执行 INSERT；配置了 NotifyChannel 时通知同样在 tx 上发送（提交后送达）
+func (w *PostgresqlWriter) LogTx(ctx context.Context, tx DBExecutor, level string, content any, fields ...LogField) error {
+	w.bufferMux.Lock()
+	started := w.started
+	w.bufferMux.Unlock()
+	if !started && !w.disabled {
+		return ErrWriterNotStarted
+	}
+	return w.writeDirect(ctx, tx, level, content, fields)
+}
 
-	if err := w.db.Exec(ctx, query); err != nil {
-		return err
+// LogSync 绕过缓冲区立即写入一条日志并返回写入结果，其他日志仍按原有策略缓冲和批量写入
+// 适用于高风险操作前必须先落库的日志，比 FlushOnError 粒度更细；写入器关闭后返回 ErrWriterClosed
+func (w *PostgresqlWriter) LogSync(level string, content any, fields ...LogField) error {
+	w.bufferMux.Lock()
+	if w.closed {
+		w.bufferMux.Unlock()
+		return ErrWriterClosed
 	}
+	if !w.started && !w.disabled {
+		w.bufferMux.Unlock()
+		return ErrWriterNotStarted
+	}
+	// 计入 wg，保证 Close 在本次写入完成后才关闭数据库连接
+	w.wg.Add(1)
+	w.bufferMux.Unlock()
+	defer w.wg.Done()
 
-	// 迁移：添加可能缺失的列（用于已存在的表）
-	migrations := []string{
-		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS log_type VARCHAR(20)`, w.tableName),
-		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS duration VARCHAR(50)`, w.tableName),
-		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS trace VARCHAR(100)`, w.tableName),
-		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS span VARCHAR(100)`, w.tableName),
-		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS user_id BIGINT`, w.tableName),
-		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS username VARCHAR(100)`, w.tableName),
-		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS fields JSONB`, w.tableName),
+	ctx, cancel := context.WithTimeout(context.Background(), w.writeTimeout)
+	defer cancel()
+	return w.writeDirect(ctx, w.db, level, content, fields)
+}
+
+// writeDirect 不经过缓冲区，在 db 上同步写入一条日志（LogTx、LogSync 共用）
+func (w *PostgresqlWriter) writeDirect(ctx context.Context, db DBExecutor, level string, content any, fields []LogField) error {
+	if w.disabled {
+		return nil
+	}
+	cause, _ := content.(error)
+	entry := w.buildEntry(level, w.content.format(content), cause, fields)
+	if w.trimTrailingSpace {
+		entry.Content = trimTrailingSpace(entry.Content)
+	}
+	if !applyEmptyContent(&entry.Content, w.emptyContent, w.emptyPlaceholder) {
+		return nil
+	}
+	if !applyBeforeWrite(w.beforeWrite, &entry) {
+		return nil
 	}
+	level, err := w.checkLevel(entry.Level)
+	if err != nil {
+		return err
+	}
+	entry.Level = level
+	if err := w.insertEntry(ctx, db, entry); err != nil {
+		return fmt.Errorf("failed to write log entry: %w", err)
+	}
+	if err := w.notify(ctx, db, entry); err != nil {
+		return fmt.Errorf("failed to notify log entry: %w", err)
+	}
+	return nil
+}
 
-	for _, migration := range migrations {
-		if err := w.db.Exec(ctx, migration); err != nil {
-			// 忽略迁移错误，继续执行（某些数据库可能不支持 IF NOT EXISTS）
-			continue
+// buildEntry 根据配置构造日志条目（字段规范化、ID、log_type 校验等），text 为已格式化的内容，cause 非空时用于 CaptureErrorChain
+func (w *PostgresqlWriter) buildEntry(level, text string, cause error, fields []LogField) LogEntry {
+	if w.fieldKeyFunc != nil && len(fields) > 0 {
+		// 规范化后的字段只在构造条目期间使用，切片来自池
+		buf := getFieldSlice()
+		defer putFieldSlice(buf)
+		*buf = normalizeFieldKeys(*buf, fields, w.fieldKeyFunc)
+		fields = *buf
+	}
+	var entry LogEntry
+	switch w.specialKeys {
+	case SpecialKeysOff:
+		entry = newPlainLogEntry(level, text, fields)
+	case SpecialKeysWarn:
+		w.checkSpecialKeys(fields)
+		entry = newTextLogEntry(level, text, fields)
+	default:
+		entry = newTextLogEntry(level, text, fields)
+	}
+	entry.Level = escalateLevel(w.escalationRules, entry.Level, entry.Content, fields)
+	entry.EntryID = w.newEntryID()
+	if entry.LogType == "" {
+		entry.LogType = w.defaultLogType
+	}
+	entry.LogType = w.checkLogType(entry.LogType)
+	if w.parseContentFields {
+		w.applyContentFields(&entry)
+	}
+	if cause == nil && w.captureErrorChain {
+		cause = fieldError(fields)
+	}
+	if cause != nil && w.captureErrorChain {
+		if entry.Fields == nil {
+			entry.Fields = make(map[string]interface{}, 1)
 		}
+		entry.Fields["error_chain"] = errorChain(cause)
 	}
-
-	// 创建索引
-	indexes := []string{
-		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_timestamp ON %s(timestamp)`, w.tableName, w.tableName),
-		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_level ON %s(level)`, w.tableName, w.tableName),
-		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_trace ON %s(trace)`, w.tableName, w.tableName),
-		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_user_id ON %s(user_id)`, w.tableName, w.tableName),
-		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_log_type ON %s(log_type)`, w.tableName, w.tableName),
-		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_username ON %s(username)`, w.tableName, w.tableName),
+	if w.flattenSeparator != "" {
+		entry.Fields = flattenFields(entry.Fields, w.flattenSeparator, w.flattenMaxDepth)
 	}
+	if w.maxFieldBytes > 0 || w.maxFieldsBytes > 0 {
+		entry.Fields = capFields(entry.Fields, w.maxFieldBytes, w.maxFieldsBytes, w.fieldOverflow)
+	}
+	if w.includeGoroutineID {
+		if entry.Fields == nil {
+			entry.Fields = make(map[string]interface{}, 1)
+		}
+		if _, ok := entry.Fields["goroutine"]; !ok {
+			entry.Fields["goroutine"] = goroutineID()
+		}
+	}
+	if w.includeCaller {
+		if entry.Fields == nil {
+			entry.Fields = make(map[string]interface{}, 1)
+		}
+		if _, ok := entry.Fields["caller"]; !ok {
+			entry.Fields["caller"] = externalCaller(w.callerFunction)
+		}
+	}
+	return entry
+}
 
-	for _, idx := range indexes {
-		if err := w.db.Exec(ctx, idx); err != nil {
-			return err
+// applyContentFields 将内容中的 key=value 片段合并到 fields，显式传入的字段和特殊字段优先
+func (w *PostgresqlWriter) applyContentFields(entry *LogEntry) {
+	parsed := parseContentFields(entry.Content)
+	for key, value := range parsed {
+		if w.fieldKeyFunc != nil {
+			key = w.fieldKeyFunc(key)
+		}
+		if isSpecialKey(key) {
+			continue
 		}
+		if _, exists := entry.Fields[key]; exists {
+			continue
+		}
+		if entry.Fields == nil {
+			entry.Fields = make(map[string]interface{}, len(parsed))
+		}
+		entry.Fields[key] = value
 	}
+}
 
-	return nil
+// checkSpecialKeys 检查特殊字段的值类型，类型不符时每个键只报告一次
+func (w *PostgresqlWriter) checkSpecialKeys(fields []LogField) {
+	for _, field := range fields {
+		if !isSpecialKey(field.Key) || specialValueOK(field.Key, field.Value) {
+			continue
+		}
+		if _, warned := w.specialKeyWarned.LoadOrStore(field.Key, struct{}{}); warned {
+			continue
+		}
+		w.handleError(fmt.Errorf("%w: field %q is reserved for a column but got %T", ErrSpecialKeyType, field.Key, field.Value))
+	}
 }
 
-// AddEntry 添加一条日志到缓冲区
-func (w *PostgresqlWriter) AddEntry(entry LogEntry) {
-	w.bufferMux.Lock()
-	defer w.bufferMux.Unlock()
+// newEntryID 生成日志条目 ID，未配置 IDGenerator 时返回空字符串
+func (w *PostgresqlWriter) newEntryID() string {
+	if w.idGenerator == nil {
+		return ""
+	}
+	return w.idGenerator()
+}
 
-	w.buffer = append(w.buffer, entry)
+// checkLogType 按白名单校验 log_type，未知值替换为 UnknownLogType
+func (w *PostgresqlWriter) checkLogType(logType string) string {
+	if w.allowedLogTypes == nil || logType == "" {
+		return logType
+	}
+	if _, ok := w.allowedLogTypes[logType]; ok {
+		return logType
+	}
 
-	if len(w.buffer) >= w.bufferSize {
-		w.flushLocked()
+	if w.warnUnknownLogType {
+		w.AddEntry(LogEntry{
+			Timestamp: time.Now().Format(timestampLayout),
+			Level:     "warn",
+			Content:   fmt.Sprintf("unknown log_type %q replaced with %q", logType, w.unknownLogType),
+			LogType:   w.unknownLogType,
+			Fields:    map[string]interface{}{"log_type": logType},
+		})
 	}
+	return w.unknownLogType
 }
 
-// Log 写入日志（核心方法）
-func (w *PostgresqlWriter) Log(level string, content any, fields ...LogField) {
-	trace, span, duration, logType, userID, username := extractFields(fields)
-	entry := LogEntry{
-		Timestamp: time.Now().Format(time.RFC3339),
-		Level:     level,
-		Content:   FormatContent(content),
-		LogType:   logType,
-		Duration:  duration,
-		Trace:     trace,
-		Span:      span,
-		UserID:    userID,
-		Username:  username,
-		Fields:    convertLogFields(fields),
-	}
-	w.AddEntry(entry)
+// Named 返回带组件名的派生 Writer，与当前 Writer 共享缓冲区
+func (w *PostgresqlWriter) Named(component string) Writer {
+	return newDerivedWriter(w, component)
+}
+
+// With 返回附加默认字段的派生 Writer，每条日志都会带上这些字段（调用时传入的同名字段优先）
+func (w *PostgresqlWriter) With(fields ...LogField) Writer {
+	return newFieldsWriter(w, fields)
 }
 
 // Info 写入 info 级别日志
@@ -169,34 +1356,62 @@ func (w *PostgresqlWriter) Warn(content any, fields ...LogField) {
 	w.Log("warn", content, fields...)
 }
 
+// InfoMsg 写入只有消息的 info 级别日志
+func (w *PostgresqlWriter) InfoMsg(msg string) {
+	w.LogMsg("info", msg)
+}
+
+// ErrorMsg 写入只有消息的 error 级别日志
+func (w *PostgresqlWriter) ErrorMsg(msg string) {
+	w.LogMsg("error", msg)
+}
+
+// DebugMsg 写入只有消息的 debug 级别日志
+func (w *PostgresqlWriter) DebugMsg(msg string) {
+	w.LogMsg("debug", msg)
+}
+
+// WarnMsg 写入只有消息的 warn 级别日志
+func (w *PostgresqlWriter) WarnMsg(msg string) {
+	w.LogMsg("warn", msg)
+}
+
 // Infof 写入 info 级别格式化日志
 func (w *PostgresqlWriter) Infof(format string, args ...any) {
-	w.Log("info", fmt.Sprintf(format, args...))
+	w.Logf("info", format, args...)
 }
 
 // Errorf 写入 error 级别格式化日志
 func (w *PostgresqlWriter) Errorf(format string, args ...any) {
-	w.Log("error", fmt.Sprintf(format, args...))
+	w.Logf("error", format, args...)
 }
 
 // Debugf 写入 debug 级别格式化日志
 func (w *PostgresqlWriter) Debugf(format string, args ...any) {
-	w.Log("debug", fmt.Sprintf(format, args...))
+	w.Logf("debug", format, args...)
 }
 
 // Warnf 写入 warn 级别格式化日志
 func (w *PostgresqlWriter) Warnf(format string, args ...any) {
-	w.Log("warn", fmt.Sprintf(format, args...))
+	w.Logf("warn", format, args...)
 }
 
 // Logf 写入格式化日志
 func (w *PostgresqlWriter) Logf(level string, format string, args ...any) {
+	// 级别被过滤时不格式化，避免无谓的分配
+	if !w.Enabled(level) {
+		return
+	}
 	w.Log(level, fmt.Sprintf(format, args...))
 }
 
 // flushLoop 后台定时刷新协程
 func (w *PostgresqlWriter) flushLoop() {
 	defer w.wg.Done()
+	if w.idleFlushInterval > 0 {
+		w.idleFlushLoop()
+		return
+	}
 	ticker := time.NewTicker(w.flushInterval)
 	defer ticker.Stop()
 
@@ -204,6 +1419,7 @@ func (w *PostgresqlWriter) flushLoop() {
 		select {
 		case <-ticker.C:
 			w.Flush()
+			w.checkBacklog()
 		case <-w.done:
 			w.Flush()
 			return
@@ -211,63 +1427,539 @@ func (w *PostgresqlWriter) flushLoop() {
 	}
 }
 
+// flushTimer 刷新协程使用的定时器
+type flushTimer interface {
+	C() <-chan time.Time
+	Reset(d time.Duration)
+	Stop()
+}
+
+// realTimer 基于 time.Timer 的 flushTimer
+type realTimer struct {
+	timer *time.Timer
+}
+
+func newRealTimer(d time.Duration) flushTimer {
+	return realTimer{timer: time.NewTimer(d)}
+}
+
+func (t realTimer) C() <-chan time.Time   { return t.timer.C }
+func (t realTimer) Reset(d time.Duration) { t.timer.Reset(d) }
+func (t realTimer) Stop()                 { t.timer.Stop() }
+
+// idleFlushLoop 空闲刷新模式：缓冲区在 idleFlushInterval 内没有新日志时刷新，
+// 但从第一条日志进入缓冲区起最迟 flushInterval 后刷新；缓冲区为空时不唤醒
+func (w *PostgresqlWriter) idleFlushLoop() {
+	idle := w.newTimer(w.idleFlushInterval)
+	idle.Stop()
+	defer idle.Stop()
+	var ceiling flushTimer
+	var ceilingC <-chan time.Time
+
+	flush := func() {
+		idle.Stop()
+		if ceiling != nil {
+			ceiling.Stop()
+			ceiling, ceilingC = nil, nil
+		}
+		w.Flush()
+		w.checkBacklog()
+	}
+
+	for {
+		select {
+		case <-w.idleKick:
+			idle.Reset(w.idleFlushInterval)
+			if ceiling == nil {
+				ceiling = w.newTimer(w.flushInterval)
+				ceilingC = ceiling.C()
+			}
+		case <-idle.C():
+			flush()
+		case <-ceilingC:
+			flush()
+		case <-w.done:
+			flush()
+			return
+		}
+	}
+}
+
 // Flush 刷新缓冲区到数据库
 func (w *PostgresqlWriter) Flush() {
+	if err := w.drainSpill(); err != nil {
+		w.handleError(err)
+	}
 	w.bufferMux.Lock()
 	defer w.bufferMux.Unlock()
 	w.flushLocked()
 }
 
+// FlushSync 同步刷新缓冲区，返回实际写入数据库的条数
+// 部分写入失败时返回成功的条数和错误；调用前已在异步写入中的日志不计入
+func (w *PostgresqlWriter) FlushSync() (int, error) {
+	w.bufferMux.Lock()
+	if !w.started {
+		w.bufferMux.Unlock()
+		return 0, nil
+	}
+	if w.strictOrder {
+		return w.flushSyncOrdered()
+	}
+	entries := w.takeBufferLocked()
+	w.bufferMux.Unlock()
+
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	w.inflight.Add(int64(len(entries)))
+	defer w.inflight.Add(-int64(len(entries)))
+	defer w.releaseBatch(entries)
+	return w.writeEntries(entries)
+}
+
+// flushSyncOrdered StrictOrder 下的 FlushSync：调用时已持有 bufferMux，返回前释放
+// 先等待正在写的批次完成并占用唯一的写入名额，保证本批在之前取出的批次之后写入
+func (w *PostgresqlWriter) flushSyncOrdered() (int, error) {
+	start := time.Now()
+	for w.activeWrites >= w.maxWrites {
+		w.writeSlotFree.Wait()
+	}
+	w.flushWait.Add(int64(time.Since(start)))
+	entries := w.takeBufferLocked()
+	if len(entries) == 0 {
+		w.bufferMux.Unlock()
+		return 0, nil
+	}
+	w.activeWrites++
+	w.inflight.Add(int64(len(entries)))
+	w.bufferMux.Unlock()
+
+	n, err := w.writeEntries(entries)
+	w.inflight.Add(-int64(len(entries)))
+	w.releaseBatch(entries)
+
+	// 归还名额；写入期间被推迟的刷新由新的写库协程接续
+	w.bufferMux.Lock()
+	w.activeWrites--
+	w.writeSlotFree.Broadcast()
+	if w.pendingFlush {
+		w.pendingFlush = false
+		w.flushLocked()
+	}
+	w.bufferMux.Unlock()
+	return n, err
+}
+
+// takeBufferLocked 在已持有锁的情况下取出缓冲区中的全部条目
+// 直接交出当前缓冲区并换上一个复用的空切片，避免每次刷新都分配和拷贝；写完后应调用 releaseBatch 归还
+func (w *PostgresqlWriter) takeBufferLocked() []LogEntry {
+	if len(w.buffer) == 0 {
+		return nil
+	}
+
+	entries := w.buffer
+	if v, ok := w.batchPool.Get().(*[]LogEntry); ok {
+		w.buffer = (*v)[:0]
+	} else {
+		w.buffer = make([]LogEntry, 0, w.bufferSize)
+	}
+	return entries
+}
+
+// releaseBatch 归还已写完的批次切片以便复用，过大的切片（积压时产生）直接丢弃
+func (w *PostgresqlWriter) releaseBatch(entries []LogEntry) {
+	if entries == nil || cap(entries) > 4*w.bufferSize {
+		return
+	}
+	// 清除条目引用，避免复用期间持有已写入日志的字段
+	clear(entries)
+	entries = entries[:0]
+	w.batchPool.Put(&entries)
+}
+
 // flushLocked 在已持有锁的情况下刷新缓冲区
+// 写库协程数达到 maxWrites 时不再新建协程，日志留在缓冲区，由先完成的协程接续写出；
+// 开启 BlockOnSaturation 时改为等待（期间释放锁）有协程写完后再由当前调用方发起写入
 func (w *PostgresqlWriter) flushLocked() {
-	if len(w.buffer) == 0 {
+	// Start 之前表可能尚不存在，日志留在缓冲区
+	if len(w.buffer) == 0 || !w.started {
+		return
+	}
+	if w.activeWrites >= w.maxWrites {
+		if !w.blockOnSaturation {
+			w.pendingFlush = true
+			return
+		}
+		start := time.Now()
+		for w.activeWrites >= w.maxWrites {
+			w.writeSlotFree.Wait()
+		}
+		w.flushWait.Add(int64(time.Since(start)))
+		// 等待期间缓冲区可能已被其他调用方取走
+		if len(w.buffer) == 0 {
+			return
+		}
+	}
+	entries := w.takeBufferLocked()
+
+	// 异步写入数据库，Close 时等待完成
+	w.activeWrites++
+	w.inflight.Add(int64(len(entries)))
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for len(entries) > 0 {
+			if _, err := w.writeEntries(entries); err != nil {
+				w.handleError(err)
+			}
+			w.inflight.Add(-int64(len(entries)))
+			w.releaseBatch(entries)
+			if err := w.drainSpill(); err != nil {
+				w.handleError(err)
+			}
+			entries = w.nextPendingBatch()
+		}
+	}()
+}
+
+// scheduleErrorFlushLocked 在 errorFlushInterval 后刷新缓冲区，已安排时不重复安排
+func (w *PostgresqlWriter) scheduleErrorFlushLocked() {
+	if w.errorFlushTimer != nil {
 		return
 	}
+	w.errorFlushTimer = time.AfterFunc(w.errorFlushInterval, func() {
+		w.bufferMux.Lock()
+		defer w.bufferMux.Unlock()
+		w.errorFlushTimer = nil
+		// 关闭时由 flushLoop 完成最后一次刷新
+		if !w.closed {
+			w.flushLocked()
+		}
+	})
+}
 
-	entries := make([]LogEntry, len(w.buffer))
-	copy(entries, w.buffer)
-	w.buffer = w.buffer[:0]
+// nextPendingBatch 写库协程完成一批后调用：有被推迟的刷新时取出缓冲区继续写，否则释放写入名额
+func (w *PostgresqlWriter) nextPendingBatch() []LogEntry {
+	w.bufferMux.Lock()
+	defer w.bufferMux.Unlock()
+	if w.pendingFlush && len(w.buffer) > 0 {
+		w.pendingFlush = false
+		entries := w.takeBufferLocked()
+		w.inflight.Add(int64(len(entries)))
+		return entries
+	}
+	w.pendingFlush = false
+	w.activeWrites--
+	w.writeSlotFree.Broadcast()
+	return nil
+}
 
-	// 异步写入数据库
-	go w.writeEntries(entries)
+// writeEntries 批量写入日志条目，返回成功写入的条数
+func (w *PostgresqlWriter) writeEntries(entries []LogEntry) (int, error) {
+	return w.writeEntriesContext(context.Background(), entries)
 }
 
-// writeEntries 批量写入日志条目
-func (w *PostgresqlWriter) writeEntries(entries []LogEntry) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// writeEntriesContext 同 writeEntries，parent 取消时停止写入（单批仍受 WriteTimeout 限制）
+func (w *PostgresqlWriter) writeEntriesContext(parent context.Context, entries []LogEntry) (int, error) {
+	ctx, cancel := context.WithTimeout(parent, w.writeTimeout)
 	defer cancel()
 
-	for _, entry := range entries {
-		fieldsJSON, _ := json.Marshal(entry.Fields)
-		query := fmt.Sprintf(`
-			INSERT INTO %s (timestamp, level, content, log_type, duration, trace, span, user_id, username, fields)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		`, w.tableName)
+	if w.validateBatch != nil {
+		if err := w.validateBatch(entries); err != nil {
+			// 整批不写入，转交 Fallback，避免无效数据入库
+			if w.fallback != nil {
+				for _, entry := range entries {
+					w.fallback.AddEntry(entry)
+				}
+			}
+			w.failed.Add(int64(len(entries)))
+			return 0, fmt.Errorf("%w (%d entries): %w", ErrBatchRejected, len(entries), err)
+		}
+	}
+
+	if w.multiTable() {
+		w.groupByTable(entries)
+	}
+
+	start := time.Now()
+	var written, failed int
+	var errs []error
+	if w.multiRowInsert {
+		written, failed, errs = w.writeMultiRow(ctx, entries)
+	} else {
+		written, failed, errs = w.writeRows(ctx, entries)
+	}
+
+	elapsed := time.Since(start)
+	w.flushes.Add(1)
+	w.flushTime.Add(int64(elapsed))
+	w.written.Add(int64(written))
+	w.failed.Add(int64(len(entries) - written))
+	if w.onFlush != nil && written > 0 {
+		w.onFlush(written, elapsed)
+	}
+
+	if len(errs) > 0 {
+		return written, fmt.Errorf("failed to write %d of %d log entries: %w", failed, len(entries), errors.Join(errs...))
+	}
+	return written, nil
+}
+
+// writeRows 逐条写入日志条目，失败的条目转交 Fallback，返回成功、失败的条数及错误
+func (w *PostgresqlWriter) writeRows(ctx context.Context, entries []LogEntry) (written, failed int, errs []error) {
+	for i, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			// 已超时：剩余条目不再逐条执行注定失败的 Exec，整体转交 Fallback
+			failed += len(entries) - i
+			errs = append(errs, w.abandonEntries(entries[i:], err))
+			break
+		}
+		if err := w.insertWithRetry(ctx, entry); err != nil {
+			failed++
+			errs = append(errs, err)
+			if w.fallback != nil {
+				w.fallback.AddEntry(entry)
+			}
+			continue
+		}
+		written++
+
+		if err := w.notify(ctx, w.db, entry); err != nil {
+			errs = append(errs, fmt.Errorf("failed to notify: %w", err))
+		}
+	}
+	return written, failed, errs
+}
+
+// abandonEntries 写库超时后将尚未写入的条目整体转交 Fallback，返回包含 ErrWriteDeadline 的错误
+func (w *PostgresqlWriter) abandonEntries(rest []LogEntry, cause error) error {
+	if w.fallback != nil {
+		for _, entry := range rest {
+			w.fallback.AddEntry(entry)
+		}
+	}
+	return fmt.Errorf("%w: %d entries not written: %w", ErrWriteDeadline, len(rest), cause)
+}
+
+// notify 对 error/severe 级别的日志发送 pg_notify 通知
+func (w *PostgresqlWriter) notify(ctx context.Context, db DBExecutor, entry LogEntry) error {
+	if w.notifyChannel == "" || (entry.Level != "error" && entry.Level != "severe") {
+		return nil
+	}
+	query := fmt.Sprintf(`SELECT pg_notify(%s, %s)`, w.placeholder(1), w.placeholder(2))
+	return db.Exec(ctx, query, w.notifyChannel, notifyPayload(entry))
+}
+
+// maxNotifyPayload NOTIFY 负载的字节上限（PostgreSQL 限制为 8000 字节以内）
+const maxNotifyPayload = 7999
+
+// notifyPayload 生成 NOTIFY 的 JSON 负载，超出上限时截断 content
+func notifyPayload(entry LogEntry) string {
+	payload := struct {
+		Level   string `json:"level"`
+		Content string `json:"content"`
+		Trace   string `json:"trace,omitempty"`
+	}{entry.Level, entry.Content, entry.Trace}
+
+	data, _ := json.Marshal(payload)
+	for len(data) > maxNotifyPayload && payload.Content != "" {
+		// 按超出的字节数截断（JSON 转义可能放大长度，循环直到满足上限）
+		excess := len(data) - maxNotifyPayload
+		cut := len(payload.Content) - excess
+		if cut < 0 {
+			cut = 0
+		}
+		payload.Content = truncateUTF8(payload.Content, cut)
+		data, _ = json.Marshal(payload)
+	}
+	return string(data)
+}
+
+// placeholder 返回第 n 个参数的占位符
+func (w *PostgresqlWriter) placeholder(n int) string {
+	if w.placeholderStyle == PlaceholderQuestion {
+		return "?"
+	}
+	return fmt.Sprintf("$%d", n)
+}
+
+// insertQuery 生成写入单条日志的 INSERT 语句，列顺序与 insertArgs 一致
+func (w *PostgresqlWriter) insertQuery(table string) string {
+	placeholders := make([]string, len(w.insertColumns))
+	for i := range w.insertColumns {
+		placeholders[i] = w.placeholder(i + 1)
+	}
+	return fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)`,
+		table, strings.Join(w.insertColumns, ", "), strings.Join(placeholders, ", "))
+}
+
+// insertEntry 使用指定的执行器写入单条日志
+func (w *PostgresqlWriter) insertEntry(ctx context.Context, db DBExecutor, entry LogEntry) error {
+	table := w.tableFor(entry)
+	if err := w.ensureShardTable(ctx, table); err != nil {
+		return err
+	}
+	return db.Exec(ctx, w.insertQuery(table), w.insertArgs(entry)...)
+}
+
+// insertArgs 返回单条日志的 INSERT 参数，顺序与 insertColumns 一致
+func (w *PostgresqlWriter) insertArgs(entry LogEntry) []any {
+	entry = w.storedEntry(entry)
+	ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+	if err != nil {
+		ts = time.Now()
+	}
 
-		ts, err := time.Parse(time.RFC3339, entry.Timestamp)
-		if err != nil {
-			ts = time.Now()
+	args := make([]any, 0, len(w.insertColumns))
+	for _, column := range w.insertColumns {
+		switch column {
+		case "id":
+			args = append(args, NewUUIDv7())
+		case "timestamp":
+			args = append(args, w.dbTime(ts))
+		case "level":
+			args = append(args, entry.Level)
+		case "content":
+			args = append(args, entry.Content)
+		case "log_type":
+			args = append(args, entry.LogType)
+		case "duration":
+			args = append(args, w.durationArg(entry.Duration))
+		case "trace":
+			args = append(args, entry.Trace)
+		case "span":
+			args = append(args, entry.Span)
+		case "user_id":
+			args = append(args, entry.UserID)
+		case "username":
+			args = append(args, entry.Username)
+		case "fields":
+			args = append(args, w.encodeFields(w.columnFields(entry.Fields)))
+		case "entry_id":
+			args = append(args, nullIfEmpty(entry.EntryID))
+		case "expires_at":
+			args = append(args, parseTimeOrNil(entry.ExpiresAt))
+		case "component":
+			args = append(args, nullIfEmpty(entry.Component))
+		case "prev_hash":
+			args = append(args, nullIfEmpty(entry.PrevHash))
+		case "hash":
+			args = append(args, nullIfEmpty(entry.Hash))
+		case ErrorKey:
+			msg, _ := entry.Fields[ErrorKey].(string)
+			args = append(args, nullIfEmpty(msg))
 		}
+	}
+	return args
+}
+
+// columnFields 返回写入 fields 列的字段：开启 ErrorColumn 时去掉已写入 error 列的错误消息（非字符串值仍保留在 fields 中）
+func (w *PostgresqlWriter) columnFields(fields map[string]interface{}) map[string]interface{} {
+	if !w.errorColumn {
+		return fields
+	}
+	if _, ok := fields[ErrorKey].(string); !ok {
+		return fields
+	}
+	rest := maps.Clone(fields)
+	delete(rest, ErrorKey)
+	return rest
+}
 
-		_ = w.db.Exec(ctx, query,
-			ts,
-			entry.Level,
-			entry.Content,
-			entry.LogType,
-			entry.Duration,
-			entry.Trace,
-			entry.Span,
-			entry.UserID,
-			entry.Username,
-			fieldsJSON,
-		)
+// storedEntry 返回条目实际入库的形式：清理非法字符、清空已关闭列对应的属性、按 ContentMaxLength 截断内容、按 DurationStorage 规范化 duration
+// INSERT 和审计哈希都基于此形式，保证哈希与从数据库读回的内容一致
+func (w *PostgresqlWriter) storedEntry(entry LogEntry) LogEntry {
+	if w.sanitizeStrings {
+		entry = sanitizeEntry(entry)
 	}
+	if w.contentMaxLength > 0 {
+		entry.Content = truncateRunes(entry.Content, w.contentMaxLength)
+	}
+	if w.durationStorage != DurationStorageText && entry.Duration != "" {
+		// 规范化为读回时的形式（精确到微秒），无法解析的原值移入 fields
+		if d, ok := parseDurationText(entry.Duration); ok {
+			entry.Duration = d.Truncate(time.Microsecond).String()
+		} else {
+			fields := make(map[string]interface{}, len(entry.Fields)+1)
+			maps.Copy(fields, entry.Fields)
+			fields["duration"] = entry.Duration
+			entry.Fields = fields
+			entry.Duration = ""
+		}
+	}
+	for column := range w.disabledColumns {
+		switch column {
+		case "log_type":
+			entry.LogType = ""
+		case "duration":
+			entry.Duration = ""
+		case "trace":
+			entry.Trace = ""
+		case "span":
+			entry.Span = ""
+		case "user_id":
+			entry.UserID = nil
+		case "username":
+			entry.Username = ""
+		case "fields":
+			entry.Fields = nil
+		case "entry_id":
+			entry.EntryID = ""
+		case "expires_at":
+			entry.ExpiresAt = ""
+		case "component":
+			entry.Component = ""
+		}
+	}
+	return entry
 }
 
 // Close 关闭写入器
+// 未调用 Start 就关闭时，缓冲区中的日志交给 Fallback（未配置时通过 OnError 回调报告丢弃的条数）
+// Close 开始后写入的日志会被拒绝（通过 OnError 回调返回 ErrWriterClosed），
+// 此前已进入缓冲区的日志会在返回前全部写入数据库。重复调用返回 ErrWriterClosed。
 func (w *PostgresqlWriter) Close() error {
+	// 等待进行中的 Start 结束，避免后台协程在关闭后才启动
+	w.startMux.Lock()
+	defer w.startMux.Unlock()
+
+	w.bufferMux.Lock()
+	if w.closed {
+		w.bufferMux.Unlock()
+		return ErrWriterClosed
+	}
+	w.closed = true
+	var unstarted []LogEntry
+	if !w.started {
+		// 从未启动，缓冲区中的日志无法写入数据库
+		unstarted = w.buffer
+		w.buffer = nil
+	}
+	w.bufferMux.Unlock()
+
+	if len(unstarted) > 0 {
+		if w.fallback != nil {
+			for _, entry := range unstarted {
+				w.fallback.AddEntry(entry)
+			}
+		} else {
+			w.handleError(fmt.Errorf("%d buffered entries discarded: %w", len(unstarted), ErrWriterNotStarted))
+		}
+	}
+
 	close(w.done)
+	if w.manualFlush {
+		// 没有刷新协程负责最后一次刷新
+		w.Flush()
+	}
 	w.wg.Wait()
+	if w.spill != nil {
+		if err := w.spill.close(); err != nil {
+			w.handleError(err)
+		}
+	}
 	return w.db.Close()
 }
 
@@ -0,0 +1,2048 @@
+package writer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFieldStorageModes(t *testing.T) {
+	tests := []struct {
+		storage   FieldStorage
+		column    string
+		extension bool
+		check     func(t *testing.T, arg any)
+	}{
+		{FieldStorageJSONB, "fields JSONB", false, func(t *testing.T, arg any) {
+			data, ok := arg.([]byte)
+			if !ok {
+				t.Fatalf("jsonb arg is %T, want []byte", arg)
+			}
+			var fields map[string]any
+			if err := json.Unmarshal(data, &fields); err != nil || fields["order_id"] != "A-1" || fields["qty"] != float64(2) {
+				t.Errorf("jsonb fields = %s (%v)", data, err)
+			}
+		}},
+		{FieldStorageHstore, "fields HSTORE", true, func(t *testing.T, arg any) {
+			if arg != `"order_id"=>"A-1", "qty"=>"2"` {
+				t.Errorf("hstore fields = %v", arg)
+			}
+		}},
+		{FieldStorageText, "fields TEXT", false, func(t *testing.T, arg any) {
+			if arg != `{"order_id":"A-1","qty":2}` {
+				t.Errorf("text fields = %v", arg)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.storage), func(t *testing.T) {
+			db := &mockDB{}
+			w := newTestWriter(t, db, &PostgresConfig{FieldStorage: tt.storage})
+
+			create := db.execs("CREATE TABLE")
+			if len(create) != 1 || !strings.Contains(create[0].sql, tt.column) {
+				t.Fatalf("CREATE TABLE = %v, want column %q", create, tt.column)
+			}
+			if got := len(db.execs("CREATE EXTENSION IF NOT EXISTS hstore")) == 1; got != tt.extension {
+				t.Errorf("hstore extension created = %v, want %v", got, tt.extension)
+			}
+
+			w.Info("order placed", Field("order_id", "A-1"), Field("qty", 2))
+			flushSync(t, w)
+			inserts := db.inserts()
+			if len(inserts) != 1 {
+				t.Fatalf("got %d inserts, want 1", len(inserts))
+			}
+			tt.check(t, argOf(t, w, inserts[0], "fields"))
+		})
+	}
+}
+
+func TestFieldStorageHstoreEscaping(t *testing.T) {
+	got := formatHstore(map[string]interface{}{`a"b`: `c\d`, "nil": nil})
+	if want := `"a\"b"=>"c\\d", "nil"=>NULL`; got != want {
+		t.Errorf("formatHstore = %s, want %s", got, want)
+	}
+}
+
+func TestFieldStorageUnsupported(t *testing.T) {
+	if _, err := NewPostgresqlWriter(&mockDB{}, &PostgresConfig{TableName: "logs", FieldStorage: "xml"}); err == nil {
+		t.Fatal("expected an error for an unsupported field storage")
+	}
+}
+
+func TestLoggingDuringClose(t *testing.T) {
+	db := &mockDB{}
+	var rejected atomic.Int64
+	w, err := NewPostgresqlWriter(db, &PostgresConfig{
+		TableName:     "logs",
+		BufferSize:    7,
+		FlushInterval: time.Millisecond,
+		OnError: func(err error) {
+			if errors.Is(err, ErrWriterClosed) {
+				rejected.Add(1)
+			} else {
+				t.Errorf("unexpected error: %v", err)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const goroutines, perGoroutine = 8, 200
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				w.Info("message")
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	// 每条日志要么写入数据库，要么被明确拒绝，不会静默丢失
+	written := int64(len(db.inserts()))
+	if written+rejected.Load() != goroutines*perGoroutine {
+		t.Errorf("written %d + rejected %d != %d", written, rejected.Load(), goroutines*perGoroutine)
+	}
+	if err := w.Close(); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("second Close = %v, want ErrWriterClosed", err)
+	}
+	if !db.closed {
+		t.Error("Close did not close the executor")
+	}
+}
+
+func TestFieldKeyFuncSnakeCase(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{FieldKeyFunc: SnakeCaseKey})
+	w.Info("request done", Field("requestID", "r-1"), Field("Trace", "abc"))
+	flushSync(t, w)
+
+	call := db.inserts()[0]
+	var fields map[string]any
+	json.Unmarshal(argOf(t, w, call, "fields").([]byte), &fields)
+	if fields["request_id"] != "r-1" || fields["requestID"] != nil {
+		t.Errorf("fields = %v, want request_id", fields)
+	}
+	// 特殊字段按规范化后的名称识别
+	if got := argOf(t, w, call, "trace"); got != "abc" {
+		t.Errorf("trace = %v, want abc", got)
+	}
+}
+
+func TestFieldKeyFuncDisabledByDefault(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, nil)
+	w.Info("request done", Field("requestID", "r-1"))
+	flushSync(t, w)
+
+	var fields map[string]any
+	json.Unmarshal(argOf(t, w, db.inserts()[0], "fields").([]byte), &fields)
+	if fields["requestID"] != "r-1" {
+		t.Errorf("fields = %v, want the original key", fields)
+	}
+}
+
+func TestAllowedLogTypes(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{
+		AllowedLogTypes:    []string{"user", "system"},
+		UnknownLogType:     "other",
+		WarnUnknownLogType: true,
+	})
+	w.Info("accepted", Field("log_type", "user"))
+	w.Info("rejected", Field("log_type", "usr"))
+	w.Info("unset")
+	flushSync(t, w)
+
+	got := make(map[string]any)
+	var warnings []execCall
+	for _, call := range db.inserts() {
+		content := argOf(t, w, call, "content").(string)
+		if argOf(t, w, call, "level") == "warn" {
+			warnings = append(warnings, call)
+			continue
+		}
+		got[content] = argOf(t, w, call, "log_type")
+	}
+	want := map[string]any{"accepted": "user", "rejected": "other", "unset": ""}
+	for content, logType := range want {
+		if got[content] != logType {
+			t.Errorf("%s: log_type = %v, want %q", content, got[content], logType)
+		}
+	}
+	if len(warnings) != 1 || !strings.Contains(argOf(t, w, warnings[0], "content").(string), `"usr"`) {
+		t.Errorf("got %d warnings, want one naming the unknown log_type", len(warnings))
+	}
+}
+
+func TestErrorTableRouting(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{ErrorTableName: "logs_errors"})
+
+	var created []string
+	for _, call := range db.execs("CREATE TABLE") {
+		created = append(created, call.sql)
+	}
+	if len(created) != 2 || !strings.Contains(strings.Join(created, "\n"), "logs_errors") {
+		t.Fatalf("CREATE TABLE = %v, want logs and logs_errors", created)
+	}
+
+	w.Error("failed")
+	w.Info("ok")
+	flushSync(t, w)
+
+	tables := make(map[string]string)
+	for _, call := range db.inserts() {
+		table := strings.Fields(call.sql)[2]
+		tables[argOf(t, w, call, "level").(string)] = table
+	}
+	if tables["error"] != "logs_errors" {
+		t.Errorf("error entry written to %q, want logs_errors", tables["error"])
+	}
+	if tables["info"] != "logs" {
+		t.Errorf("info entry written to %q, want logs", tables["info"])
+	}
+}
+
+func TestLevelTablesRouteByLevel(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{
+		ErrorTableName: "logs_errors",
+		LevelTables:    map[string]string{"debug": "logs_debug", "error": "logs_durable"},
+	})
+
+	created := execSQL(db.execs("CREATE TABLE"))
+	for _, table := range []string{"logs_debug", "logs_durable"} {
+		if !strings.Contains(created, table) {
+			t.Errorf("CREATE TABLE statements do not create %s:\n%s", table, created)
+		}
+	}
+
+	w.Debug("trace")
+	w.Info("ok")
+	w.Error("failed")
+	flushSync(t, w)
+
+	tables := make(map[string]string)
+	for _, call := range db.inserts() {
+		tables[argOf(t, w, call, "level").(string)] = insertTable(call.sql)
+	}
+	want := map[string]string{"debug": "logs_debug", "info": "logs", "error": "logs_durable"}
+	for level, table := range want {
+		if tables[level] != table {
+			t.Errorf("%s entry written to %q, want %q", level, tables[level], table)
+		}
+	}
+}
+
+func TestCaptureErrorChain(t *testing.T) {
+	inner := errors.New("connection refused")
+	outer := fmt.Errorf("load user: %w", inner)
+
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{CaptureErrorChain: true})
+	w.Error(outer)
+	flushSync(t, w)
+
+	call := db.inserts()[0]
+	if got := argOf(t, w, call, "content"); got != "load user: connection refused" {
+		t.Errorf("content = %v, want the top-level message", got)
+	}
+	var fields struct {
+		ErrorChain []map[string]string `json:"error_chain"`
+	}
+	if err := json.Unmarshal(argOf(t, w, call, "fields").([]byte), &fields); err != nil {
+		t.Fatal(err)
+	}
+	chain := fields.ErrorChain
+	if len(chain) != 2 {
+		t.Fatalf("error_chain = %v, want 2 links", chain)
+	}
+	if chain[0]["type"] != "*fmt.wrapError" || chain[0]["message"] != outer.Error() {
+		t.Errorf("chain[0] = %v", chain[0])
+	}
+	if chain[1]["type"] != "*errors.errorString" || chain[1]["message"] != "connection refused" {
+		t.Errorf("chain[1] = %v", chain[1])
+	}
+}
+
+func TestCaptureErrorChainDisabled(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, nil)
+	w.Error(fmt.Errorf("load user: %w", errors.New("connection refused")))
+	flushSync(t, w)
+
+	if fields := argOf(t, w, db.inserts()[0], "fields"); fields != nil {
+		if data, _ := fields.([]byte); strings.Contains(string(data), "error_chain") {
+			t.Errorf("fields = %s, want no error_chain", data)
+		}
+	}
+}
+
+func TestIDGeneratorDistinctIDs(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{IDGenerator: NewUUID})
+	for i := 0; i < 50; i++ {
+		w.Info("same content")
+	}
+	flushSync(t, w)
+
+	seen := make(map[any]bool)
+	for _, call := range db.inserts() {
+		id := argOf(t, w, call, "entry_id")
+		if id == nil || seen[id] {
+			t.Fatalf("entry_id %v is missing or repeated", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != 50 {
+		t.Errorf("got %d distinct ids, want 50", len(seen))
+	}
+}
+
+func TestIDGeneratorDisabledByDefault(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, nil)
+	w.Info("hello")
+	flushSync(t, w)
+	if id := argOf(t, w, db.inserts()[0], "entry_id"); id != nil {
+		t.Errorf("entry_id = %v, want NULL", id)
+	}
+}
+
+func TestBufferHighWaterWarnsOnce(t *testing.T) {
+	release := make(chan struct{})
+	db := &mockDB{execFunc: func(ctx context.Context, sql string, args []any) error {
+		if strings.HasPrefix(sql, "INSERT") {
+			<-release
+		}
+		return nil
+	}}
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	w := newTestWriter(t, db, &PostgresConfig{
+		BufferHighWater:         3,
+		BufferHighWaterDuration: 10 * time.Millisecond,
+		OnError: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+	})
+	backedUp := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		n := 0
+		for _, err := range errs {
+			if errors.Is(err, ErrBufferBackedUp) {
+				n++
+			}
+		}
+		return n
+	}
+
+	// 第一批卡在阻塞的 Exec 中，之后的日志持续积压
+	for i := 0; i < 5; i++ {
+		w.Info("queued")
+	}
+	w.Flush()
+	waitFor(t, "blocked insert", func() bool { return len(db.inserts()) > 0 })
+	for i := 0; i < 5; i++ {
+		w.Info("queued")
+	}
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < 20; i++ {
+		w.Info("queued")
+		time.Sleep(time.Millisecond)
+	}
+	if n := backedUp(); n != 1 {
+		t.Errorf("got %d ErrBufferBackedUp warnings, want exactly 1", n)
+	}
+
+	close(release)
+	waitFor(t, "blocked flush", func() bool { return w.BufferLen() == 25 })
+	flushSync(t, w)
+	w.Info("drained")
+	if n := backedUp(); n != 1 {
+		t.Errorf("got %d warnings after draining, want still 1", n)
+	}
+}
+
+func TestFlushSyncReportsWrittenCount(t *testing.T) {
+	var inserts atomic.Int64
+	db := &mockDB{execFunc: func(ctx context.Context, sql string, args []any) error {
+		// 第一批 3 条成功，第二批的第 2 条开始失败
+		if strings.HasPrefix(sql, "INSERT") && inserts.Add(1) > 4 {
+			return errors.New("disk full")
+		}
+		return nil
+	}}
+	w := newTestWriter(t, db, nil)
+
+	for i := 0; i < 3; i++ {
+		w.Info("first batch")
+	}
+	if n, err := w.FlushSync(); n != 3 || err != nil {
+		t.Fatalf("first FlushSync = (%d, %v), want (3, nil)", n, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		w.Info("second batch")
+	}
+	n, err := w.FlushSync()
+	if n != 1 || err == nil || !strings.Contains(err.Error(), "failed to write 2 of 3") {
+		t.Errorf("second FlushSync = (%d, %v), want (1, failed to write 2 of 3)", n, err)
+	}
+	if n, err := w.FlushSync(); n != 0 || err != nil {
+		t.Errorf("FlushSync on an empty buffer = (%d, %v), want (0, nil)", n, err)
+	}
+}
+
+func TestNotifyErrorEntriesOnly(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{NotifyChannel: "log_alerts"})
+	w.Info("fine")
+	w.Warn("careful")
+	w.Error("payment failed", Field("trace", "t-1"))
+	flushSync(t, w)
+
+	notifies := db.execs("SELECT pg_notify")
+	if len(notifies) != 1 {
+		t.Fatalf("got %d notifications, want 1 for the error entry", len(notifies))
+	}
+	args := notifies[0].args
+	if args[0] != "log_alerts" {
+		t.Errorf("channel = %v, want log_alerts", args[0])
+	}
+	var payload map[string]string
+	if err := json.Unmarshal([]byte(args[1].(string)), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload["level"] != "error" || payload["content"] != "payment failed" || payload["trace"] != "t-1" {
+		t.Errorf("payload = %v", payload)
+	}
+}
+
+func TestNotifyPayloadTruncated(t *testing.T) {
+	payload := notifyPayload(LogEntry{Level: "error", Content: strings.Repeat("界", 5000)})
+	if len(payload) > maxNotifyPayload {
+		t.Errorf("payload is %d bytes, want at most %d", len(payload), maxNotifyPayload)
+	}
+	if !json.Valid([]byte(payload)) {
+		t.Error("truncated payload is not valid JSON")
+	}
+}
+
+func TestPlaceholderStyles(t *testing.T) {
+	tests := []struct {
+		style PlaceholderStyle
+		first string
+		last  string
+	}{
+		{PlaceholderDollar, "$1", "$13"},
+		{PlaceholderQuestion, "?", "?"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.style), func(t *testing.T) {
+			w := newTestWriter(t, &mockDB{}, &PostgresConfig{PlaceholderStyle: tt.style, NotifyChannel: "alerts"})
+
+			query := w.insertQuery("logs")
+			want := "VALUES (" + tt.first
+			if !strings.Contains(query, want) || !strings.HasSuffix(query, tt.last+")") {
+				t.Errorf("insert query = %s", query)
+			}
+			if n := strings.Count(query, tt.first[:1]); n != len(w.insertColumns) {
+				t.Errorf("insert query has %d placeholders, want %d", n, len(w.insertColumns))
+			}
+			if tt.style == PlaceholderQuestion && strings.Contains(query, "$") {
+				t.Errorf("question style query contains $: %s", query)
+			}
+
+			rows := w.insertRowsQuery("logs", 2)
+			if n := strings.Count(rows, tt.first[:1]); n != 2*len(w.insertColumns) {
+				t.Errorf("multi-row query has %d placeholders, want %d", n, 2*len(w.insertColumns))
+			}
+		})
+	}
+}
+
+func TestPlaceholderStyleQuestionNotify(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{PlaceholderStyle: PlaceholderQuestion, NotifyChannel: "alerts"})
+	w.Error("boom")
+	flushSync(t, w)
+	if n := db.execs("SELECT pg_notify"); len(n) != 1 || n[0].sql != "SELECT pg_notify(?, ?)" {
+		t.Errorf("notify = %v", n)
+	}
+}
+
+func TestPlaceholderStyleUnsupported(t *testing.T) {
+	if _, err := NewPostgresqlWriter(&mockDB{}, &PostgresConfig{TableName: "logs", PlaceholderStyle: "colon"}); err == nil {
+		t.Fatal("expected an error for an unsupported placeholder style")
+	}
+}
+
+func TestParseContentFieldsOption(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{ParseContentFields: true})
+	w.Info("user=42 action=login status=ok", Field("status", "explicit"))
+	w.Info("plain prose")
+	flushSync(t, w)
+
+	inserts := db.inserts()
+	call := inserts[0]
+	if got := argOf(t, w, call, "content"); got != "user=42 action=login status=ok" {
+		t.Errorf("content = %v, want unchanged", got)
+	}
+	var fields map[string]any
+	json.Unmarshal(argOf(t, w, call, "fields").([]byte), &fields)
+	want := map[string]any{"user": "42", "action": "login", "status": "explicit"}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("fields[%s] = %v, want %v", k, fields[k], v)
+		}
+	}
+	if fields := argOf(t, w, inserts[1], "fields").([]byte); string(fields) != "null" {
+		t.Errorf("fields for prose = %s, want null", fields)
+	}
+}
+
+func TestUseUTCStoresConvertedTimestamp(t *testing.T) {
+	local := time.FixedZone("UTC+8", 8*3600)
+	ts := time.Date(2025, 12, 17, 16, 30, 0, 123456789, local)
+	entry := LogEntry{Timestamp: ts.Format(timestampLayout), Level: "info", Content: "hello"}
+
+	tests := []struct {
+		name   string
+		config *PostgresConfig
+		column string
+		offset int
+	}{
+		{"utc", &PostgresConfig{UseUTC: true, TimestampType: TimestampTypeNoTZ}, "timestamp TIMESTAMP NOT NULL", 0},
+		{"local", &PostgresConfig{}, "timestamp TIMESTAMPTZ NOT NULL", 8 * 3600},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &mockDB{}
+			w := newTestWriter(t, db, tt.config)
+			if create := execSQL(db.execs("CREATE TABLE")); !strings.Contains(create, tt.column) {
+				t.Errorf("CREATE TABLE = %s, want %s", create, tt.column)
+			}
+			w.AddEntry(entry)
+			flushSync(t, w)
+
+			got := argOf(t, w, db.inserts()[0], "timestamp").(time.Time)
+			if _, offset := got.Zone(); !got.Equal(ts) || offset != tt.offset {
+				t.Errorf("timestamp = %s, want %s at offset %d", got, ts, tt.offset)
+			}
+			if got.Nanosecond() != 123456789 {
+				t.Errorf("sub-second precision lost: %s", got)
+			}
+		})
+	}
+}
+
+func TestMaxConcurrentWrites(t *testing.T) {
+	for _, block := range []bool{false, true} {
+		t.Run(fmt.Sprintf("block=%v", block), func(t *testing.T) {
+			var active, peak, written atomic.Int64
+			release := make(chan struct{})
+			db := &mockDB{execFunc: func(ctx context.Context, sql string, args []any) error {
+				if !strings.HasPrefix(sql, "INSERT") {
+					return nil
+				}
+				n := active.Add(1)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+				<-release
+				active.Add(-1)
+				written.Add(1)
+				return nil
+			}}
+			w := newTestWriter(t, db, &PostgresConfig{BufferSize: 5, MaxConcurrentWrites: 2, BlockOnSaturation: block})
+
+			var wg sync.WaitGroup
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 25; i++ {
+						w.Info("burst")
+						w.Flush()
+					}
+				}()
+			}
+			waitFor(t, "both write slots in use", func() bool { return active.Load() == 2 })
+			// 名额已满：再等一会儿也不会出现第三个并发写入
+			time.Sleep(20 * time.Millisecond)
+			if got := active.Load(); got != 2 {
+				t.Errorf("%d concurrent Exec calls while saturated, want 2", got)
+			}
+			close(release)
+			wg.Wait()
+			w.Flush()
+			waitFor(t, "all writes", func() bool { return written.Load() == 200 })
+			if got := peak.Load(); got > 2 {
+				t.Errorf("peak concurrent Exec calls = %d, want <= 2", got)
+			}
+		})
+	}
+}
+
+func TestFlushOnError(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{FlushOnError: true})
+
+	w.Info("before")
+	w.Warn("still batching")
+	if n := len(db.inserts()); n != 0 {
+		t.Fatalf("got %d inserts before the error, want 0", n)
+	}
+	// 同步刷新：Error 返回时缓冲区中的日志（包括之前的低级别日志）已写入
+	w.Error("crash imminent")
+	if n := len(db.inserts()); n != 3 {
+		t.Errorf("got %d inserts after the error, want 3", n)
+	}
+	if w.BufferLen() != 0 {
+		t.Errorf("buffer holds %d entries after the error", w.BufferLen())
+	}
+
+	w.Info("after")
+	if n := len(db.inserts()); n != 3 {
+		t.Errorf("info after the error was flushed immediately (%d inserts)", n)
+	}
+}
+
+func TestLogTxUsesProvidedExecutor(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{NotifyChannel: "alerts"})
+	db.reset()
+
+	tx := &mockDB{}
+	if err := w.LogTx(context.Background(), tx, "error", "order rolled back", Field("order_id", "A-1")); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(tx.inserts()); n != 1 {
+		t.Fatalf("got %d inserts on the transaction, want 1", n)
+	}
+	if n := len(tx.execs("SELECT pg_notify")); n != 1 {
+		t.Errorf("got %d notifications on the transaction, want 1", n)
+	}
+	if got := argOf(t, w, tx.inserts()[0], "content"); got != "order rolled back" {
+		t.Errorf("content = %v", got)
+	}
+	// 事务写入不经过缓冲区，也不使用写入器自己的执行器
+	if n := len(db.calls); n != 0 {
+		t.Errorf("writer executor got %d statements, want 0", n)
+	}
+	if w.BufferLen() != 0 {
+		t.Errorf("buffer holds %d entries", w.BufferLen())
+	}
+
+	tx.execFunc = func(ctx context.Context, sql string, args []any) error { return errors.New("tx aborted") }
+	if err := w.LogTx(context.Background(), tx, "info", "lost"); err == nil || !strings.Contains(err.Error(), "tx aborted") {
+		t.Errorf("LogTx error = %v, want the executor error", err)
+	}
+}
+
+func TestInlineFlushBoundsGoroutines(t *testing.T) {
+	release := make(chan struct{})
+	var written atomic.Int64
+	db := &mockDB{execFunc: func(ctx context.Context, sql string, args []any) error {
+		if strings.HasPrefix(sql, "INSERT") {
+			<-release
+			written.Add(1)
+		}
+		return nil
+	}}
+	w := newTestWriter(t, db, &PostgresConfig{
+		BufferSize:              5,
+		FlushInterval:           time.Millisecond,
+		MaxConcurrentWrites:     2,
+		InlineFlushOnSaturation: true,
+	})
+	baseline := runtime.NumGoroutine()
+
+	const callers = 10
+	var wg sync.WaitGroup
+	for g := 0; g < callers; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				w.Info("stuck")
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// 执行器卡住时只有 MaxConcurrentWrites 个后台写库协程，其余调用方同步写入而不是派生新协程
+	if n := runtime.NumGoroutine() - baseline; n > callers+2 {
+		t.Errorf("%d extra goroutines while the executor is held, want at most %d", n, callers+2)
+	}
+	stats := w.Stats()
+	if stats.ActiveWrites > 2 || stats.InlineFlushes == 0 {
+		t.Errorf("ActiveWrites = %d, InlineFlushes = %d; want <= 2 and > 0", stats.ActiveWrites, stats.InlineFlushes)
+	}
+
+	close(release)
+	wg.Wait()
+	flushSync(t, w)
+	waitFor(t, "all writes", func() bool { return written.Load() == callers*20 })
+}
+
+func TestIncludeGoroutineID(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			db := &mockDB{}
+			w := newTestWriter(t, db, &PostgresConfig{IncludeGoroutineID: enabled})
+			var gid int64
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				gid = goroutineID()
+				w.Info("from goroutine")
+			}()
+			<-done
+			flushSync(t, w)
+
+			var fields map[string]any
+			json.Unmarshal(argOf(t, w, db.inserts()[0], "fields").([]byte), &fields)
+			got, ok := fields["goroutine"]
+			if ok != enabled {
+				t.Fatalf("goroutine field present = %v, want %v", ok, enabled)
+			}
+			if enabled && (gid == 0 || got != float64(gid)) {
+				t.Errorf("goroutine = %v, want %d", got, gid)
+			}
+		})
+	}
+}
+
+func TestErrorFlushInterval(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{FlushInterval: time.Hour, ErrorFlushInterval: 10 * time.Millisecond})
+
+	w.Info("low value")
+	time.Sleep(30 * time.Millisecond)
+	if n := len(db.inserts()); n != 0 {
+		t.Fatalf("info entry flushed after %d inserts, want it to wait for FlushInterval", n)
+	}
+
+	w.Error("critical")
+	// 错误日志触发快速刷新，已在缓冲区中的 info 日志随之写入
+	waitFor(t, "error flush", func() bool { return len(db.inserts()) == 2 })
+
+	w.Info("after")
+	time.Sleep(30 * time.Millisecond)
+	if n := len(db.inserts()); n != 2 {
+		t.Errorf("got %d inserts, want the info entry to keep waiting", n)
+	}
+}
+
+func TestBatchPoolClearsReleasedEntries(t *testing.T) {
+	w := newTestWriter(t, &mockDB{}, &PostgresConfig{BufferSize: 4})
+	w.bufferMux.Lock()
+	w.buffer = append(w.buffer, LogEntry{Content: "a", Fields: map[string]any{"k": 1}}, LogEntry{Content: "b"})
+	batch := w.takeBufferLocked()
+	w.bufferMux.Unlock()
+
+	if len(batch) != 2 || len(w.buffer) != 0 {
+		t.Fatalf("took %d entries, %d left in buffer", len(batch), len(w.buffer))
+	}
+	backing := batch[:cap(batch)]
+	w.releaseBatch(batch)
+	for i, entry := range backing {
+		if entry.Content != "" || entry.Fields != nil {
+			t.Errorf("released slot %d still holds %+v", i, entry)
+		}
+	}
+
+	// 过大的批次不归还，避免积压时的大切片长期驻留
+	big := make([]LogEntry, 0, 100)
+	w.releaseBatch(big)
+	for i := 0; i < 4; i++ {
+		if v, ok := w.batchPool.Get().(*[]LogEntry); ok && cap(*v) == 100 {
+			t.Fatal("oversized batch was pooled")
+		}
+	}
+}
+
+// BenchmarkTakeBuffer 对比每次刷新复制缓冲区（旧实现）与交换复用切片的分配情况
+func BenchmarkTakeBuffer(b *testing.B) {
+	const batch = 100
+	entry := LogEntry{Level: "info", Content: "request served"}
+	b.Run("copy", func(b *testing.B) {
+		buffer := make([]LogEntry, 0, batch)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < batch; j++ {
+				buffer = append(buffer, entry)
+			}
+			entries := make([]LogEntry, len(buffer))
+			copy(entries, buffer)
+			buffer = buffer[:0]
+			_ = entries
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		w := newTestWriter(b, nopDB{}, &PostgresConfig{BufferSize: batch})
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			w.bufferMux.Lock()
+			for j := 0; j < batch; j++ {
+				w.buffer = append(w.buffer, entry)
+			}
+			entries := w.takeBufferLocked()
+			w.bufferMux.Unlock()
+			w.releaseBatch(entries)
+		}
+	})
+}
+
+// BenchmarkLogFlush 完整的写日志和同步刷新路径；只有特殊字段时不分配 fields map
+func BenchmarkLogFlush(b *testing.B) {
+	for _, tt := range []struct {
+		name   string
+		fields []LogField
+	}{
+		{"special-only", []LogField{Field("trace", "t-1")}},
+		{"fields", []LogField{Field("trace", "t-1"), Field("status", 200), Field("path", "/api")}},
+	} {
+		b.Run(tt.name, func(b *testing.B) {
+			w := newTestWriter(b, nopDB{}, &PostgresConfig{BufferSize: 100})
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				w.Info("request served", tt.fields...)
+				if i%100 == 99 {
+					w.FlushSync()
+				}
+			}
+		})
+	}
+}
+
+func TestAddEntryPersistsVerbatim(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{DurationStorage: DurationStorageText})
+	userID := int64(42)
+	ts := time.Date(2025, 12, 17, 8, 0, 0, 0, time.UTC)
+	expires := ts.Add(24 * time.Hour)
+	entry := LogEntry{
+		Timestamp: ts.Format(timestampLayout),
+		EntryID:   "e-1",
+		Level:     "warn",
+		Content:   "  imported from syslog\n",
+		LogType:   "system",
+		Duration:  "150ms",
+		Trace:     "t-1",
+		Span:      "s-1",
+		UserID:    &userID,
+		Username:  "alice",
+		Component: "bridge",
+		ExpiresAt: expires.Format(timestampLayout),
+		// 已结构化的条目不做特殊字段提取：fields 中的 trace 原样保留
+		Fields: map[string]any{"host": "web-1", "trace": "from-fields"},
+	}
+	w.AddEntry(entry)
+	flushSync(t, w)
+
+	call := db.inserts()[0]
+	want := map[string]any{
+		"level":      "warn",
+		"content":    "  imported from syslog\n",
+		"log_type":   "system",
+		"duration":   "150ms",
+		"trace":      "t-1",
+		"span":       "s-1",
+		"user_id":    &userID,
+		"username":   "alice",
+		"component":  "bridge",
+		"entry_id":   "e-1",
+		"expires_at": expires,
+	}
+	for column, value := range want {
+		got := argOf(t, w, call, column)
+		if p, ok := got.(*int64); ok {
+			got = *p
+			value = *value.(*int64)
+		}
+		if got != value {
+			t.Errorf("%s = %v (%T), want %v", column, got, got, value)
+		}
+	}
+	if got := argOf(t, w, call, "timestamp").(time.Time); !got.Equal(ts) {
+		t.Errorf("timestamp = %s, want %s", got, ts)
+	}
+	if got := string(argOf(t, w, call, "fields").([]byte)); got != `{"host":"web-1","trace":"from-fields"}` {
+		t.Errorf("fields = %s", got)
+	}
+}
+
+func TestEmptyAndNilContentModes(t *testing.T) {
+	tests := []struct {
+		name   string
+		config PostgresConfig
+		// 依次为 nil、空字符串、非空内容写入的 content，"-" 表示被丢弃
+		want [3]string
+	}{
+		{"keep", PostgresConfig{}, [3]string{"", "", "hello"}},
+		{"skip", PostgresConfig{EmptyContent: EmptyContentSkip}, [3]string{"-", "-", "hello"}},
+		{"placeholder", PostgresConfig{EmptyContent: EmptyContentPlaceholder}, [3]string{"(empty)", "(empty)", "hello"}},
+		{"custom placeholder", PostgresConfig{EmptyContent: EmptyContentPlaceholder, EmptyContentPlaceholder: "n/a"}, [3]string{"n/a", "n/a", "hello"}},
+		{"nil content", PostgresConfig{NilContent: "null"}, [3]string{"null", "", "hello"}},
+		{"nil content with skip", PostgresConfig{NilContent: "null", EmptyContent: EmptyContentSkip}, [3]string{"null", "-", "hello"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &mockDB{}
+			config := tt.config
+			w := newTestWriter(t, db, &config)
+			for i, content := range []any{nil, "", "hello"} {
+				db.reset()
+				w.Info(content)
+				flushSync(t, w)
+				inserts := db.inserts()
+				got := "-"
+				if len(inserts) == 1 {
+					got = argOf(t, w, inserts[0], "content").(string)
+				}
+				if got != tt.want[i] {
+					t.Errorf("content %#v stored as %q, want %q", content, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMaxReaderBytes(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{MaxReaderBytes: 5})
+	w.Info(strings.NewReader("hello world"))
+	flushSync(t, w)
+	if got := argOf(t, w, db.inserts()[0], "content"); got != "hello...(truncated)" {
+		t.Errorf("content = %v, want the first 5 bytes", got)
+	}
+}
+
+func TestOnFlushReportsBatch(t *testing.T) {
+	type flush struct {
+		n int
+		d time.Duration
+	}
+	var mu sync.Mutex
+	var flushes []flush
+	db := &mockDB{execFunc: func(ctx context.Context, sql string, args []any) error {
+		if strings.HasPrefix(sql, "INSERT") {
+			time.Sleep(time.Millisecond)
+			if args[2] == "bad" {
+				return errors.New("rejected")
+			}
+		}
+		return nil
+	}}
+	w := newTestWriter(t, db, &PostgresConfig{OnFlush: func(n int, d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		flushes = append(flushes, flush{n, d})
+	}})
+
+	for i := 0; i < 3; i++ {
+		w.Info("ok")
+	}
+	flushSync(t, w)
+	// 部分失败时只报告成功条数；整批失败时不触发
+	w.Info("ok")
+	w.Info("bad")
+	w.FlushSync()
+	w.Info("bad")
+	w.FlushSync()
+	// 空缓冲区刷新不触发
+	flushSync(t, w)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(flushes) != 2 || flushes[0].n != 3 || flushes[1].n != 1 {
+		t.Fatalf("flushes = %+v, want counts [3 1]", flushes)
+	}
+	if flushes[0].d < 3*time.Millisecond {
+		t.Errorf("latency = %v, want at least the time spent in Exec", flushes[0].d)
+	}
+}
+
+func TestSanitizeStrings(t *testing.T) {
+	// 与 PostgreSQL 一样拒绝含 NUL 字节的文本
+	rejectNUL := func(ctx context.Context, sql string, args []any) error {
+		for _, arg := range args {
+			var s string
+			switch v := arg.(type) {
+			case string:
+				s = v
+			case []byte:
+				s = string(v)
+			}
+			if strings.Contains(s, "\x00") || strings.Contains(s, `\u0000`) {
+				return errors.New("invalid byte sequence for encoding \"UTF8\": 0x00")
+			}
+		}
+		return nil
+	}
+
+	db := &mockDB{execFunc: rejectNUL}
+	w := newTestWriter(t, db, &PostgresConfig{SanitizeStrings: true})
+	w.Info("bad\x00content", Field("blob", "a\x00b"), Field("bin", "\xffok"))
+	if n := flushSync(t, w); n != 1 {
+		t.Fatalf("wrote %d rows, want 1", n)
+	}
+	call := db.inserts()[0]
+	if got := argOf(t, w, call, "content"); got != "badcontent" {
+		t.Errorf("content = %q, want NUL stripped", got)
+	}
+	fields := fieldsOf(t, w, call)
+	if fields["blob"] != "ab" || fields["bin"] != "�ok" {
+		t.Errorf("fields = %q, want NUL stripped and invalid UTF-8 replaced", fields)
+	}
+
+	// 未开启时该条日志写入失败
+	raw := newTestWriter(t, &mockDB{execFunc: rejectNUL}, nil)
+	raw.Info("bad\x00content")
+	if n, err := raw.FlushSync(); n != 0 || err == nil {
+		t.Errorf("unsanitized flush = %d, %v; want the row rejected", n, err)
+	}
+}
+
+func TestFieldByteCaps(t *testing.T) {
+	big := strings.Repeat("x", 100)
+	tests := []struct {
+		name   string
+		config PostgresConfig
+		want   map[string]any
+	}{
+		{
+			"truncate",
+			PostgresConfig{MaxFieldBytes: 10},
+			map[string]any{"big": "xxxxxxxxxx", "list": `[1,2,3,4,5`, "small": "ok", fieldOverflowMarker: []any{"big", "list"}},
+		},
+		{
+			"drop",
+			PostgresConfig{MaxFieldBytes: 10, FieldOverflow: FieldOverflowDrop},
+			map[string]any{"small": "ok", fieldOverflowMarker: []any{"big", "list"}},
+		},
+		{
+			"total",
+			PostgresConfig{MaxFieldsBytes: 20, FieldOverflow: FieldOverflowDrop},
+			map[string]any{"list": []any{1.0, 2.0, 3.0, 4.0, 5.0, 6.0}, "small": "ok", fieldOverflowMarker: []any{"big"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &mockDB{}
+			config := tt.config
+			w := newTestWriter(t, db, &config)
+			w.Info("payload", Field("big", big), Field("list", []int{1, 2, 3, 4, 5, 6}), Field("small", "ok"))
+			flushSync(t, w)
+			if got := fieldsOf(t, w, db.inserts()[0]); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewPostgresqlWriter(&mockDB{}, &PostgresConfig{TableName: "logs", FieldOverflow: "squash"}); err == nil {
+		t.Error("unsupported overflow mode accepted")
+	}
+}
+
+func TestIdleFlushResetsOnNewEntries(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{
+		FlushInterval:     time.Second,
+		IdleFlushInterval: 100 * time.Millisecond,
+		DeferStart:        true,
+	})
+	clock := &fakeClock{}
+	w.newTimer = clock.newTimer
+	if err := w.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// log 写入一条日志并等待刷新协程重置空闲计时
+	log := func() {
+		resets := clock.resetCount()
+		w.Info("tick")
+		waitFor(t, "idle timer reset", func() bool { return clock.resetCount() > resets })
+	}
+	inserted := func() int { return len(db.inserts()) }
+
+	// 每 60ms 一条日志：空闲计时不断重置，不刷新
+	for i := 0; i < 5; i++ {
+		log()
+		clock.advance(60 * time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := inserted(); n != 0 {
+		t.Fatalf("flushed %d rows while entries kept arriving", n)
+	}
+
+	// 空闲满 100ms 后刷新
+	clock.advance(40 * time.Millisecond)
+	waitFor(t, "idle flush", func() bool { return inserted() == 5 })
+
+	// 日志持续不断时，从第一条起最迟 FlushInterval 刷新
+	for i := 0; i < 20; i++ {
+		log()
+		clock.advance(50 * time.Millisecond)
+	}
+	waitFor(t, "ceiling flush", func() bool { return inserted() == 25 })
+}
+
+func TestFallbackReceivesFailedEntries(t *testing.T) {
+	for _, multiRow := range []bool{false, true} {
+		t.Run(fmt.Sprintf("multiRow=%v", multiRow), func(t *testing.T) {
+			fallback := &memoryWriter{}
+			db := &mockDB{execFunc: func(ctx context.Context, sql string, args []any) error {
+				if strings.HasPrefix(sql, "INSERT") && slices.Contains(args, any("lost")) {
+					return errors.New("disk full")
+				}
+				return nil
+			}}
+			w := newTestWriter(t, db, &PostgresConfig{
+				Fallback:       fallback,
+				MultiRowInsert: multiRow,
+			})
+			w.Info("kept")
+			w.Error("lost", Field("order", 1))
+			w.Warn("lost", Field("order", 2))
+			if n, err := w.FlushSync(); n != 1 || err == nil {
+				t.Fatalf("FlushSync = %d, %v; want 1 written and an error", n, err)
+			}
+
+			entries := fallback.all()
+			if len(entries) != 2 {
+				t.Fatalf("fallback got %d entries, want 2", len(entries))
+			}
+			for i, entry := range entries {
+				if entry.Content != "lost" || entry.Fields["order"] != i+1 {
+					t.Errorf("fallback entry %d = %+v", i, entry)
+				}
+			}
+			if entries[0].Level != "error" || entries[1].Level != "warn" {
+				t.Errorf("fallback levels = %s, %s", entries[0].Level, entries[1].Level)
+			}
+		})
+	}
+}
+
+func TestEscalationRoutesToErrorTable(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{
+		ErrorTableName: "error_logs",
+		EscalationRules: []EscalationRule{
+			{Pattern: regexp.MustCompile(`(?i)panic`), Level: "severe"},
+			{Field: "payment", FieldValue: "declined", Level: "error"},
+		},
+	})
+	w.Info("recovered from PANIC in handler")
+	w.Info("charge", Field("payment", "declined"))
+	w.Info("charge", Field("payment", "ok"))
+	flushSync(t, w)
+
+	inserts := db.inserts()
+	if len(inserts) != 3 {
+		t.Fatalf("got %d inserts, want 3", len(inserts))
+	}
+	want := []struct{ table, level string }{{"error_logs", "severe"}, {"error_logs", "error"}, {"logs", "info"}}
+	for i, call := range inserts {
+		if !strings.HasPrefix(call.sql, "INSERT INTO "+want[i].table+" ") || argOf(t, w, call, "level") != want[i].level {
+			t.Errorf("insert %d = %s level %v, want %s in %s", i, call.sql, argOf(t, w, call, "level"), want[i].level, want[i].table)
+		}
+	}
+}
+
+func TestManualFlush(t *testing.T) {
+	db := &mockDB{}
+	before := runtime.NumGoroutine()
+	w, err := NewPostgresqlWriter(db, &PostgresConfig{TableName: "logs", BufferSize: 100, FlushInterval: time.Millisecond, ManualFlush: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("goroutines %d -> %d, want no background flush loop", before, after)
+	}
+
+	w.Info("first")
+	time.Sleep(20 * time.Millisecond)
+	if n := len(db.inserts()); n != 0 {
+		t.Fatalf("%d rows written without a flush", n)
+	}
+	w.Flush()
+	waitFor(t, "manual flush", func() bool { return len(db.inserts()) == 1 })
+
+	// Close 负责写入剩余日志
+	w.Info("second")
+	w.Info("third")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(db.inserts()); n != 3 {
+		t.Errorf("%d rows after Close, want 3", n)
+	}
+}
+
+func TestStatsLevelCounts(t *testing.T) {
+	w := newTestWriter(t, &mockDB{}, &PostgresConfig{
+		EscalationRules: []EscalationRule{{Field: "fatal", Level: "severe"}},
+	})
+	for i := 0; i < 5; i++ {
+		w.Info("request")
+	}
+	w.Warn("slow query")
+	w.Warn("slow query")
+	w.Error("failed")
+	w.Errorf("failed %d", 2)
+	w.Info("crash", Field("fatal", true))
+
+	want := map[string]int64{"info": 5, "warn": 2, "error": 2, "severe": 1}
+	if got := w.Stats().Levels; !reflect.DeepEqual(got, want) {
+		t.Errorf("Levels = %v, want %v", got, want)
+	}
+}
+
+func TestSearchPathBeforeCreateTable(t *testing.T) {
+	db := &mockDB{}
+	newTestWriter(t, db, &PostgresConfig{SearchPath: " logging ,public"})
+
+	db.mu.Lock()
+	calls := append([]execCall(nil), db.calls...)
+	db.mu.Unlock()
+	if len(calls) == 0 || calls[0].sql != "SET search_path TO logging, public" {
+		t.Fatalf("first statement = %v, want SET search_path", calls)
+	}
+	if !strings.Contains(execSQL(calls[1:]), "CREATE TABLE") {
+		t.Errorf("no CREATE TABLE after SET search_path: %s", execSQL(calls))
+	}
+
+	for _, path := range []string{"logging; DROP TABLE logs", "a b", "logging,"} {
+		if _, err := NewPostgresqlWriter(&mockDB{}, &PostgresConfig{TableName: "logs", SearchPath: path}); err == nil {
+			t.Errorf("search path %q accepted", path)
+		}
+	}
+}
+
+func TestNestedFieldsStoredAsJSON(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, nil)
+	w.Info("request",
+		Field("http", map[string]any{"method": "GET", "status": 200, "headers": map[string]any{"accept": "json"}}),
+		Field("user", slog.GroupValue(slog.String("name", "ann"), slog.Int("age", 30))),
+		Field("err", errors.New("boom")),
+	)
+	flushSync(t, w)
+
+	fields := fieldsOf(t, w, db.inserts()[0])
+	want := map[string]any{
+		"http": map[string]any{"method": "GET", "status": 200.0, "headers": map[string]any{"accept": "json"}},
+		"user": map[string]any{"name": "ann", "age": 30.0},
+		"err":  "boom",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %v, want %v", fields, want)
+	}
+	// 嵌套对象可按 JSON 路径访问，而不是字符串化的 map
+	if http, ok := fields["http"].(map[string]any); !ok || http["headers"].(map[string]any)["accept"] != "json" {
+		t.Errorf("http field is not a nested JSON object: %#v", fields["http"])
+	}
+}
+
+func TestDefaultLogType(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{DefaultLogType: "system"})
+	w.Info("booted")
+	w.Info("login", Field("log_type", "user"))
+	w.AddEntry(LogEntry{Level: "info", Content: "raw"})
+	flushSync(t, w)
+
+	want := []string{"system", "user", "system"}
+	for i, call := range db.inserts() {
+		if got := argOf(t, w, call, "log_type"); got != want[i] {
+			t.Errorf("insert %d: log_type = %v, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestLevelEnum(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{LevelEnum: "log_level", LevelEnumValues: []string{"audit"}, UnknownLevelPolicy: UnknownLevelReject})
+
+	db.mu.Lock()
+	calls := append([]execCall(nil), db.calls...)
+	db.mu.Unlock()
+	ddl := execSQL(calls)
+	create := "CREATE TYPE log_level AS ENUM ('debug', 'info', 'slow', 'stat', 'warn', 'error', 'alert', 'severe', 'stack', 'audit')"
+	typeAt, tableAt := strings.Index(ddl, create), strings.Index(ddl, "CREATE TABLE")
+	if typeAt < 0 || tableAt < typeAt {
+		t.Fatalf("enum not created before the table:\n%s", ddl)
+	}
+	if !strings.Contains(ddl, "ALTER TYPE log_level ADD VALUE IF NOT EXISTS 'audit'") {
+		t.Errorf("custom level not added to an existing enum:\n%s", ddl)
+	}
+	if !strings.Contains(ddl, "level log_level NOT NULL") {
+		t.Errorf("level column does not use the enum:\n%s", ddl)
+	}
+
+	w.Log("audit", "role granted")
+	w.Log("verbose", "rejected by policy")
+	flushSync(t, w)
+	inserts := db.inserts()
+	if len(inserts) != 1 || argOf(t, w, inserts[0], "level") != "audit" {
+		t.Errorf("inserts = %v, want only the audit entry", inserts)
+	}
+}
+
+func TestLogSyncPersistsImmediately(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, nil)
+	w.Info("buffered 1")
+	w.Info("buffered 2")
+
+	if err := w.LogSync("warn", "about to drop table", Field("table", "orders")); err != nil {
+		t.Fatal(err)
+	}
+	inserts := db.inserts()
+	if len(inserts) != 1 || argOf(t, w, inserts[0], "content") != "about to drop table" {
+		t.Fatalf("inserts = %v, want only the LogSync entry", inserts)
+	}
+	if n := w.BufferLen(); n != 2 {
+		t.Errorf("BufferLen = %d, want the 2 buffered entries still pending", n)
+	}
+
+	flushSync(t, w)
+	if n := len(db.inserts()); n != 3 {
+		t.Errorf("%d inserts after flush, want 3", n)
+	}
+
+	db.execFunc = func(ctx context.Context, sql string, args []any) error { return errors.New("constraint violation") }
+	if err := w.LogSync("error", "rejected"); err == nil {
+		t.Error("LogSync swallowed the write error")
+	}
+	w.Close()
+	if err := w.LogSync("info", "late"); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("LogSync after Close = %v, want ErrWriterClosed", err)
+	}
+}
+
+func TestFlattenFields(t *testing.T) {
+	nested := Field("http", map[string]any{"status": 200, "request": map[string]any{"method": "GET", "path": "/users"}})
+	tests := []struct {
+		name   string
+		config PostgresConfig
+		want   map[string]any
+	}{
+		{"default separator", PostgresConfig{FlattenFields: true}, map[string]any{
+			"http.status": 200.0, "http.request.method": "GET", "http.request.path": "/users", "env": "prod",
+		}},
+		{"custom separator", PostgresConfig{FlattenFields: true, FlattenSeparator: "_"}, map[string]any{
+			"http_status": 200.0, "http_request_method": "GET", "http_request_path": "/users", "env": "prod",
+		}},
+		{"max depth", PostgresConfig{FlattenFields: true, FlattenMaxDepth: 1}, map[string]any{
+			"http.status": 200.0, "http.request": map[string]any{"method": "GET", "path": "/users"}, "env": "prod",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &mockDB{}
+			config := tt.config
+			w := newTestWriter(t, db, &config)
+			w.Info("request", nested, Field("env", "prod"))
+			flushSync(t, w)
+			if got := fieldsOf(t, w, db.inserts()[0]); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// countingDB 只统计 INSERT 条数的 DBExecutor，可安全地被并发调用
+type countingDB struct {
+	nopDB
+	rows atomic.Int64
+}
+
+func (d *countingDB) Exec(ctx context.Context, sql string, args ...any) error {
+	if strings.HasPrefix(sql, "INSERT") {
+		d.rows.Add(1)
+	}
+	return nil
+}
+
+func TestConcurrentAddEntryFlushClose(t *testing.T) {
+	db := &countingDB{}
+	var rejected atomic.Int64
+	w := newTestWriter(t, db, &PostgresConfig{
+		BufferSize:    16,
+		FlushInterval: time.Millisecond,
+		OnError: func(err error) {
+			if errors.Is(err, ErrWriterClosed) {
+				rejected.Add(1)
+			} else {
+				t.Errorf("unexpected error: %v", err)
+			}
+		},
+	})
+	derived := w.Named("worker").With(Field("pool", "a"))
+
+	const writers, perWriter = 8, 500
+	var wg sync.WaitGroup
+	for g := 0; g < writers; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				if i%2 == 0 {
+					w.AddEntry(LogEntry{Level: "info", Content: "raw", Fields: map[string]interface{}{"i": i}})
+				} else {
+					derived.Info("derived", Field("i", i))
+				}
+			}
+		}()
+	}
+	stop := make(chan struct{})
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				w.FlushSync()
+			}
+		}
+	}()
+
+	// 写入进行到一半时关闭
+	waitFor(t, "some rows", func() bool { return db.rows.Load() > writers*perWriter/4 })
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	close(stop)
+	<-flushed
+
+	// 每条日志要么已写入，要么因写入器关闭被拒绝并通知
+	if total := db.rows.Load() + rejected.Load(); total != writers*perWriter {
+		t.Errorf("written %d + rejected %d = %d, want %d", db.rows.Load(), rejected.Load(), total, writers*perWriter)
+	}
+}
+
+// BenchmarkAddEntry 多协程并发写入缓冲区
+func BenchmarkAddEntry(b *testing.B) {
+	w := newTestWriter(b, nopDB{}, &PostgresConfig{BufferSize: 1000, FlushInterval: time.Second})
+	entry := LogEntry{Level: "info", Content: "request served", Fields: map[string]interface{}{"status": 200}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			w.AddEntry(entry)
+		}
+	})
+}
+
+func TestBlockOnSaturationRecordsWait(t *testing.T) {
+	release := make(chan struct{})
+	var once sync.Once
+	db := &mockDB{execFunc: func(ctx context.Context, sql string, args []any) error {
+		if strings.HasPrefix(sql, "INSERT") {
+			<-release
+		}
+		return nil
+	}}
+	w := newTestWriter(t, db, &PostgresConfig{MaxConcurrentWrites: 1, BlockOnSaturation: true})
+	t.Cleanup(func() { once.Do(func() { close(release) }) })
+
+	w.Info("first")
+	w.Flush()
+	waitFor(t, "first write to start", func() bool { return w.Stats().ActiveWrites == 1 })
+
+	// 唯一的写入名额被占用：第二次 Flush 阻塞到慢写入完成
+	w.Info("second")
+	began, flushed := make(chan struct{}), make(chan struct{})
+	go func() {
+		close(began)
+		w.Flush()
+		close(flushed)
+	}()
+	<-began
+	select {
+	case <-flushed:
+		t.Fatal("Flush returned while the only write slot was busy")
+	case <-time.After(30 * time.Millisecond):
+	}
+	once.Do(func() { close(release) })
+	<-flushed
+
+	// 协程开始到进入等待之间的调度延迟不计入，留出余量
+	if wait := w.Stats().FlushWaitTotal; wait < 20*time.Millisecond {
+		t.Errorf("FlushWaitTotal = %v, want most of the 30ms spent blocked", wait)
+	}
+	waitFor(t, "both rows", func() bool { return len(db.inserts()) == 2 })
+}
+
+func TestPayloadSeparateFromMessage(t *testing.T) {
+	type loginRequest struct {
+		IP     string `json:"ip"`
+		Method string `json:"method"`
+	}
+	db := &mockDB{}
+	w := newTestWriter(t, db, nil)
+	w.Info("user login", Payload(loginRequest{IP: "10.0.0.1", Method: "password"}), Field("tenant", "acme"))
+	flushSync(t, w)
+
+	call := db.inserts()[0]
+	if got := argOf(t, w, call, "content"); got != "user login" {
+		t.Errorf("content = %v, want only the message", got)
+	}
+	want := map[string]any{"payload": map[string]any{"ip": "10.0.0.1", "method": "password"}, "tenant": "acme"}
+	if got := fieldsOf(t, w, call); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestBeforeWriteEnrichesEntries(t *testing.T) {
+	db := &mockDB{}
+	shared := map[string]any{"user": "bob"}
+	w := newTestWriter(t, db, &PostgresConfig{BeforeWrite: func(entry *LogEntry) bool {
+		entry.LogType = "audit"
+		entry.Fields["region"] = "eu"
+		delete(entry.Fields, "user")
+		return true
+	}})
+	w.AddEntry(LogEntry{Level: "info", Content: "login", Fields: shared})
+	flushSync(t, w)
+
+	call := db.inserts()[0]
+	if got := argOf(t, w, call, "log_type"); got != "audit" {
+		t.Errorf("log_type = %v, want audit", got)
+	}
+	if got := fieldsOf(t, w, call); !reflect.DeepEqual(got, map[string]any{"region": "eu"}) {
+		t.Errorf("fields = %v, want only region", got)
+	}
+	// 钩子修改的是条目的副本，调用方传入的 map 不受影响
+	if !reflect.DeepEqual(shared, map[string]any{"user": "bob"}) {
+		t.Errorf("caller's fields were mutated: %v", shared)
+	}
+}
+
+func TestBeforeWriteDropsEntries(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{BeforeWrite: func(entry *LogEntry) bool {
+		return entry.Level != "debug"
+	}})
+	w.Debug("noise")
+	w.Info("kept")
+	if n := flushSync(t, w); n != 1 {
+		t.Fatalf("flushed %d entries, want 1", n)
+	}
+	if got := argOf(t, w, db.inserts()[0], "content"); got != "kept" {
+		t.Errorf("content = %v, want kept", got)
+	}
+}
+
+func TestDisabledColumnsOmittedFromDDLAndInsert(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{DisabledColumns: []string{"trace", "user_id"}})
+	w.Info("hello", Field("trace", "t-1"))
+	flushSync(t, w)
+
+	ddl := execSQL(db.execs("CREATE"))
+	insert := db.inserts()[0]
+	for _, column := range []string{"trace", "user_id"} {
+		if strings.Contains(ddl, column) {
+			t.Errorf("DDL mentions disabled column %s:\n%s", column, ddl)
+		}
+		if strings.Contains(insert.sql, column) {
+			t.Errorf("INSERT mentions disabled column %s: %s", column, insert.sql)
+		}
+	}
+	if !strings.Contains(ddl, "span") || !strings.Contains(insert.sql, "span") {
+		t.Error("enabled column span missing from DDL or INSERT")
+	}
+	if len(insert.args) != len(w.insertColumns) || slices.Contains(w.insertColumns, "trace") {
+		t.Errorf("insert columns %v with %d args", w.insertColumns, len(insert.args))
+	}
+
+	if _, err := NewPostgresqlWriter(&mockDB{}, &PostgresConfig{TableName: "logs", DisabledColumns: []string{"content"}}); err == nil {
+		t.Error("disabling a core column succeeded, want error")
+	}
+}
+
+func TestValidateBatchDivertsToFallback(t *testing.T) {
+	fallback := &memoryWriter{}
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{
+		Fallback: fallback,
+		ValidateBatch: func(entries []LogEntry) error {
+			for _, e := range entries {
+				if e.LogType == "audit" && e.UserID == nil {
+					return errors.New("audit entry without user_id")
+				}
+			}
+			return nil
+		},
+	})
+	w.Info("ok")
+	w.Info("who did this", Field("log_type", "audit"))
+	n, err := w.FlushSync()
+	if n != 0 || !errors.Is(err, ErrBatchRejected) {
+		t.Fatalf("FlushSync = %d, %v; want 0 and ErrBatchRejected", n, err)
+	}
+	if got := len(db.inserts()); got != 0 {
+		t.Errorf("rejected batch produced %d inserts", got)
+	}
+	if got := len(fallback.all()); got != 2 {
+		t.Errorf("fallback got %d entries, want the whole batch of 2", got)
+	}
+	if got := w.Stats().Failed; got != 2 {
+		t.Errorf("Stats().Failed = %d, want 2", got)
+	}
+
+	uid := int64(1)
+	w.AddEntry(LogEntry{Level: "info", Content: "signed", LogType: "audit", UserID: &uid})
+	if n := flushSync(t, w); n != 1 {
+		t.Errorf("valid batch wrote %d entries, want 1", n)
+	}
+}
+
+func TestContentMaxLengthTruncatesToVarchar(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{ContentMaxLength: 5})
+	if ddl := execSQL(db.execs("CREATE TABLE")); !strings.Contains(ddl, "content VARCHAR(5)") {
+		t.Errorf("DDL = %s, want content VARCHAR(5)", ddl)
+	}
+
+	w.Info("héllo 世界")
+	w.Info("short")
+	flushSync(t, w)
+	inserts := db.inserts()
+	// 按字符而非字节截断，与 VARCHAR(n) 的长度语义一致
+	if got := argOf(t, w, inserts[0], "content"); got != "héllo" {
+		t.Errorf("content = %q, want héllo", got)
+	}
+	if got := argOf(t, w, inserts[1], "content"); got != "short" {
+		t.Errorf("content = %q, want short unchanged", got)
+	}
+
+	if _, err := NewPostgresqlWriter(&mockDB{}, &PostgresConfig{TableName: "logs", ContentMaxLength: -1}); err == nil {
+		t.Error("negative ContentMaxLength accepted")
+	}
+}
+
+func TestDeferStartBuffersUntilStart(t *testing.T) {
+	db := &mockDB{pingErr: errors.New("database not ready")}
+	w := newTestWriter(t, db, &PostgresConfig{DeferStart: true})
+	w.Info("early 1")
+	w.Info("early 2")
+	if calls := db.execs(""); len(calls) != 0 {
+		t.Fatalf("construction and logging touched the database: %s", execSQL(calls))
+	}
+
+	// 启动失败后可以重试，已缓冲的日志不丢失
+	if err := w.Start(context.Background()); err == nil {
+		t.Fatal("Start succeeded while Ping fails")
+	}
+	db.pingErr = nil
+	if err := w.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.execs("CREATE TABLE")) == 0 {
+		t.Error("Start did not create the table")
+	}
+	waitFor(t, "buffered entries to flush", func() bool { return len(db.inserts()) == 2 })
+	if got := argOf(t, w, db.inserts()[0], "content"); got != "early 1" {
+		t.Errorf("first insert = %v, want early 1", got)
+	}
+	if err := w.Start(context.Background()); !errors.Is(err, ErrWriterStarted) {
+		t.Errorf("second Start = %v, want ErrWriterStarted", err)
+	}
+}
+
+func TestSpecialKeysWarnReportsOncePerKey(t *testing.T) {
+	errLog := &errorLog{}
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{SpecialKeys: SpecialKeysWarn, OnError: errLog.add})
+	w.Info("a", Field("trace", 12345), Field("user_id", "bob"))
+	w.Info("b", Field("trace", 67890))
+	w.Info("c", Field("trace", "t-1"), Field("user_id", 7))
+	flushSync(t, w)
+
+	errs := errLog.all()
+	if len(errs) != 2 {
+		t.Fatalf("errors = %v, want one per mistyped key", errs)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, ErrSpecialKeyType) {
+			t.Errorf("error = %v, want ErrSpecialKeyType", err)
+		}
+	}
+	// 警告模式仍然照常提取
+	if got := argOf(t, w, db.inserts()[2], "trace"); got != "t-1" {
+		t.Errorf("trace column = %v, want t-1", got)
+	}
+}
+
+func TestSpecialKeysOffKeepsReservedNamesInFields(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{SpecialKeys: SpecialKeysOff})
+	w.Info("a", Field("trace", "t-1"), Field("user_id", 7))
+	flushSync(t, w)
+
+	call := db.inserts()[0]
+	if got := argOf(t, w, call, "trace"); got != "" {
+		t.Errorf("trace column = %v, want empty", got)
+	}
+	fields := fieldsOf(t, w, call)
+	if fields["trace"] != "t-1" || fields["user_id"] != float64(7) {
+		t.Errorf("fields = %v, want trace and user_id kept as normal fields", fields)
+	}
+}
+
+func TestUnknownLevelPolicies(t *testing.T) {
+	tests := []struct {
+		policy     UnknownLevelPolicy
+		unknown    string
+		wantLevels string // 依次写入 verbose、verbose、info 后入库的级别
+		wantErrors int
+	}{
+		{UnknownLevelAccept, "", "verbose,verbose,info", 0},
+		{UnknownLevelMap, "", "info,info,info", 0},
+		{UnknownLevelMap, "debug", "debug,debug,info", 0},
+		{UnknownLevelReject, "", "info", 2},
+		{UnknownLevelWarn, "", "verbose,verbose,info", 1},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy)+"/"+tt.unknown, func(t *testing.T) {
+			errLog := &errorLog{}
+			db := &mockDB{}
+			w := newTestWriter(t, db, &PostgresConfig{UnknownLevelPolicy: tt.policy, UnknownLevel: tt.unknown, OnError: errLog.add})
+			w.Log("verbose", "first")
+			w.Log("verbose", "second")
+			w.Info("known")
+			flushSync(t, w)
+
+			var levels []string
+			for _, call := range db.inserts() {
+				levels = append(levels, argOf(t, w, call, "level").(string))
+			}
+			if got := strings.Join(levels, ","); got != tt.wantLevels {
+				t.Errorf("levels = %s, want %s", got, tt.wantLevels)
+			}
+			errs := errLog.all()
+			if len(errs) != tt.wantErrors {
+				t.Fatalf("errors = %v, want %d", errs, tt.wantErrors)
+			}
+			for _, err := range errs {
+				if !errors.Is(err, ErrUnknownLevel) {
+					t.Errorf("error = %v, want ErrUnknownLevel", err)
+				}
+			}
+		})
+	}
+
+	if _, err := NewPostgresqlWriter(&mockDB{}, &PostgresConfig{TableName: "logs", UnknownLevelPolicy: "ignore"}); err == nil {
+		t.Error("unsupported policy accepted")
+	}
+}
+
+// BenchmarkMessageOnly 只有消息的日志：variadic 经过 Info(any, ...LogField)，msg 为 InfoMsg 快速路径
+func BenchmarkMessageOnly(b *testing.B) {
+	msg := strings.Repeat("request served ", 2)
+	for _, tt := range []struct {
+		name string
+		log  func(w *PostgresqlWriter)
+	}{
+		{"variadic", func(w *PostgresqlWriter) { w.Info(msg) }},
+		{"msg", func(w *PostgresqlWriter) { w.InfoMsg(msg) }},
+	} {
+		b.Run(tt.name, func(b *testing.B) {
+			w := newTestWriter(b, nopDB{}, &PostgresConfig{BufferSize: 100})
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tt.log(w)
+				if i%100 == 99 {
+					w.FlushSync()
+				}
+			}
+		})
+	}
+}
+
+func TestMessageOnlySkipsBoxing(t *testing.T) {
+	msg := strings.Repeat("request served ", 2)
+	w := newTestWriter(t, nopDB{}, &PostgresConfig{BufferSize: 1000})
+	variadic := testing.AllocsPerRun(100, func() { w.Info(msg) })
+	fast := testing.AllocsPerRun(100, func() { w.InfoMsg(msg) })
+	// 快速路径省去把 string 装箱为 any 的一次分配
+	if fast >= variadic {
+		t.Errorf("InfoMsg allocates %.1f times per call, Info %.1f; want fewer", fast, variadic)
+	}
+}
+
+func TestWriteDeadlineDivertsRemainder(t *testing.T) {
+	fallback := &memoryWriter{}
+	db := &mockDB{execFunc: func(ctx context.Context, sql string, args []any) error {
+		if !strings.HasPrefix(sql, "INSERT") {
+			return nil
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	w := newTestWriter(t, db, &PostgresConfig{WriteTimeout: 20 * time.Millisecond, Fallback: fallback})
+	for i := 0; i < 5; i++ {
+		w.Info("entry", Field("n", i))
+	}
+	n, err := w.FlushSync()
+	if n != 0 || !errors.Is(err, ErrWriteDeadline) {
+		t.Fatalf("FlushSync = %d, %v; want 0 and ErrWriteDeadline", n, err)
+	}
+	// 第一条阻塞到超时，其余条目不再执行 Exec
+	if got := len(db.inserts()); got != 1 {
+		t.Errorf("issued %d INSERTs after the deadline, want 1", got)
+	}
+	entries := fallback.all()
+	if len(entries) != 5 {
+		t.Fatalf("fallback got %d entries, want 5", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Fields["n"] != i {
+			t.Errorf("fallback entry %d = %v, want the original order", i, entry.Fields["n"])
+		}
+	}
+	if !strings.Contains(err.Error(), "failed to write 5 of 5") {
+		t.Errorf("error = %v, want the whole batch counted", err)
+	}
+}
+
+func TestDurationStorage(t *testing.T) {
+	tests := []struct {
+		storage DurationStorage
+		column  string
+		want    any // "150ms" 入库的参数
+	}{
+		{DurationStorageText, "duration VARCHAR(50)", "150ms"},
+		{DurationStorageInterval, "duration INTERVAL", "150000 microseconds"},
+		{DurationStorageMilliseconds, "duration DOUBLE PRECISION", 150.0},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.storage), func(t *testing.T) {
+			db := &mockDB{}
+			w := newTestWriter(t, db, &PostgresConfig{DurationStorage: tt.storage})
+			if ddl := execSQL(db.execs("CREATE TABLE")); !strings.Contains(ddl, tt.column) {
+				t.Errorf("DDL = %s, want %s", ddl, tt.column)
+			}
+			w.Info("query", Field("duration", "150ms"))
+			w.Info("no duration")
+			flushSync(t, w)
+			inserts := db.inserts()
+			if got := argOf(t, w, inserts[0], "duration"); got != tt.want {
+				t.Errorf("duration arg = %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+			}
+			if tt.storage != DurationStorageText {
+				if got := argOf(t, w, inserts[1], "duration"); got != nil {
+					t.Errorf("empty duration = %v, want NULL", got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDurationText(t *testing.T) {
+	tests := map[string]time.Duration{
+		"150ms":  150 * time.Millisecond,
+		" 1.5s ": 1500 * time.Millisecond,
+		"2 m":    2 * time.Minute,
+		"150":    150 * time.Millisecond, // 纯数字按毫秒解析
+		"0.25":   250 * time.Microsecond,
+	}
+	for s, want := range tests {
+		if got, ok := parseDurationText(s); !ok || got != want {
+			t.Errorf("parseDurationText(%q) = %v, %v; want %v", s, got, ok, want)
+		}
+	}
+	for _, s := range []string{"", "fast", "NaN", "Inf"} {
+		if _, ok := parseDurationText(s); ok {
+			t.Errorf("parseDurationText(%q) succeeded, want failure", s)
+		}
+	}
+}
+
+func TestWithErrorStoresMessageAndError(t *testing.T) {
+	err := fmt.Errorf("charge card: %w", errors.New("card declined"))
+
+	t.Run("fields", func(t *testing.T) {
+		db := &mockDB{}
+		w := newTestWriter(t, db, &PostgresConfig{CaptureErrorChain: true})
+		w.Error("payment failed", WithError(err), Field("order", "A-1"))
+		flushSync(t, w)
+		call := db.inserts()[0]
+		if got := argOf(t, w, call, "content"); got != "payment failed" {
+			t.Errorf("content = %v, want the message", got)
+		}
+		fields := fieldsOf(t, w, call)
+		if fields["error"] != "charge card: card declined" || fields["order"] != "A-1" {
+			t.Errorf("fields = %v, want error message alongside other fields", fields)
+		}
+		if chain, _ := fields["error_chain"].([]any); len(chain) != 2 {
+			t.Errorf("error_chain = %v, want both levels of the wrapped error", fields["error_chain"])
+		}
+	})
+
+	t.Run("column", func(t *testing.T) {
+		db := &mockDB{}
+		w := newTestWriter(t, db, &PostgresConfig{ErrorColumn: true})
+		if ddl := execSQL(db.execs("CREATE TABLE")); !strings.Contains(ddl, "error TEXT") {
+			t.Errorf("DDL = %s, want an error TEXT column", ddl)
+		}
+		w.Error("payment failed", WithError(err), Field("order", "A-1"))
+		w.Info("no error")
+		flushSync(t, w)
+		inserts := db.inserts()
+		if got := argOf(t, w, inserts[0], "error"); got != "charge card: card declined" {
+			t.Errorf("error column = %v", got)
+		}
+		if fields := fieldsOf(t, w, inserts[0]); fields["error"] != nil || fields["order"] != "A-1" {
+			t.Errorf("fields = %v, want error moved to its column", fields)
+		}
+		if got := argOf(t, w, inserts[1], "error"); got != nil {
+			t.Errorf("error column without WithError = %v, want NULL", got)
+		}
+	})
+}
+
+func TestStrictOrderUnderConcurrentFlushes(t *testing.T) {
+	var mu sync.Mutex
+	var committed []string
+	var calls atomic.Int64
+	var w *PostgresqlWriter
+	db := &mockDB{execFunc: func(ctx context.Context, sql string, args []any) error {
+		if !strings.HasPrefix(sql, "INSERT") {
+			return nil
+		}
+		// 写入耗时不一：并发写入时后取出的批次可能先完成
+		time.Sleep(time.Duration(calls.Add(1)%3) * 100 * time.Microsecond)
+		mu.Lock()
+		defer mu.Unlock()
+		committed = append(committed, argOf(t, w, execCall{sql: sql, args: args}, "content").(string))
+		return nil
+	}}
+	w = newTestWriter(t, db, &PostgresConfig{BufferSize: 7, MaxConcurrentWrites: 4, StrictOrder: true})
+
+	// 在锁内写入，保证缓冲区中的顺序就是编号顺序；同时另有协程反复触发刷新
+	var logMu sync.Mutex
+	seq := 0
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				logMu.Lock()
+				w.Info(fmt.Sprintf("%03d", seq))
+				seq++
+				logMu.Unlock()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				if i%2 == 0 {
+					w.Flush()
+				} else {
+					w.FlushSync()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	flushSync(t, w)
+	waitFor(t, "all entries", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(committed) == 200
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.IsSorted(committed) {
+		t.Errorf("entries committed out of submission order: %v", committed)
+	}
+}
+
+func TestMultiRowInsertSplitsUnderBindLimit(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{BufferSize: 20000, MultiRowInsert: true})
+	rows := maxBindParams/len(w.insertColumns) + 100 // 超过单条语句能容纳的行数
+	for i := 0; i < rows; i++ {
+		w.Info("row")
+	}
+	if n := flushSync(t, w); n != rows {
+		t.Fatalf("FlushSync wrote %d entries, want %d", n, rows)
+	}
+
+	inserts := db.inserts()
+	if len(inserts) != 2 {
+		t.Fatalf("got %d INSERT statements for %d rows x %d columns, want 2", len(inserts), rows, len(w.insertColumns))
+	}
+	total := 0
+	for i, call := range inserts {
+		if len(call.args) > maxBindParams {
+			t.Errorf("statement %d binds %d parameters, want at most %d", i, len(call.args), maxBindParams)
+		}
+		total += len(call.args) / len(w.insertColumns)
+	}
+	if total != rows {
+		t.Errorf("statements insert %d rows, want %d", total, rows)
+	}
+}
+
+func TestTrimTrailingSpace(t *testing.T) {
+	for _, trim := range []bool{false, true} {
+		t.Run(fmt.Sprintf("trim=%v", trim), func(t *testing.T) {
+			db := &mockDB{}
+			w := newTestWriter(t, db, &PostgresConfig{TrimTrailingSpace: trim})
+			w.Info("line one\nline two \n")
+			flushSync(t, w)
+
+			want := "line one\nline two \n"
+			if trim {
+				want = "line one\nline two"
+			}
+			if got := argOf(t, w, db.inserts()[0], "content"); got != want {
+				t.Errorf("content = %q, want %q", got, want)
+			}
+		})
+	}
+}
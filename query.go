@@ -0,0 +1,376 @@
+package writer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Rows 查询结果集接口，database/sql 的 *sql.Rows 可直接满足，pgx 等驱动需简单适配
+type Rows interface {
+	// Next 移动到下一行，没有更多行时返回 false
+	Next() bool
+	// Scan 将当前行的列依次读入 dest
+	Scan(dest ...any) error
+	// Err 返回遍历过程中遇到的错误
+	Err() error
+	// Close 关闭结果集
+	Close() error
+}
+
+// DBQuerier 可选的查询接口，DBExecutor 同时实现此接口时才能使用 Query、ExportCSV 等读取方法
+type DBQuerier interface {
+	// Query 执行查询语句并返回结果集
+	Query(ctx context.Context, sql string, args ...any) (Rows, error)
+}
+
+// QueryOptions 日志查询条件，零值字段表示不过滤
+type QueryOptions struct {
+	Table     string            // 查询的表（默认为 TableName，仅允许 TableName、ErrorTableName 或 LevelTables 中的表）
+	Start     time.Time         // 起始时间（包含）
+	End       time.Time         // 结束时间（不包含）
+	Levels    []string          // 日志级别
+	LogType   string            // 日志类型
+	Trace     string            // 追踪 ID
+	UserID    *int64            // 用户 ID
+	Username  string            // 用户名
+	Component string            // 组件名
+	Fields    map[string]string // fields 中的键值相等条件，如 {"tenant_id": "42"}（值按文本比较，键名只允许字母、数字、下划线和点号）
+	Limit     int               // 最大返回条数（0 表示不限制）
+	Desc      bool              // 按时间倒序返回（默认正序）
+}
+
+// queryColumns 查询返回的列，顺序与 scanEntry 一致
+var queryColumns = []string{"timestamp", "level", "content", "log_type", "duration", "trace", "span", "user_id", "username", "component", "entry_id", "expires_at"}
+
+// Query 按条件查询日志
+// 数据库执行器需实现 DBQuerier 接口，否则返回错误
+func (w *PostgresqlWriter) Query(ctx context.Context, opts QueryOptions) ([]LogEntry, error) {
+	var entries []LogEntry
+	err := w.queryEach(ctx, opts, func(entry LogEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// queryEach 按条件查询日志，逐行回调，不在内存中保存全部结果
+func (w *PostgresqlWriter) queryEach(ctx context.Context, opts QueryOptions, fn func(LogEntry) error) error {
+	querier, ok := w.db.(DBQuerier)
+	if !ok {
+		return fmt.Errorf("database executor does not implement DBQuerier")
+	}
+
+	query, args, err := w.selectQuery(opts)
+	if err != nil {
+		return err
+	}
+
+	rows, err := querier.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query logs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := w.scanEntry(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan log row: %w", err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read log rows: %w", err)
+	}
+	return nil
+}
+
+// selectQuery 根据查询条件生成 SELECT 语句及参数
+func (w *PostgresqlWriter) selectQuery(opts QueryOptions) (string, []any, error) {
+	table := opts.Table
+	if table == "" {
+		table = w.tableName
+	}
+	// 配置了 TableNameFunc 时也允许查询分表（包括此前运行中创建的表）
+	if !slices.Contains(w.staticTables, table) && (w.tableNameFunc == nil || !isValidIdentifier(table)) {
+		return "", nil, fmt.Errorf("unknown log table: %s", table)
+	}
+
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, w.placeholder(len(args))))
+	}
+	for column, set := range map[string]bool{
+		"log_type":  opts.LogType != "",
+		"trace":     opts.Trace != "",
+		"user_id":   opts.UserID != nil,
+		"username":  opts.Username != "",
+		"component": opts.Component != "",
+		"fields":    len(opts.Fields) > 0,
+	} {
+		if set && !w.hasColumn(column) {
+			return "", nil, fmt.Errorf("cannot filter on disabled column %s", column)
+		}
+	}
+
+	if !opts.Start.IsZero() {
+		add("timestamp >= %s", w.dbTime(opts.Start))
+	}
+	if !opts.End.IsZero() {
+		add("timestamp < %s", w.dbTime(opts.End))
+	}
+	if len(opts.Levels) > 0 {
+		placeholders := make([]string, len(opts.Levels))
+		for i, level := range opts.Levels {
+			args = append(args, level)
+			placeholders[i] = w.placeholder(len(args))
+		}
+		conds = append(conds, fmt.Sprintf("level IN (%s)", strings.Join(placeholders, ", ")))
+	}
+	if opts.LogType != "" {
+		add("log_type = %s", opts.LogType)
+	}
+	if opts.Trace != "" {
+		add("trace = %s", opts.Trace)
+	}
+	if opts.UserID != nil {
+		add("user_id = %s", *opts.UserID)
+	}
+	if opts.Username != "" {
+		add("username = %s", opts.Username)
+	}
+	if opts.Component != "" {
+		add("component = %s", opts.Component)
+	}
+	if len(opts.Fields) > 0 {
+		keys := make([]string, 0, len(opts.Fields))
+		for key := range opts.Fields {
+			// 键名直接拼入 SQL（以便命中表达式索引），必须先校验
+			if !isValidFieldKey(key) {
+				return "", nil, fmt.Errorf("invalid field key: %q", key)
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			add(w.fieldTextExpr(key)+" = %s", opts.Fields[key])
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "SELECT %s FROM %s", w.selectList(), table)
+	if len(conds) > 0 {
+		b.WriteString(" WHERE " + strings.Join(conds, " AND "))
+	}
+	if opts.Desc {
+		b.WriteString(" ORDER BY timestamp DESC")
+	} else {
+		b.WriteString(" ORDER BY timestamp")
+	}
+	if opts.Limit > 0 {
+		fmt.Fprintf(&b, " LIMIT %d", opts.Limit)
+	}
+	return b.String(), args, nil
+}
+
+// selectList 返回与 scanEntry 顺序一致的 SELECT 列表，已关闭的列读为 NULL
+func (w *PostgresqlWriter) selectList() string {
+	exprs := make([]string, 0, len(queryColumns)+1)
+	for _, column := range queryColumns {
+		switch {
+		case column == "duration" && w.hasColumn(column) && w.durationStorage == DurationStorageInterval:
+			// 数值类型的 duration 统一读为毫秒文本，scanEntry 中转换回时长字符串
+			exprs = append(exprs, "(EXTRACT(EPOCH FROM duration) * 1000)::text AS duration")
+		case column == "duration" && w.hasColumn(column) && w.durationStorage == DurationStorageMilliseconds:
+			exprs = append(exprs, "duration::text AS duration")
+		case w.hasColumn(column):
+			exprs = append(exprs, column)
+		default:
+			exprs = append(exprs, "NULL AS "+column)
+		}
+	}
+
+	// hstore 无法直接读为 JSON，统一转为 JSON 文本后解析
+	switch {
+	case w.errorColumn:
+		// error 列合并回 fields，读回的条目与写入时一致
+		fields := "NULL::jsonb"
+		switch {
+		case !w.hasColumn("fields"):
+		case w.fieldStorage == FieldStorageHstore:
+			fields = "hstore_to_json(fields)::jsonb"
+		case w.fieldStorage == FieldStorageText:
+			fields = "fields::jsonb"
+		default:
+			fields = "fields"
+		}
+		exprs = append(exprs, fmt.Sprintf("(CASE WHEN error IS NULL THEN %s ELSE COALESCE(%s, '{}'::jsonb) || jsonb_build_object('error', error) END)::text AS fields", fields, fields))
+	case !w.hasColumn("fields"):
+		exprs = append(exprs, "NULL AS fields")
+	case w.fieldStorage == FieldStorageHstore:
+		exprs = append(exprs, "hstore_to_json(fields)::text AS fields")
+	default:
+		exprs = append(exprs, "fields::text AS fields")
+	}
+	return strings.Join(exprs, ", ")
+}
+
+// isValidFieldKey 判断 fields 键名能否安全拼入 SQL：由点号连接的标识符（如 FlattenFields 展开后的 http.status）
+func isValidFieldKey(key string) bool {
+	for _, part := range strings.Split(key, ".") {
+		if !isValidIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldTextExpr 返回以文本形式读取 fields 中指定键的 SQL 表达式，key 须已校验
+func (w *PostgresqlWriter) fieldTextExpr(key string) string {
+	if w.errorColumn && key == ErrorKey {
+		// 错误消息为非字符串值时仍保存在 fields 中
+		return "COALESCE(error, " + w.fieldsTextExpr(key) + ")"
+	}
+	return w.fieldsTextExpr(key)
+}
+
+// fieldsTextExpr 返回以文本形式读取 fields 列中指定键的 SQL 表达式，key 须已校验
+func (w *PostgresqlWriter) fieldsTextExpr(key string) string {
+	switch w.fieldStorage {
+	case FieldStorageHstore:
+		return fmt.Sprintf("fields->'%s'", key)
+	case FieldStorageText:
+		return fmt.Sprintf("(fields::jsonb)->>'%s'", key)
+	default:
+		return fmt.Sprintf("fields->>'%s'", key)
+	}
+}
+
+// scanEntry 将当前行读取为 LogEntry，可为空的列以指针接收
+func (w *PostgresqlWriter) scanEntry(rows Rows) (LogEntry, error) {
+	var (
+		timestamp                                           time.Time
+		level, content                                      string
+		logType, duration, trace, span, username, component *string
+		entryID, fields                                     *string
+		userID                                              *int64
+		expiresAt                                           *time.Time
+	)
+	if err := rows.Scan(&timestamp, &level, &content, &logType, &duration, &trace, &span,
+		&userID, &username, &component, &entryID, &expiresAt, &fields); err != nil {
+		return LogEntry{}, err
+	}
+
+	entry := LogEntry{
+		Timestamp: timestamp.Format(timestampLayout),
+		Level:     level,
+		Content:   content,
+		LogType:   derefString(logType),
+		Duration:  derefString(duration),
+		Trace:     derefString(trace),
+		Span:      derefString(span),
+		UserID:    userID,
+		Username:  derefString(username),
+		Component: derefString(component),
+		EntryID:   derefString(entryID),
+	}
+	if expiresAt != nil {
+		entry.ExpiresAt = expiresAt.Format(timestampLayout)
+	}
+	if w.durationStorage != DurationStorageText && entry.Duration != "" {
+		if d, ok := parseDurationText(entry.Duration); ok {
+			entry.Duration = d.Truncate(time.Microsecond).String()
+		}
+	}
+	if fields != nil && *fields != "" {
+		if err := json.Unmarshal([]byte(*fields), &entry.Fields); err != nil {
+			return LogEntry{}, fmt.Errorf("failed to decode fields: %w", err)
+		}
+	}
+	return entry, nil
+}
+
+// derefString 返回字符串指针的值，nil 返回空字符串
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// CountByLevel 统计时间范围内各级别的日志条数（from 包含、to 不包含，零值表示不限制）
+// 配置了 ErrorTableName、LevelTables 时合并所有表的结果
+func (w *PostgresqlWriter) CountByLevel(ctx context.Context, from, to time.Time) (map[string]int64, error) {
+	return w.countBy(ctx, "level", from, to)
+}
+
+// CountByLogType 统计时间范围内各 log_type 的日志条数，未设置 log_type 的日志计入空字符串
+func (w *PostgresqlWriter) CountByLogType(ctx context.Context, from, to time.Time) (map[string]int64, error) {
+	return w.countBy(ctx, "log_type", from, to)
+}
+
+// countBy 按指定列分组计数，column 只能是内部传入的列名
+func (w *PostgresqlWriter) countBy(ctx context.Context, column string, from, to time.Time) (map[string]int64, error) {
+	querier, ok := w.db.(DBQuerier)
+	if !ok {
+		return nil, fmt.Errorf("database executor does not implement DBQuerier")
+	}
+	if !w.hasColumn(column) {
+		return nil, fmt.Errorf("cannot count by disabled column %s", column)
+	}
+
+	var conds []string
+	var args []any
+	if !from.IsZero() {
+		args = append(args, w.dbTime(from))
+		conds = append(conds, "timestamp >= "+w.placeholder(len(args)))
+	}
+	if !to.IsZero() {
+		args = append(args, w.dbTime(to))
+		conds = append(conds, "timestamp < "+w.placeholder(len(args)))
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	counts := make(map[string]int64)
+	for _, table := range w.tables() {
+		// 转为文本再 COALESCE，level 列为 ENUM 时空字符串不是合法的枚举值
+		query := fmt.Sprintf("SELECT COALESCE(%s::text, ''), COUNT(*) FROM %s%s GROUP BY 1", column, table, where)
+		if err := w.scanCounts(ctx, querier, query, args, counts); err != nil {
+			return nil, fmt.Errorf("failed to count logs in table %s: %w", table, err)
+		}
+	}
+	return counts, nil
+}
+
+// scanCounts 执行分组计数查询，将结果累加到 counts
+func (w *PostgresqlWriter) scanCounts(ctx context.Context, querier DBQuerier, query string, args []any, counts map[string]int64) error {
+	rows, err := querier.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var n int64
+		if err := rows.Scan(&key, &n); err != nil {
+			return err
+		}
+		counts[key] += n
+	}
+	return rows.Err()
+}
@@ -0,0 +1,93 @@
+package writer
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryFieldPredicates(t *testing.T) {
+	db := &queryDB{queryFunc: func(sql string, args []any) ([][]any, error) {
+		ts := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		return [][]any{logRow(ts, "error", "payment failed", `{"tenant_id": "42", "region": "eu"}`)}, nil
+	}}
+	w := newTestWriter(t, db, nil)
+
+	entries, err := w.Query(context.Background(), QueryOptions{
+		Levels: []string{"error", "warn"},
+		Fields: map[string]string{"tenant_id": "42", "region": "eu"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Fields["tenant_id"] != "42" {
+		t.Fatalf("entries = %+v, want the tenant 42 row", entries)
+	}
+
+	query := db.lastQuery()
+	// 键名按字母序编译，值全部参数化
+	wantWhere := "WHERE level IN ($1, $2) AND fields->>'region' = $3 AND fields->>'tenant_id' = $4"
+	if !strings.Contains(query.sql, wantWhere) {
+		t.Errorf("query = %s, want %s", query.sql, wantWhere)
+	}
+	if want := []any{"error", "warn", "eu", "42"}; !reflect.DeepEqual(query.args, want) {
+		t.Errorf("args = %v, want %v", query.args, want)
+	}
+}
+
+func TestQueryRejectsInvalidFieldKey(t *testing.T) {
+	db := &queryDB{}
+	w := newTestWriter(t, db, nil)
+	for _, key := range []string{"a'; DROP TABLE logs; --", "a b", "", "x..y"} {
+		if _, err := w.Query(context.Background(), QueryOptions{Fields: map[string]string{key: "1"}}); err == nil {
+			t.Errorf("key %q accepted, want error", key)
+		}
+	}
+	if len(db.queries) != 0 {
+		t.Errorf("invalid keys reached the database: %v", db.queries)
+	}
+}
+
+func TestCountByLevel(t *testing.T) {
+	db := &queryDB{queryFunc: func(sql string, args []any) ([][]any, error) {
+		return [][]any{{"info", int64(120)}, {"warn", int64(7)}, {"error", int64(3)}}, nil
+	}}
+	w := newTestWriter(t, db, nil)
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	counts, err := w.CountByLevel(context.Background(), from, from.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int64{"info": 120, "warn": 7, "error": 3}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+	query := db.lastQuery()
+	if want := "SELECT COALESCE(level::text, ''), COUNT(*) FROM logs WHERE timestamp >= $1 AND timestamp < $2 GROUP BY 1"; query.sql != want {
+		t.Errorf("query = %s, want %s", query.sql, want)
+	}
+	if len(query.args) != 2 {
+		t.Errorf("args = %v, want the time window", query.args)
+	}
+}
+
+func TestCountByLogTypeSumsTables(t *testing.T) {
+	db := &queryDB{queryFunc: func(sql string, args []any) ([][]any, error) {
+		if strings.Contains(sql, "FROM error_logs") {
+			return [][]any{{"system", int64(2)}}, nil
+		}
+		return [][]any{{"user", int64(5)}, {"system", int64(1)}, {"", int64(4)}}, nil
+	}}
+	w := newTestWriter(t, db, &PostgresConfig{ErrorTableName: "error_logs"})
+	counts, err := w.CountByLogType(context.Background(), time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int64{"user": 5, "system": 3, "": 4}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+	if len(db.queries) != 2 || strings.Contains(db.queries[0].sql, "WHERE") {
+		t.Errorf("queries = %v, want one unfiltered query per table", db.queries)
+	}
+}
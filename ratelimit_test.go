@@ -0,0 +1,95 @@
+package writer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketRefill(t *testing.T) {
+	b := newTokenBucket(2, 3)
+	now := b.last
+	for i := 0; i < 3; i++ {
+		if !b.allow(now) {
+			t.Fatalf("token %d denied from a full bucket", i)
+		}
+	}
+	if b.allow(now) {
+		t.Fatal("empty bucket allowed a token")
+	}
+
+	// 每秒补充 2 个：0.5 秒后补 1 个
+	now = now.Add(500 * time.Millisecond)
+	if !b.allow(now) {
+		t.Fatal("refilled token denied")
+	}
+	if b.allow(now) {
+		t.Fatal("allowed more than the refilled tokens")
+	}
+
+	// 长时间空闲后不超过桶容量
+	now = now.Add(time.Hour)
+	allowed := 0
+	for b.allow(now) {
+		allowed++
+	}
+	if allowed != 3 {
+		t.Errorf("allowed %d after idling, want burst 3", allowed)
+	}
+}
+
+func TestTokenBucketDefaultBurst(t *testing.T) {
+	if b := newTokenBucket(0.5, 0); b.burst != 1 {
+		t.Errorf("burst = %v, want 1 for a sub-1 rate", b.burst)
+	}
+	if b := newTokenBucket(20, 0); b.burst != 20 {
+		t.Errorf("burst = %v, want the rate", b.burst)
+	}
+}
+
+func TestRateLimitSuppressionSummary(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{RateLimit: 1, RateBurst: 2})
+	for i := 0; i < 5; i++ {
+		w.Info("storm")
+	}
+	if got := w.SuppressedCount(); got != 3 {
+		t.Fatalf("SuppressedCount = %d, want 3", got)
+	}
+
+	// 模拟令牌补充：下一条放行时先写入汇总日志
+	w.limiter.mu.Lock()
+	w.limiter.tokens = 1
+	w.limiter.mu.Unlock()
+	w.Info("recovered")
+	flushSync(t, w)
+
+	inserts := db.inserts()
+	if len(inserts) != 4 {
+		t.Fatalf("got %d inserts, want 2 allowed + summary + recovered", len(inserts))
+	}
+	summary := inserts[2]
+	if got := argOf(t, w, summary, "content"); got != "3 logs suppressed by rate limiter" {
+		t.Errorf("summary content = %v", got)
+	}
+	if got := argOf(t, w, summary, "level"); got != "warn" {
+		t.Errorf("summary level = %v, want warn", got)
+	}
+	if got := argOf(t, w, inserts[3], "content"); got != "recovered" {
+		t.Errorf("last content = %v, want recovered", got)
+	}
+	if got := w.Stats().Suppressed; got != 3 {
+		t.Errorf("Stats().Suppressed = %d, want 3", got)
+	}
+}
+
+func TestRateLimitExemptErrors(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{RateLimit: 1, RateBurst: 1, RateLimitExemptErrors: true})
+	w.Info("first")
+	w.Info("dropped")
+	w.Error("kept")
+	flushSync(t, w)
+	if got := len(db.inserts()); got != 2 {
+		t.Errorf("got %d inserts, want 2", got)
+	}
+}
@@ -0,0 +1,134 @@
+package writer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// replayOffsetSuffix 记录回放进度的旁路文件后缀，中断后再次回放从该位置继续
+const replayOffsetSuffix = ".offset"
+
+// Replay 将 JSONL 格式的死信文件（如作为 Fallback 的 FileWriter 写出的文件）重新写入数据库
+// 按 BufferSize 分批写入，与正常刷新走同一写库路径（MultiRowInsert、重试、ValidateBatch、分表、OnFlush 及统计均生效），
+// 每批成功后记录已处理的字节位置（path + ".offset"），中断后再次调用会从该位置继续，
+// 最多重复写入中断时所在的一批；全部写入成功后清空文件并删除进度文件，返回本次写入的条数
+// 写入器已关闭时返回 ErrWriterClosed，开启 DeferStart 后尚未 Start 时返回 ErrWriterNotStarted
+// 无法解析的行通过 OnError 回调报告后跳过。回放期间文件不应再被追加，建议先 Reopen 轮转 FileWriter，再回放轮转出的旧文件
+func (w *PostgresqlWriter) Replay(ctx context.Context, path string) (int, error) {
+	w.bufferMux.Lock()
+	closed, started := w.closed, w.started
+	w.bufferMux.Unlock()
+	if closed {
+		return 0, ErrWriterClosed
+	}
+	if !started {
+		return 0, ErrWriterNotStarted
+	}
+	if w.disabled {
+		return 0, fmt.Errorf("writer is disabled")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open dead-letter file: %w", err)
+	}
+	defer file.Close()
+
+	offsetPath := path + replayOffsetSuffix
+	offset, err := readReplayOffset(offsetPath)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to seek dead-letter file: %w", err)
+	}
+
+	restored := 0
+	reader := bufio.NewReader(file)
+	batch := make([]LogEntry, 0, w.bufferSize)
+	consumed := offset
+	// flush 写入当前批次并记录进度，有条目失败时停止回放，下次从上一批结束处继续
+	flush := func() error {
+		n, err := w.writeEntriesContext(ctx, batch)
+		restored += n
+		if err != nil {
+			return fmt.Errorf("failed to replay log entries: %w", err)
+		}
+		batch = batch[:0]
+		return writeReplayOffset(offsetPath, consumed)
+	}
+
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return restored, fmt.Errorf("failed to read dead-letter file: %w", readErr)
+		}
+		lineOffset := consumed
+		consumed += int64(len(line))
+
+		if trimmed := strings.TrimSpace(string(line)); trimmed != "" {
+			var entry LogEntry
+			if err := json.Unmarshal([]byte(trimmed), &entry); err != nil {
+				w.handleError(fmt.Errorf("skipping malformed dead-letter entry at offset %d: %w", lineOffset, err))
+			} else {
+				batch = append(batch, entry)
+			}
+		}
+
+		if len(batch) >= w.bufferSize || (readErr != nil && len(batch) > 0) {
+			if err := ctx.Err(); err != nil {
+				return restored, err
+			}
+			if err := flush(); err != nil {
+				return restored, err
+			}
+		}
+		if readErr != nil {
+			break
+		}
+	}
+
+	// 全部写入成功：清空死信文件并删除进度文件
+	if err := os.Truncate(path, 0); err != nil {
+		return restored, fmt.Errorf("failed to truncate dead-letter file: %w", err)
+	}
+	if err := os.Remove(offsetPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return restored, fmt.Errorf("failed to remove replay offset file: %w", err)
+	}
+	return restored, nil
+}
+
+// readReplayOffset 读取回放进度，进度文件不存在时从头开始
+func readReplayOffset(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read replay offset: %w", err)
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid replay offset in %s: %q", path, data)
+	}
+	return offset, nil
+}
+
+// writeReplayOffset 以先写临时文件再重命名的方式保存回放进度，避免中断时留下不完整的进度
+func writeReplayOffset(path string, offset int64) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(offset, 10)), 0644); err != nil {
+		return fmt.Errorf("failed to write replay offset: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to save replay offset: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package writer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeDeadLetter 写出含 n 条日志的 JSONL 死信文件
+func writeDeadLetter(t *testing.T, n int) string {
+	t.Helper()
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		data, err := json.Marshal(LogEntry{Timestamp: "2024-05-01T10:00:00Z", Level: "error", Content: fmt.Sprintf("entry %d", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// insertedRows 统计 INSERT 语句写入的行数（多行 INSERT 按参数数换算）
+func insertedRows(w *PostgresqlWriter, db *mockDB) int {
+	rows := 0
+	for _, call := range db.inserts() {
+		rows += len(call.args) / len(w.insertColumns)
+	}
+	return rows
+}
+
+func TestReplayRowCount(t *testing.T) {
+	for _, multiRow := range []bool{false, true} {
+		t.Run(fmt.Sprintf("multiRow=%v", multiRow), func(t *testing.T) {
+			path := writeDeadLetter(t, 350)
+			db := &mockDB{}
+			var flushed atomic.Int64
+			w := newTestWriter(t, db, &PostgresConfig{
+				MultiRowInsert: multiRow,
+				OnFlush:        func(n int, _ time.Duration) { flushed.Add(int64(n)) },
+			})
+			db.reset()
+
+			n, err := w.Replay(context.Background(), path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 350 || insertedRows(w, db) != 350 {
+				t.Errorf("replayed %d, inserted %d rows, want 350", n, insertedRows(w, db))
+			}
+			if multiRow && len(db.inserts()) != 4 {
+				t.Errorf("%d INSERT statements, want 4 batches of BufferSize", len(db.inserts()))
+			}
+			// 与正常刷新走同一写库路径
+			if flushed.Load() != 350 {
+				t.Errorf("OnFlush saw %d entries, want 350", flushed.Load())
+			}
+			if data, _ := os.ReadFile(path); len(data) != 0 {
+				t.Errorf("dead-letter file not truncated: %d bytes left", len(data))
+			}
+			if _, err := os.Stat(path + replayOffsetSuffix); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("offset file left behind: %v", err)
+			}
+		})
+	}
+}
+
+func TestReplayResumesAfterFailure(t *testing.T) {
+	path := writeDeadLetter(t, 250)
+	var fail atomic.Bool
+	fail.Store(true)
+	db := &mockDB{execFunc: func(ctx context.Context, sql string, args []any) error {
+		if fail.Load() && strings.HasPrefix(sql, "INSERT") && args[2] == "entry 150" {
+			return errors.New("connection reset")
+		}
+		return nil
+	}}
+	w := newTestWriter(t, db, nil)
+	db.reset()
+
+	n, err := w.Replay(context.Background(), path)
+	// 第二批中只有一条失败，其余照常写入，但该批的进度不会记录
+	if err == nil || n != 199 {
+		t.Fatalf("Replay = %d, %v; want 199 written and an error", n, err)
+	}
+
+	// 从失败的第二批开始重放，该批中已写入的条目会重复
+	fail.Store(false)
+	db.reset()
+	n, err = w.Replay(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 150 || insertedRows(w, db) != 150 {
+		t.Errorf("resumed replay wrote %d rows, want the last 150", n)
+	}
+	if got := db.inserts()[0].args[2]; got != "entry 100" {
+		t.Errorf("resumed at %v, want entry 100", got)
+	}
+}
+
+func TestReplayRequiresRunningWriter(t *testing.T) {
+	path := writeDeadLetter(t, 3)
+
+	w := newTestWriter(t, &mockDB{}, &PostgresConfig{DeferStart: true})
+	if _, err := w.Replay(context.Background(), path); !errors.Is(err, ErrWriterNotStarted) {
+		t.Errorf("Replay before Start = %v, want ErrWriterNotStarted", err)
+	}
+
+	closed := newTestWriter(t, &mockDB{}, nil)
+	closed.Close()
+	if _, err := closed.Replay(context.Background(), path); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("Replay after Close = %v, want ErrWriterClosed", err)
+	}
+	if data, _ := os.ReadFile(path); len(strings.Split(strings.TrimSpace(string(data)), "\n")) != 3 {
+		t.Error("dead-letter file modified by a rejected Replay")
+	}
+}
@@ -0,0 +1,55 @@
+package writer
+
+import (
+	"sync"
+	"time"
+)
+
+// RequestScope 请求作用域：记录开始时间并累积字段，结束时输出一条带 duration 字段的日志
+// 用于 Web Handler 等需要统一记录请求耗时的场景，可在多个协程中调用 Add
+type RequestScope struct {
+	writer Writer
+	now    func() time.Time
+	start  time.Time
+
+	mu     sync.Mutex
+	fields []LogField
+}
+
+// StartRequest 开始一个请求作用域，fields 为请求级字段（如 trace、route）
+func StartRequest(w Writer, fields ...LogField) *RequestScope {
+	return startRequest(w, time.Now, fields)
+}
+
+// startRequest 以 now 为时钟开始请求作用域
+func startRequest(w Writer, now func() time.Time, fields []LogField) *RequestScope {
+	return &RequestScope{
+		writer: w,
+		now:    now,
+		start:  now(),
+		fields: mergeFields(nil, fields),
+	}
+}
+
+// Add 追加字段，同名字段以后追加的为准
+func (r *RequestScope) Add(fields ...LogField) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fields = mergeFields(r.fields, fields)
+}
+
+// Elapsed 返回自请求开始以来的耗时
+func (r *RequestScope) Elapsed() time.Duration {
+	return r.now().Sub(r.start)
+}
+
+// End 结束请求并输出日志，duration 字段为自开始以来的耗时（显式传入的 duration 字段优先）
+func (r *RequestScope) End(level string, content any, fields ...LogField) {
+	duration := r.Elapsed()
+
+	r.mu.Lock()
+	merged := mergeFields(append([]LogField{Field("duration", duration)}, r.fields...), fields)
+	r.mu.Unlock()
+
+	r.writer.Log(level, content, merged...)
+}
@@ -0,0 +1,39 @@
+package writer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequestScopeDuration(t *testing.T) {
+	mem := &memoryWriter{}
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	req := startRequest(mem, clock, []LogField{Field("route", "/users"), Field("status", 0)})
+	now = now.Add(250 * time.Millisecond)
+	req.Add(Field("status", 200))
+	if got := req.Elapsed(); got != 250*time.Millisecond {
+		t.Errorf("Elapsed = %v, want 250ms", got)
+	}
+	now = now.Add(1250 * time.Millisecond)
+	req.End("info", "request done", Field("bytes", 512))
+
+	entries := mem.all()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.Duration != "1.5s" {
+		t.Errorf("duration = %q, want 1.5s", entry.Duration)
+	}
+	if entry.Fields["route"] != "/users" || entry.Fields["status"] != 200 || entry.Fields["bytes"] != 512 {
+		t.Errorf("fields = %v, want accumulated request fields", entry.Fields)
+	}
+
+	// 显式传入的 duration 优先
+	startRequest(mem, clock, nil).End("info", "custom", Field("duration", "3s"))
+	if got := mem.all()[1].Duration; got != "3s" {
+		t.Errorf("explicit duration = %q, want 3s", got)
+	}
+}
@@ -0,0 +1,123 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Sweep 立即执行一次过期日志清理
+// 设置了 expires_at（通过 ttl 字段）的日志在过期后删除；其余日志在超过 Retention 后删除
+// DELETE 在 MaintenanceDB 上执行（未配置时使用主执行器），避免与日志写入争用连接
+// 开启 RetentionDropPartitions 时先整体删除已过期的分区，剩余分区和未分区的表仍使用 DELETE
+func (w *PostgresqlWriter) Sweep(ctx context.Context) error {
+	var errs []error
+	for _, table := range w.tables() {
+		if err := w.sweepTable(ctx, table); err != nil {
+			errs = append(errs, fmt.Errorf("failed to sweep table %s: %w", table, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sweepTable 清理单张表中的过期日志
+func (w *PostgresqlWriter) sweepTable(ctx context.Context, table string) error {
+	if w.retentionDropPartitions && w.retention > 0 {
+		if err := w.dropExpiredPartitions(ctx, table); err != nil {
+			return err
+		}
+	}
+
+	if !w.hasColumn("expires_at") {
+		// 关闭 expires_at 后只按 Retention 清理
+		if w.retention <= 0 {
+			return nil
+		}
+		query := fmt.Sprintf(`DELETE FROM %s WHERE timestamp < %s`, table, w.placeholder(1))
+		return w.maintenanceDB.Exec(ctx, query, w.dbTime(time.Now().Add(-w.retention)))
+	}
+
+	if w.retention <= 0 {
+		query := fmt.Sprintf(`DELETE FROM %s WHERE expires_at < NOW()`, table)
+		return w.maintenanceDB.Exec(ctx, query)
+	}
+
+	cutoff := w.dbTime(time.Now().Add(-w.retention))
+	query := fmt.Sprintf(`DELETE FROM %s WHERE expires_at < NOW() OR (expires_at IS NULL AND timestamp < %s)`,
+		table, w.placeholder(1))
+	return w.maintenanceDB.Exec(ctx, query, cutoff)
+}
+
+// dropExpiredPartitions 删除按时间范围分区且上界不晚于保留截止时间的分区，比 DELETE 代价低且不产生膨胀
+// 分区上界由 PostgreSQL 解析和比较；DEFAULT、MAXVALUE 分区和继承子表不会被删除。
+// 分区中仍有未到期的 expires_at 时保留该分区，交给 DELETE 处理；执行器未实现 DBQuerier 或表未分区时不做任何操作
+func (w *PostgresqlWriter) dropExpiredPartitions(ctx context.Context, table string) error {
+	querier, ok := w.maintenanceDB.(DBQuerier)
+	if !ok {
+		return nil
+	}
+
+	query := fmt.Sprintf(`SELECT c.oid::regclass::text FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+WHERE i.inhparent = %s::regclass
+AND substring(pg_get_expr(c.relpartbound, c.oid) from 'TO \(''([^'']+)''\)')::%s <= %s`,
+		w.placeholder(1), w.timestampColumnType(), w.placeholder(2))
+	partitions, err := queryStrings(ctx, querier, query, table, w.dbTime(time.Now().Add(-w.retention)))
+	if err != nil {
+		return fmt.Errorf("failed to list partitions: %w", err)
+	}
+
+	for _, partition := range partitions {
+		if w.hasColumn("expires_at") {
+			live, err := queryStrings(ctx, querier, fmt.Sprintf(`SELECT expires_at::text FROM %s WHERE expires_at >= NOW() LIMIT 1`, partition))
+			if err != nil {
+				return fmt.Errorf("failed to check partition %s: %w", partition, err)
+			}
+			if len(live) > 0 {
+				continue
+			}
+		}
+		if err := w.maintenanceDB.Exec(ctx, "DROP TABLE "+partition); err != nil {
+			return fmt.Errorf("failed to drop partition %s: %w", partition, err)
+		}
+	}
+	return nil
+}
+
+// queryStrings 执行查询并读取第一列的全部值
+func queryStrings(ctx context.Context, querier DBQuerier, query string, args ...any) ([]string, error) {
+	rows, err := querier.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+// retentionLoop 后台定时清理协程
+func (w *PostgresqlWriter) retentionLoop() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.retentionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), w.retentionInterval)
+			if err := w.Sweep(ctx); err != nil {
+				w.handleError(err)
+			}
+			cancel()
+		case <-w.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package writer
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tableDB 在内存中保存写入的行，并按 Sweep 生成的 DELETE 条件删除过期行
+type tableDB struct {
+	mockDB
+	w    *PostgresqlWriter
+	rmu  sync.Mutex
+	rows []map[string]any
+}
+
+func (d *tableDB) Exec(ctx context.Context, sql string, args ...any) error {
+	d.mockDB.Exec(ctx, sql, args...)
+	d.rmu.Lock()
+	defer d.rmu.Unlock()
+	switch {
+	case strings.HasPrefix(sql, "INSERT"):
+		row := make(map[string]any, len(args))
+		for i, column := range d.w.insertColumns {
+			row[column] = args[i]
+		}
+		d.rows = append(d.rows, row)
+	case strings.HasPrefix(sql, "DELETE"):
+		d.rows = d.sweep(sql, args)
+	}
+	return nil
+}
+
+// sweep 模拟 PostgreSQL 对 expires_at < NOW() OR (expires_at IS NULL AND timestamp < $1) 的求值
+func (d *tableDB) sweep(sql string, args []any) []map[string]any {
+	now := time.Now()
+	var kept []map[string]any
+	for _, row := range d.rows {
+		expired := false
+		if expiresAt, ok := row["expires_at"].(time.Time); ok {
+			expired = expiresAt.Before(now)
+		} else if strings.Contains(sql, "timestamp <") {
+			expired = row["timestamp"].(time.Time).Before(args[0].(time.Time))
+		}
+		if !expired {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
+
+// contents 返回剩余行的 content
+func (d *tableDB) contents() []string {
+	d.rmu.Lock()
+	defer d.rmu.Unlock()
+	var out []string
+	for _, row := range d.rows {
+		out = append(out, row["content"].(string))
+	}
+	return out
+}
+
+func TestSweepRespectsEntryTTL(t *testing.T) {
+	db := &tableDB{}
+	w := newTestWriter(t, db, &PostgresConfig{Retention: 24 * time.Hour})
+	db.w = w
+
+	w.Info("short", Field("ttl", time.Millisecond))
+	w.Info("long", Field("ttl", "8760h"))
+	w.Info("default")
+	w.AddEntry(LogEntry{Timestamp: time.Now().Add(-48 * time.Hour).Format(timestampLayout), Level: "info", Content: "stale"})
+	flushSync(t, w)
+	time.Sleep(5 * time.Millisecond)
+
+	if err := w.Sweep(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	deletes := db.execs("DELETE")
+	if len(deletes) != 1 || !strings.Contains(deletes[0].sql, "expires_at < NOW() OR (expires_at IS NULL AND timestamp < $1)") {
+		t.Fatalf("DELETE = %v", deletes)
+	}
+	if got := strings.Join(db.contents(), ","); got != "long,default" {
+		t.Errorf("rows after sweep = %s, want long,default", got)
+	}
+}
+
+func TestSweepTTLOnly(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, nil)
+	if err := w.Sweep(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	deletes := db.execs("DELETE")
+	if len(deletes) != 1 || deletes[0].sql != "DELETE FROM logs WHERE expires_at < NOW()" {
+		t.Errorf("DELETE = %v, want only expired rows removed", deletes)
+	}
+}
+
+func TestSweepUsesMaintenanceDB(t *testing.T) {
+	main, maintenance := &mockDB{}, &mockDB{}
+	w := newTestWriter(t, main, &PostgresConfig{
+		Retention:      24 * time.Hour,
+		MaintenanceDB:  maintenance,
+		ErrorTableName: "error_logs",
+	})
+	w.Info("kept")
+	w.Error("failed")
+	flushSync(t, w)
+	if err := w.Sweep(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(main.inserts()); n != 2 {
+		t.Errorf("main executor got %d INSERTs, want 2", n)
+	}
+	if deletes := main.execs("DELETE"); len(deletes) != 0 {
+		t.Errorf("main executor got DELETEs: %s", execSQL(deletes))
+	}
+	deletes := maintenance.execs("DELETE")
+	if len(deletes) != 2 || !strings.Contains(deletes[0].sql, "FROM logs") || !strings.Contains(deletes[1].sql, "FROM error_logs") {
+		t.Errorf("maintenance DELETEs = %s, want one per table", execSQL(deletes))
+	}
+	if n := len(maintenance.inserts()); n != 0 {
+		t.Errorf("maintenance executor got %d INSERTs", n)
+	}
+}
+
+func TestSweepDropsExpiredPartitions(t *testing.T) {
+	now := time.Now()
+	// 分区名到范围上界的映射；logs_live 虽已过保留期，但仍有未到期的 expires_at
+	bounds := map[string]time.Time{
+		"logs_old":     now.Add(-72 * time.Hour),
+		"logs_live":    now.Add(-48 * time.Hour),
+		"logs_current": now.Add(time.Hour),
+	}
+	db := &queryDB{}
+	db.queryFunc = func(sql string, args []any) ([][]any, error) {
+		if strings.Contains(sql, "pg_inherits") {
+			if args[0] != "logs" {
+				t.Errorf("partitions listed for %v, want logs", args[0])
+			}
+			var rows [][]any
+			for _, name := range []string{"logs_old", "logs_live", "logs_current"} {
+				if !bounds[name].After(args[1].(time.Time)) {
+					rows = append(rows, []any{name})
+				}
+			}
+			return rows, nil
+		}
+		if strings.Contains(sql, "FROM logs_live WHERE expires_at >= NOW()") {
+			return [][]any{{now.Add(time.Hour).String()}}, nil
+		}
+		return nil, nil
+	}
+	w := newTestWriter(t, db, &PostgresConfig{Retention: 24 * time.Hour, RetentionDropPartitions: true})
+	if err := w.Sweep(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := execSQL(db.execs("DROP TABLE")); got != "DROP TABLE logs_old" {
+		t.Errorf("dropped = %q, want only logs_old", got)
+	}
+	// 剩余分区中的过期行仍由 DELETE 清理
+	if deletes := db.execs("DELETE"); len(deletes) != 1 {
+		t.Errorf("DELETEs = %s, want one for the remaining partitions", execSQL(deletes))
+	}
+}
+
+func TestSweepUnpartitionedFallsBackToDelete(t *testing.T) {
+	db := &queryDB{}
+	w := newTestWriter(t, db, &PostgresConfig{Retention: 24 * time.Hour, RetentionDropPartitions: true})
+	if err := w.Sweep(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if drops := db.execs("DROP TABLE"); len(drops) != 0 {
+		t.Errorf("unpartitioned table dropped: %s", execSQL(drops))
+	}
+	if deletes := db.execs("DELETE"); len(deletes) != 1 {
+		t.Errorf("DELETEs = %s, want one", execSQL(deletes))
+	}
+}
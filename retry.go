@@ -0,0 +1,103 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// defaultRetryBackoff 默认首次重试前的等待时间
+const defaultRetryBackoff = 100 * time.Millisecond
+
+// sqlStater 返回 PostgreSQL SQLSTATE 错误码的驱动错误（pgx 的 *pgconn.PgError、lib/pq 的 *pq.Error 均实现）
+type sqlStater interface {
+	SQLState() string
+}
+
+// transientErrorMessages 无法取得错误码时，按错误信息判断为临时错误的关键字（小写）
+var transientErrorMessages = []string{
+	"connection refused",
+	"connection reset",
+	"broken pipe",
+	"i/o timeout",
+	"timeout",
+	"too many connections",
+	"the database system is starting up",
+	"the database system is shutting down",
+	"server closed the connection",
+	"conn closed",
+	"bad connection",
+}
+
+// IsRetryableError 默认的重试判定：连接断开、超时、死锁等临时错误返回 true，约束冲突、SQL 语法错误等永久错误返回 false
+// 优先按 SQLSTATE 判断（08 连接异常、40001 序列化失败、40P01 死锁、53 资源不足、57P01–57P03 服务关闭或启动中），其次按网络错误和错误信息判断；无法识别的错误不重试
+func IsRetryableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var stater sqlStater
+	if errors.As(err, &stater) {
+		code := stater.SQLState()
+		switch {
+		case strings.HasPrefix(code, "08"), strings.HasPrefix(code, "53"):
+			return true
+		case code == "40001", code == "40P01", code == "57P01", code == "57P02", code == "57P03":
+			return true
+		case code != "":
+			return false
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, s := range transientErrorMessages {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// insertWithRetry 写入单条日志，临时错误按指数退避重试至多 MaxRetries 次；永久错误立即返回，由调用方转交 Fallback
+func (w *PostgresqlWriter) insertWithRetry(ctx context.Context, entry LogEntry) error {
+	backoff := w.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := w.insertEntry(ctx, w.db, entry)
+		if err == nil || attempt >= w.maxRetries || !w.retryable(err) {
+			return err
+		}
+
+		w.retries.Add(1)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// retryable 使用配置的 RetryClassifier（默认 IsRetryableError）判断错误是否值得重试
+func (w *PostgresqlWriter) retryable(err error) bool {
+	if w.retryClassifier != nil {
+		return w.retryClassifier(err)
+	}
+	return IsRetryableError(err)
+}
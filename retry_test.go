@@ -0,0 +1,95 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// pgError 模拟驱动返回的带 SQLSTATE 的错误
+type pgError struct {
+	code, msg string
+}
+
+func (e *pgError) Error() string    { return e.msg }
+func (e *pgError) SQLState() string { return e.code }
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{context.Canceled, false},
+		{&pgError{"23505", "duplicate key value violates unique constraint"}, false},
+		{&pgError{"42601", "syntax error at or near"}, false},
+		{fmt.Errorf("insert: %w", &pgError{"08006", "connection failure"}), true},
+		{&pgError{"40P01", "deadlock detected"}, true},
+		{&pgError{"53300", "too many clients"}, true},
+		{&pgError{"57P01", "terminating connection due to administrator command"}, true},
+		{fmt.Errorf("write: %w", syscall.ECONNRESET), true},
+		{io.ErrUnexpectedEOF, true},
+		{context.DeadlineExceeded, true},
+		{errors.New("dial tcp: connection refused"), true},
+		{errors.New("value too long for type character varying(20)"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryableError(tt.err); got != tt.want {
+			t.Errorf("IsRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryClassification(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		classifier func(error) bool
+		wantExecs  int64
+		wantRows   int
+	}{
+		{"constraint error is not retried", &pgError{"23505", "duplicate key"}, nil, 1, 0},
+		{"connection error is retried", &pgError{"08006", "connection failure"}, nil, 3, 1},
+		{"classifier override", &pgError{"23505", "duplicate key"}, func(error) bool { return true }, 3, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var execs atomic.Int64
+			db := &mockDB{execFunc: func(ctx context.Context, sql string, args []any) error {
+				if !strings.HasPrefix(sql, "INSERT") {
+					return nil
+				}
+				// 前两次失败，第三次成功
+				if execs.Add(1) <= 2 {
+					return tt.err
+				}
+				return nil
+			}}
+			fallback := &memoryWriter{}
+			w := newTestWriter(t, db, &PostgresConfig{
+				MaxRetries:      3,
+				RetryBackoff:    time.Millisecond,
+				RetryClassifier: tt.classifier,
+				Fallback:        fallback,
+			})
+			w.Info("order placed")
+			n, _ := w.FlushSync()
+
+			if got := execs.Load(); got != tt.wantExecs {
+				t.Errorf("INSERT attempts = %d, want %d", got, tt.wantExecs)
+			}
+			if n != tt.wantRows || len(fallback.all()) != 1-tt.wantRows {
+				t.Errorf("written %d, fallback %d; want %d written", n, len(fallback.all()), tt.wantRows)
+			}
+			if got := w.Stats().Retries; got != tt.wantExecs-1 {
+				t.Errorf("Stats().Retries = %d, want %d", got, tt.wantExecs-1)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package writer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultRingEntries RingConfig 未设置任何上限时保留的条数
+const defaultRingEntries = 1000
+
+// RingConfig 内存环形缓冲 Writer 配置，各上限同时生效，任一超出时淘汰最早的日志
+type RingConfig struct {
+	MaxEntries int              `json:"max_entries"` // 最多保留的条数（0 表示不限制；三个上限都为 0 时默认 1000）
+	MaxBytes   int              `json:"max_bytes"`   // 保留日志的估算总字节数上限（内容、特殊字段及 fields 的文本长度之和，0 表示不限制）
+	MaxAge     time.Duration    `json:"max_age"`     // 最长保留时长，按写入时间计算（0 表示不限制）
+	Now        func() time.Time `json:"-"`           // 当前时间（可选，默认 time.Now，测试时可替换）
+}
+
+// RingWriter 在内存中保留最近的日志，用于 /debug/logs 等诊断页面；与其他 Writer 一起通过 MultiWriter 使用
+// 返回的条目与写入时共享 Fields，调用方只应读取
+type RingWriter struct {
+	maxEntries int
+	maxBytes   int
+	maxAge     time.Duration
+	now        func() time.Time
+
+	mu    sync.Mutex
+	items []ringItem // 按写入时间从早到晚排列
+	bytes int
+}
+
+// ringItem 环形缓冲中的一条日志
+type ringItem struct {
+	entry LogEntry
+	size  int
+	at    time.Time
+}
+
+// NewRingWriter 创建一个内存环形缓冲 Writer
+// config: 配置项（可选，传 nil 时保留最近 1000 条）
+func NewRingWriter(config *RingConfig) *RingWriter {
+	if config == nil {
+		config = &RingConfig{}
+	}
+	r := &RingWriter{
+		maxEntries: config.MaxEntries,
+		maxBytes:   config.MaxBytes,
+		maxAge:     config.MaxAge,
+		now:        config.Now,
+	}
+	if r.maxEntries <= 0 && r.maxBytes <= 0 && r.maxAge <= 0 {
+		r.maxEntries = defaultRingEntries
+	}
+	if r.now == nil {
+		r.now = time.Now
+	}
+	return r
+}
+
+// entrySize 估算日志条目占用的字节数
+func entrySize(entry LogEntry) int {
+	size := len(entry.Timestamp) + len(entry.Level) + len(entry.Content) + len(entry.LogType) +
+		len(entry.Duration) + len(entry.Trace) + len(entry.Span) + len(entry.Username) +
+		len(entry.Component) + len(entry.EntryID) + len(entry.ExpiresAt)
+	for k, v := range entry.Fields {
+		size += len(k) + len(formatFieldValue(v))
+	}
+	return size
+}
+
+// AddEntry 保存一条日志，并淘汰超出上限的旧日志
+func (r *RingWriter) AddEntry(entry LogEntry) {
+	item := ringItem{entry: entry, size: entrySize(entry), at: r.now()}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items = append(r.items, item)
+	r.bytes += item.size
+	r.evictLocked(item.at)
+}
+
+// evictLocked 在已持有锁的情况下从最早的日志开始淘汰，直到满足所有上限
+func (r *RingWriter) evictLocked(now time.Time) {
+	n := 0
+	for n < len(r.items) {
+		item := r.items[n]
+		over := (r.maxEntries > 0 && len(r.items)-n > r.maxEntries) ||
+			(r.maxBytes > 0 && r.bytes > r.maxBytes) ||
+			(r.maxAge > 0 && now.Sub(item.at) > r.maxAge)
+		if !over {
+			break
+		}
+		r.bytes -= item.size
+		n++
+	}
+	if n == 0 {
+		return
+	}
+	// 清空被淘汰的元素，释放对日志内容的引用
+	clear(r.items[:n])
+	r.items = r.items[n:]
+}
+
+// Recent 返回当前保留的全部日志，从早到晚排列
+func (r *RingWriter) Recent() []LogEntry {
+	return r.RecentSince(0)
+}
+
+// RecentSince 返回最近 d 时长内写入的日志，从早到晚排列（d 不大于 0 时返回全部）
+func (r *RingWriter) RecentSince(d time.Duration) []LogEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.evictLocked(now)
+
+	start := 0
+	if d > 0 {
+		cutoff := now.Add(-d)
+		for start < len(r.items) && r.items[start].at.Before(cutoff) {
+			start++
+		}
+	}
+	entries := make([]LogEntry, 0, len(r.items)-start)
+	for _, item := range r.items[start:] {
+		entries = append(entries, item.entry)
+	}
+	return entries
+}
+
+// Len 返回当前保留的日志条数
+func (r *RingWriter) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.evictLocked(r.now())
+	return len(r.items)
+}
+
+// Log 写入日志（核心方法）
+func (r *RingWriter) Log(level string, content any, fields ...LogField) {
+	r.AddEntry(newLogEntry(level, content, fields))
+}
+
+// Info 写入 info 级别日志
+func (r *RingWriter) Info(content any, fields ...LogField) {
+	r.Log("info", content, fields...)
+}
+
+// Error 写入 error 级别日志
+func (r *RingWriter) Error(content any, fields ...LogField) {
+	r.Log("error", content, fields...)
+}
+
+// Debug 写入 debug 级别日志
+func (r *RingWriter) Debug(content any, fields ...LogField) {
+	r.Log("debug", content, fields...)
+}
+
+// Warn 写入 warn 级别日志
+func (r *RingWriter) Warn(content any, fields ...LogField) {
+	r.Log("warn", content, fields...)
+}
+
+// Infof 写入 info 级别格式化日志
+func (r *RingWriter) Infof(format string, args ...any) {
+	r.Log("info", fmt.Sprintf(format, args...))
+}
+
+// Errorf 写入 error 级别格式化日志
+func (r *RingWriter) Errorf(format string, args ...any) {
+	r.Log("error", fmt.Sprintf(format, args...))
+}
+
+// Debugf 写入 debug 级别格式化日志
+func (r *RingWriter) Debugf(format string, args ...any) {
+	r.Log("debug", fmt.Sprintf(format, args...))
+}
+
+// Warnf 写入 warn 级别格式化日志
+func (r *RingWriter) Warnf(format string, args ...any) {
+	r.Log("warn", fmt.Sprintf(format, args...))
+}
+
+// Logf 写入格式化日志
+func (r *RingWriter) Logf(level string, format string, args ...any) {
+	r.Log(level, fmt.Sprintf(format, args...))
+}
+
+// Named 返回带组件名的派生 Writer，与当前 Writer 共享缓冲
+func (r *RingWriter) Named(component string) Writer {
+	return newDerivedWriter(r, component)
+}
+
+// With 返回附加默认字段的派生 Writer，每条日志都会带上这些字段（调用时传入的同名字段优先）
+func (r *RingWriter) With(fields ...LogField) Writer {
+	return newFieldsWriter(r, fields)
+}
+
+// Flush 空操作（日志写入时已保存）
+func (r *RingWriter) Flush() {}
+
+// Close 清空保留的日志
+func (r *RingWriter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.items)
+	r.items = nil
+	r.bytes = 0
+	return nil
+}
@@ -0,0 +1,59 @@
+package writer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// ringContents 返回条目内容，以逗号连接
+func ringContents(entries []LogEntry) string {
+	contents := make([]string, len(entries))
+	for i, e := range entries {
+		contents[i] = e.Content
+	}
+	return strings.Join(contents, ",")
+}
+
+func TestRingWriterEvictsBySize(t *testing.T) {
+	r := NewRingWriter(&RingConfig{MaxBytes: 25})
+	// 每条只有 10 字节的内容，超出 25 字节时淘汰最早的
+	for _, c := range []string{"aaaaaaaaaa", "bbbbbbbbbb", "cccccccccc"} {
+		r.AddEntry(LogEntry{Content: c})
+	}
+	if got := ringContents(r.Recent()); got != "bbbbbbbbbb,cccccccccc" {
+		t.Errorf("Recent = %s, want the last two entries", got)
+	}
+	r.AddEntry(LogEntry{Content: strings.Repeat("x", 30)})
+	if got := r.Len(); got != 0 {
+		t.Errorf("Len = %d after an entry larger than MaxBytes, want 0", got)
+	}
+}
+
+func TestRingWriterEvictsByAge(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewRingWriter(&RingConfig{MaxAge: time.Minute, Now: func() time.Time { return now }})
+
+	r.AddEntry(LogEntry{Content: "old"})
+	now = now.Add(40 * time.Second)
+	r.AddEntry(LogEntry{Content: "mid"})
+	now = now.Add(10 * time.Second)
+	r.AddEntry(LogEntry{Content: "new"})
+
+	if got := ringContents(r.RecentSince(5 * time.Second)); got != "new" {
+		t.Errorf("RecentSince(5s) = %s, want new", got)
+	}
+	if got := ringContents(r.Recent()); got != "old,mid,new" {
+		t.Errorf("Recent = %s, want all three", got)
+	}
+
+	// old 写入已超过 MaxAge，读取时即被淘汰，无需新的写入
+	now = now.Add(15 * time.Second)
+	if got := ringContents(r.Recent()); got != "mid,new" {
+		t.Errorf("Recent after 65s = %s, want mid,new", got)
+	}
+	now = now.Add(time.Hour)
+	if got := r.Len(); got != 0 {
+		t.Errorf("Len = %d after all entries expired, want 0", got)
+	}
+}
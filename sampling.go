@@ -0,0 +1,41 @@
+package writer
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// traceSampler 按 trace 采样：对 trace 做哈希，落在比例内的 trace 保留全部日志，其余全部丢弃
+// 同一 trace 的日志总是得到相同的保留/丢弃结果
+type traceSampler struct {
+	threshold uint64              // 哈希值小于该阈值的 trace 被保留
+	levels    map[string]struct{} // 参与采样的级别
+}
+
+// newTraceSampler 创建 trace 采样器，levels 为空时只对 debug 级别采样
+func newTraceSampler(rate float64, levels []string) *traceSampler {
+	if len(levels) == 0 {
+		levels = []string{"debug"}
+	}
+	s := &traceSampler{
+		threshold: uint64(rate * math.MaxUint64),
+		levels:    make(map[string]struct{}, len(levels)),
+	}
+	for _, level := range levels {
+		s.levels[level] = struct{}{}
+	}
+	return s
+}
+
+// keep 判断是否保留该条目，不参与采样的级别和没有 trace 的条目总是保留
+func (s *traceSampler) keep(entry LogEntry) bool {
+	if entry.Trace == "" {
+		return true
+	}
+	if _, ok := s.levels[entry.Level]; !ok {
+		return true
+	}
+	h := fnv.New64a()
+	h.Write([]byte(entry.Trace))
+	return h.Sum64() < s.threshold
+}
@@ -0,0 +1,69 @@
+package writer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTraceSamplerConsistentPerTrace(t *testing.T) {
+	s := newTraceSampler(0.5, nil)
+	kept := 0
+	for i := 0; i < 1000; i++ {
+		trace := fmt.Sprintf("trace-%d", i)
+		first := s.keep(LogEntry{Level: "debug", Trace: trace, Content: "first"})
+		second := s.keep(LogEntry{Level: "debug", Trace: trace, Content: "second"})
+		if first != second {
+			t.Fatalf("trace %s: decisions differ (%v, %v)", trace, first, second)
+		}
+		if first {
+			kept++
+		}
+	}
+	if kept < 400 || kept > 600 {
+		t.Errorf("kept %d of 1000 traces at rate 0.5", kept)
+	}
+}
+
+func TestTraceSamplerBypass(t *testing.T) {
+	s := newTraceSampler(0.000001, []string{"debug", "info"})
+	if !s.keep(LogEntry{Level: "debug"}) {
+		t.Error("entry without a trace was dropped")
+	}
+	if !s.keep(LogEntry{Level: "error", Trace: "t-1"}) {
+		t.Error("error entry was sampled although only debug and info are")
+	}
+}
+
+func TestTraceSamplingWriter(t *testing.T) {
+	db := &mockDB{}
+	// 缓冲区容纳全部日志，避免中途触发的异步刷新在断言时尚未完成
+	w := newTestWriter(t, db, &PostgresConfig{TraceSampleRate: 0.5, BufferSize: 1000})
+	for i := 0; i < 100; i++ {
+		trace := fmt.Sprintf("trace-%d", i)
+		w.Debug("step 1", Field("trace", trace))
+		w.Debug("step 2", Field("trace", trace))
+	}
+	w.Info("always kept", Field("trace", "trace-0"))
+	flushSync(t, w)
+
+	perTrace := make(map[any]int)
+	info := 0
+	for _, call := range db.inserts() {
+		if argOf(t, w, call, "level") == "debug" {
+			perTrace[argOf(t, w, call, "trace")]++
+		} else {
+			info++
+		}
+	}
+	if info != 1 {
+		t.Errorf("got %d info entries, want 1 (info is not sampled)", info)
+	}
+	for trace, n := range perTrace {
+		if n != 2 {
+			t.Errorf("trace %v kept %d of 2 entries", trace, n)
+		}
+	}
+	if len(perTrace) == 0 || len(perTrace) == 100 {
+		t.Errorf("kept %d of 100 traces, want a sample", len(perTrace))
+	}
+}
@@ -0,0 +1,146 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ErrSchemaMismatch VerifyTable 发现表缺少列或列类型不兼容时返回
+var ErrSchemaMismatch = errors.New("table schema mismatch")
+
+// stringColumnTypes 字符串列可接受的类型（information_schema.columns.udt_name）
+var stringColumnTypes = []string{"varchar", "text"}
+
+// expectedColumns 返回写入器需要的列及可接受的类型（udt_name）
+func (w *PostgresqlWriter) expectedColumns() map[string][]string {
+	columns := map[string][]string{
+		"content":    {"text"},
+		"log_type":   stringColumnTypes,
+		"duration":   stringColumnTypes,
+		"trace":      stringColumnTypes,
+		"span":       stringColumnTypes,
+		"user_id":    {"int8"},
+		"username":   stringColumnTypes,
+		"entry_id":   stringColumnTypes,
+		"expires_at": {"timestamptz"},
+		"component":  stringColumnTypes,
+	}
+
+	// 设置了 ContentMaxLength 时新表为 varchar，已有的 text 列同样兼容
+	if w.contentMaxLength > 0 {
+		columns["content"] = stringColumnTypes
+	}
+
+	switch w.durationStorage {
+	case DurationStorageInterval:
+		columns["duration"] = []string{"interval"}
+	case DurationStorageMilliseconds:
+		columns["duration"] = []string{"float8"}
+	}
+
+	// TimestampType 的取值与 udt_name 一致
+	columns["timestamp"] = []string{string(w.timestampType)}
+
+	if w.levelEnum != "" {
+		columns["level"] = []string{strings.ToLower(w.levelEnum)}
+	} else {
+		columns["level"] = stringColumnTypes
+	}
+
+	switch w.fieldStorage {
+	case FieldStorageHstore:
+		columns["fields"] = []string{"hstore"}
+	case FieldStorageText:
+		columns["fields"] = []string{"text"}
+	default:
+		columns["fields"] = []string{"jsonb"}
+	}
+
+	// bigserial 主键由数据库生成，只有客户端生成的 UUID 需要核对类型
+	if w.primaryKey == PrimaryKeyUUIDv7 {
+		columns["id"] = []string{"uuid"}
+	}
+	if w.auditChain != "" {
+		columns["prev_hash"] = stringColumnTypes
+		columns["hash"] = stringColumnTypes
+	}
+	if w.errorColumn {
+		columns[ErrorKey] = stringColumnTypes
+	}
+	for column := range w.disabledColumns {
+		delete(columns, column)
+	}
+	return columns
+}
+
+// VerifyTable 通过 information_schema.columns 检查日志表（及错误表）是否包含写入所需的列且类型兼容
+// 已有表早于新增列创建且迁移未生效时，写入会在后台失败；启动时调用可以尽早发现。数据库执行器需实现 DBQuerier 接口
+func (w *PostgresqlWriter) VerifyTable(ctx context.Context) error {
+	querier, ok := w.db.(DBQuerier)
+	if !ok {
+		return fmt.Errorf("database executor does not implement DBQuerier")
+	}
+
+	var errs []error
+	for _, table := range w.tables() {
+		if err := w.verifyTable(ctx, querier, table); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// verifyTable 检查单张表，table 可带 schema 前缀（如 audit.app_logs），否则使用 current_schema()
+func (w *PostgresqlWriter) verifyTable(ctx context.Context, querier DBQuerier, table string) error {
+	schemaExpr, args := "current_schema()", []any{}
+	name := table
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		schemaExpr = w.placeholder(1)
+		args = append(args, strings.ToLower(table[:i]))
+		name = table[i+1:]
+	}
+	args = append(args, strings.ToLower(name))
+	query := fmt.Sprintf(`SELECT column_name, udt_name FROM information_schema.columns WHERE table_schema = %s AND table_name = %s`,
+		schemaExpr, w.placeholder(len(args)))
+
+	rows, err := querier.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	actual := make(map[string]string)
+	for rows.Next() {
+		var column, udt string
+		if err := rows.Scan(&column, &udt); err != nil {
+			return fmt.Errorf("failed to read columns of %s: %w", table, err)
+		}
+		actual[column] = udt
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	if len(actual) == 0 {
+		return fmt.Errorf("%w: table %s does not exist", ErrSchemaMismatch, table)
+	}
+
+	var problems []string
+	for column, accepted := range w.expectedColumns() {
+		udt, ok := actual[column]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("missing column %s", column))
+		case !slices.Contains(accepted, udt):
+			problems = append(problems, fmt.Sprintf("column %s has type %s, want %s", column, udt, strings.Join(accepted, " or ")))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("%w: table %s: %s", ErrSchemaMismatch, table, strings.Join(problems, "; "))
+}
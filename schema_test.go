@@ -0,0 +1,63 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// schemaRows 返回日志表的 information_schema 列信息，去掉 drop 中的列，retype 中的列改为指定类型
+func schemaRows(w *PostgresqlWriter, drop []string, retype map[string]string) [][]any {
+	var rows [][]any
+	for column, types := range w.expectedColumns() {
+		if slices.Contains(drop, column) {
+			continue
+		}
+		udt := types[0]
+		if t, ok := retype[column]; ok {
+			udt = t
+		}
+		rows = append(rows, []any{column, udt})
+	}
+	return append(rows, []any{"id", "int8"})
+}
+
+func TestVerifyTable(t *testing.T) {
+	var drop []string
+	var retype map[string]string
+	var w *PostgresqlWriter
+	db := &queryDB{queryFunc: func(sql string, args []any) ([][]any, error) {
+		return schemaRows(w, drop, retype), nil
+	}}
+	w = newTestWriter(t, db, nil)
+
+	if err := w.VerifyTable(context.Background()); err != nil {
+		t.Fatalf("matching schema: %v", err)
+	}
+	if query := db.lastQuery(); !strings.Contains(query.sql, "information_schema.columns") || query.args[0] != "logs" {
+		t.Errorf("query = %s %v", query.sql, query.args)
+	}
+
+	drop, retype = []string{"component"}, map[string]string{"fields": "json"}
+	err := w.VerifyTable(context.Background())
+	if !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("err = %v, want ErrSchemaMismatch", err)
+	}
+	for _, want := range []string{"table logs", "missing column component", "column fields has type json, want jsonb"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want it to mention %q", err, want)
+		}
+	}
+}
+
+func TestVerifyTableOnStart(t *testing.T) {
+	db := &queryDB{queryFunc: func(sql string, args []any) ([][]any, error) {
+		return [][]any{{"id", "int8"}, {"timestamp", "timestamptz"}, {"level", "varchar"}, {"content", "text"}}, nil
+	}}
+	_, err := NewPostgresqlWriter(db, &PostgresConfig{TableName: "logs", VerifyTable: true})
+	if !errors.Is(err, ErrSchemaMismatch) || !strings.Contains(err.Error(), "missing column fields") {
+		t.Errorf("NewPostgresqlWriter = %v, want a missing column error", err)
+	}
+}
@@ -0,0 +1,26 @@
+package writer
+
+import "encoding/json"
+
+// Serializer 日志条目序列化接口，用于非 SQL Writer（如 ElasticWriter）
+// 核心库只提供 JSON 实现，msgpack/CBOR 等格式可由用户通过适配器接入，避免引入额外依赖
+type Serializer interface {
+	Marshal(entry LogEntry) ([]byte, error)
+}
+
+// SerializerFunc 将普通函数适配为 Serializer，便于接入第三方编码库
+// 例如：writer.SerializerFunc(func(e writer.LogEntry) ([]byte, error) { return msgpack.Marshal(e) })
+type SerializerFunc func(entry LogEntry) ([]byte, error)
+
+// Marshal 实现 Serializer 接口
+func (f SerializerFunc) Marshal(entry LogEntry) ([]byte, error) {
+	return f(entry)
+}
+
+// JSONSerializer 默认的 JSON 序列化实现
+type JSONSerializer struct{}
+
+// Marshal 实现 Serializer 接口
+func (JSONSerializer) Marshal(entry LogEntry) ([]byte, error) {
+	return json.Marshal(entry)
+}
@@ -0,0 +1,66 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/gob"
+	"reflect"
+	"testing"
+)
+
+// gobSerializer 测试用的自定义格式：gob 编码后再 base64，保证输出为单行文本
+var gobSerializer = SerializerFunc(func(entry LogEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entry); err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+})
+
+// decodeGob 解码 gobSerializer 的输出
+func decodeGob(t *testing.T, data []byte) LogEntry {
+	t.Helper()
+	raw, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry LogEntry
+	if err := gob.NewDecoder(bytes.NewReader(raw)).Decode(&entry); err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+// sampleEntry 序列化测试使用的条目
+func sampleEntry() LogEntry {
+	uid := int64(42)
+	return LogEntry{
+		Timestamp: "2025-01-02T03:04:05.123456789Z",
+		Level:     "warn",
+		Content:   "disk almost full",
+		Trace:     "abc123",
+		UserID:    &uid,
+		Fields:    map[string]interface{}{"mount": "/var", "free": "3%"},
+	}
+}
+
+func TestSerializerFuncRoundTrip(t *testing.T) {
+	entry := sampleEntry()
+	data, err := gobSerializer.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decodeGob(t, data); !reflect.DeepEqual(got, entry) {
+		t.Errorf("round trip = %+v, want %+v", got, entry)
+	}
+}
+
+func TestJSONSerializerOmitsEmpty(t *testing.T) {
+	data, err := JSONSerializer{}.Marshal(LogEntry{Timestamp: "t", Level: "info", Content: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"@timestamp":"t","level":"info","content":"hi"}`; string(data) != want {
+		t.Errorf("JSON = %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,71 @@
+package writer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// ensureShardTable 按需创建 TableNameFunc 生成的表，成功后缓存，之后同名表不再执行 DDL
+// TableName、ErrorTableName、LevelTables 中的表在 Start 时已创建，直接跳过
+func (w *PostgresqlWriter) ensureShardTable(ctx context.Context, table string) error {
+	if w.tableNameFunc == nil || slices.Contains(w.staticTables, table) {
+		return nil
+	}
+	if _, ok := w.shardTables.Load(table); ok {
+		return nil
+	}
+	if !isValidIdentifier(table) {
+		return fmt.Errorf("invalid table name from TableNameFunc: %q", table)
+	}
+
+	w.shardMux.Lock()
+	defer w.shardMux.Unlock()
+	if _, ok := w.shardTables.Load(table); ok {
+		return nil
+	}
+	if err := w.ensureTable(ctx, table); err != nil {
+		return fmt.Errorf("failed to ensure table %s: %w", table, err)
+	}
+	w.shardTables.Store(table, struct{}{})
+	return nil
+}
+
+// shardTableList 返回已创建的分表（按名称排序），供清理、校验等遍历所有表的操作使用
+func (w *PostgresqlWriter) shardTableList() []string {
+	var tables []string
+	w.shardTables.Range(func(key, _ any) bool {
+		tables = append(tables, key.(string))
+		return true
+	})
+	slices.Sort(tables)
+	return tables
+}
+
+// groupByTable 按目标表原地重排一批条目，使同一张表的条目相邻；表按首次出现的顺序排列，表内保持原有顺序
+func (w *PostgresqlWriter) groupByTable(entries []LogEntry) {
+	tables := make([]string, len(entries))
+	rank := make(map[string]int)
+	for i, entry := range entries {
+		tables[i] = w.tableFor(entry)
+		if _, ok := rank[tables[i]]; !ok {
+			rank[tables[i]] = len(rank)
+		}
+	}
+	if len(rank) <= 1 {
+		return
+	}
+
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return rank[tables[a]] - rank[tables[b]]
+	})
+	grouped := make([]LogEntry, len(entries))
+	for i, j := range order {
+		grouped[i] = entries[j]
+	}
+	copy(entries, grouped)
+}
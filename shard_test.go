@@ -0,0 +1,68 @@
+package writer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// tenantTable 按 tenant_id 字段分表
+func tenantTable(entry LogEntry) string {
+	if id, ok := entry.Fields["tenant_id"]; ok {
+		return fmt.Sprintf("logs_tenant%v", id)
+	}
+	return ""
+}
+
+// insertTable 返回 INSERT 语句的目标表
+func insertTable(sql string) string {
+	table, _, _ := strings.Cut(strings.TrimPrefix(sql, "INSERT INTO "), " ")
+	return table
+}
+
+func TestTableNameFuncRoutesTenants(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{TableNameFunc: tenantTable, MultiRowInsert: true})
+	w.Info("a1", Field("tenant_id", 42))
+	w.Info("b1", Field("tenant_id", 7))
+	w.Info("a2", Field("tenant_id", 42))
+	w.Info("untenanted")
+	flushSync(t, w)
+
+	got := make(map[string]int)
+	for _, call := range db.inserts() {
+		got[insertTable(call.sql)] += len(call.args) / len(w.insertColumns)
+	}
+	want := map[string]int{"logs_tenant42": 2, "logs_tenant7": 1, "logs": 1}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("rows per table = %v, want %v", got, want)
+	}
+	if n := len(db.inserts()); n != 3 {
+		t.Errorf("got %d INSERTs, want one per table", n)
+	}
+
+	// 分表只在首次出现时建表
+	w.Info("a3", Field("tenant_id", 42))
+	flushSync(t, w)
+	creates := execSQL(db.execs("CREATE TABLE"))
+	if n := strings.Count(creates, "logs_tenant42 ("); n != 1 {
+		t.Errorf("logs_tenant42 created %d times:\n%s", n, creates)
+	}
+}
+
+func TestTableNameFuncRejectsInvalidNames(t *testing.T) {
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{TableNameFunc: tenantTable})
+	w.Info("bad", Field("tenant_id", "1; DROP TABLE logs"))
+	w.Info("good", Field("tenant_id", 1))
+	if _, err := w.FlushSync(); err == nil || !strings.Contains(err.Error(), "invalid table name") {
+		t.Errorf("FlushSync error = %v, want invalid table name", err)
+	}
+	if creates := execSQL(db.execs("CREATE TABLE")); strings.Contains(creates, "DROP") {
+		t.Errorf("invalid name reached DDL:\n%s", creates)
+	}
+	inserts := db.inserts()
+	if len(inserts) != 1 || insertTable(inserts[0].sql) != "logs_tenant1" {
+		t.Errorf("inserts = %s, want only the valid tenant", execSQL(inserts))
+	}
+}
@@ -0,0 +1,77 @@
+package writer
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// reopener 支持重新打开输出目标的 Writer（如 FileWriter）
+type reopener interface {
+	Reopen() error
+}
+
+// FlushOnSignal 收到指定信号时刷新 Writer 的缓冲区，并重新打开支持 Reopen 的 Writer（如 FileWriter，配合 logrotate），Writer 继续运行
+// 传入 MultiWriter 时对其中每个 Writer 分别处理；Reopen 失败通过 onError 回调通知（可选）
+// 返回的 stop 函数停止监听并等待正在进行的处理完成；未传入信号时不做任何事
+//
+// 注意：
+//   - 监听期间该信号不再执行默认动作（如 SIGUSR1 默认终止进程），stop 后恢复；同一信号的其他 signal.Notify 监听者同样会收到
+//   - 处理期间到达的多个信号会合并为一次
+//   - 刷新在独立协程中异步触发，返回时日志不一定已写入下游
+func FlushOnSignal(w Writer, onError func(err error), sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		// signal.Notify 不传信号表示监听所有信号，此处视为未配置
+		return func() {}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ch:
+				flushAndReopen(w, onError)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+// flushAndReopen 先重新打开输出目标再刷新，MultiWriter 递归处理其中的每个 Writer（错误带上 Writer 的名称）
+func flushAndReopen(w Writer, onError func(err error)) {
+	if m, ok := w.(*MultiWriter); ok {
+		for i, child := range m.writers {
+			childError := onError
+			if onError != nil {
+				label := m.label(i)
+				childError = func(err error) { onError(fmt.Errorf("%s: %w", label, err)) }
+			}
+			flushAndReopen(child, childError)
+		}
+		return
+	}
+
+	if r, ok := w.(reopener); ok {
+		if err := r.Reopen(); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+	w.Flush()
+}
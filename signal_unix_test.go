@@ -0,0 +1,53 @@
+//go:build unix
+
+package writer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFlushOnSignalFlushesAndReopens(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	rotated := filepath.Join(dir, "app.log.1")
+	file, err := NewFileWriter(&FileConfig{Path: path, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem := &memoryWriter{}
+	stop := FlushOnSignal(NewMultiWriter(file, mem), func(err error) { t.Error(err) }, syscall.SIGUSR1)
+	defer stop()
+
+	file.Info("before rotation")
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "signal flush", func() bool {
+		mem.mu.Lock()
+		defer mem.mu.Unlock()
+		return mem.flushes == 1
+	})
+	// 收到信号后 FileWriter 已重新打开原路径，Writer 仍可继续写入
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("log file not reopened: %v", err)
+	}
+	file.Info("after rotation")
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(fileContents(t, rotated), ","); got != "before rotation" {
+		t.Errorf("rotated file = %s", got)
+	}
+	if got := strings.Join(fileContents(t, path), ","); got != "after rotation" {
+		t.Errorf("new file = %s", got)
+	}
+}
@@ -0,0 +1,149 @@
+package writer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// diskSpill 缓冲区溢出文件：缓冲区超过 SpillThreshold 后的日志以 JSONL 追加到文件末尾，缓冲区有空间时按顺序读回
+// 全部读回后清空文件；Close 时把读取进度保存到 path + ".offset"，下次启动从该位置继续。所有方法由 bufferMux 保护
+type diskSpill struct {
+	path    string
+	file    *os.File
+	readOff int64 // 已读回缓冲区的字节位置
+	size    int64 // 文件当前大小，新条目写在此处
+}
+
+// openSpill 打开（或创建）溢出文件，文件中尚未读回的日志会在启动后优先写入
+func openSpill(path string) (*diskSpill, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spill file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat spill file: %w", err)
+	}
+	offset, err := readReplayOffset(path + replayOffsetSuffix)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if offset > info.Size() {
+		offset = 0
+	}
+	return &diskSpill{path: path, file: file, readOff: offset, size: info.Size()}, nil
+}
+
+// pending 文件中是否还有未读回的日志；为 true 时新日志也写入文件，保证顺序
+func (s *diskSpill) pending() bool {
+	return s.readOff < s.size
+}
+
+// append 将一条日志追加到文件末尾
+func (s *diskSpill) append(entry LogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode spilled entry: %w", err)
+	}
+	data = append(data, '\n')
+	if _, err := s.file.WriteAt(data, s.size); err != nil {
+		return fmt.Errorf("failed to write spill file: %w", err)
+	}
+	s.size += int64(len(data))
+	return nil
+}
+
+// read 按写入顺序读回至多 n 条日志，无法解析的行跳过并在 err 中报告；文件全部读回后清空
+func (s *diskSpill) read(n int) ([]LogEntry, error) {
+	reader := bufio.NewReader(io.NewSectionReader(s.file, s.readOff, s.size-s.readOff))
+	var entries []LogEntry
+	var errs []error
+	for len(entries) < n && s.pending() {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			errs = append(errs, fmt.Errorf("failed to read spill file: %w", readErr))
+			break
+		}
+		lineOffset := s.readOff
+		s.readOff += int64(len(line))
+		if trimmed := strings.TrimSpace(string(line)); trimmed != "" {
+			var entry LogEntry
+			if err := json.Unmarshal([]byte(trimmed), &entry); err != nil {
+				errs = append(errs, fmt.Errorf("skipping malformed spilled entry at offset %d: %w", lineOffset, err))
+			} else {
+				entries = append(entries, entry)
+			}
+		}
+		if readErr != nil {
+			break
+		}
+	}
+
+	if !s.pending() {
+		if err := s.reset(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return entries, errors.Join(errs...)
+}
+
+// reset 文件全部读回后清空文件并删除进度文件
+func (s *diskSpill) reset() error {
+	if err := s.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate spill file: %w", err)
+	}
+	s.readOff, s.size = 0, 0
+	if err := os.Remove(s.path + replayOffsetSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove spill offset file: %w", err)
+	}
+	return nil
+}
+
+// close 保存读取进度并关闭文件，未读回的日志留待下次启动
+func (s *diskSpill) close() error {
+	var errs []error
+	if s.readOff > 0 && s.pending() {
+		errs = append(errs, writeReplayOffset(s.path+replayOffsetSuffix, s.readOff))
+	}
+	errs = append(errs, s.file.Close())
+	return errors.Join(errs...)
+}
+
+// spillLocked 缓冲区已达 SpillThreshold（或文件中仍有未读回的日志）时将条目写入溢出文件，返回是否已写入
+// 写文件失败时条目仍留在内存中，错误由调用方在释放锁后报告
+func (w *PostgresqlWriter) spillLocked(entry LogEntry) (bool, error) {
+	if w.spill == nil || !w.started || (len(w.buffer) < w.spillThreshold && !w.spill.pending()) {
+		return false, nil
+	}
+	if err := w.spill.append(entry); err != nil {
+		return false, err
+	}
+	w.spilled.Add(1)
+	return true, nil
+}
+
+// drainSpill 缓冲区低于 SpillThreshold 时从溢出文件读回日志补满缓冲区，并安排写库协程接续写出
+// 关闭后不再读回，剩余日志保留在文件中
+func (w *PostgresqlWriter) drainSpill() error {
+	if w.spill == nil {
+		return nil
+	}
+	w.bufferMux.Lock()
+	defer w.bufferMux.Unlock()
+	if w.closed || !w.started || !w.spill.pending() || len(w.buffer) >= w.spillThreshold {
+		return nil
+	}
+	entries, err := w.spill.read(w.spillThreshold - len(w.buffer))
+	if len(entries) > 0 {
+		w.buffer = append(w.buffer, entries...)
+		w.pendingFlush = true
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package writer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// insertedContents 返回 INSERT 语句按执行顺序写入的 content
+func insertedContents(t *testing.T, w *PostgresqlWriter, db *mockDB) []string {
+	t.Helper()
+	var contents []string
+	for _, call := range db.inserts() {
+		contents = append(contents, argOf(t, w, call, "content").(string))
+	}
+	return contents
+}
+
+func TestSpillAndDrain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill.jsonl")
+	release := make(chan struct{})
+	db := &mockDB{execFunc: func(ctx context.Context, sql string, args []any) error {
+		if strings.HasPrefix(sql, "INSERT") {
+			<-release
+		}
+		return nil
+	}}
+	w := newTestWriter(t, db, &PostgresConfig{BufferSize: 2, SpillFile: path, SpillThreshold: 4, MaxConcurrentWrites: 1})
+
+	// 写库阻塞期间缓冲区停在 SpillThreshold（另有一批正在写入），其余日志进入溢出文件
+	var want []string
+	for i := 0; i < 20; i++ {
+		msg := fmt.Sprintf("%02d", i)
+		want = append(want, msg)
+		w.Info(msg)
+	}
+	if n := w.BufferLen(); n != 6 {
+		t.Errorf("%d entries in memory while blocked, want the threshold of 4 plus the batch being written", n)
+	}
+	if spilled := w.Stats().Spilled; spilled != 14 {
+		t.Errorf("Spilled = %d, want the other 14 entries on disk", spilled)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+		t.Fatalf("spill file is empty: %v", err)
+	}
+
+	// 写库恢复后溢出的日志按原顺序读回并写入
+	close(release)
+	waitFor(t, "all entries to be written", func() bool {
+		w.Flush()
+		return len(db.inserts()) == 20
+	})
+	if got := insertedContents(t, w, db); !slices.Equal(got, want) {
+		t.Errorf("inserted %v, want %v", got, want)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("spill file not emptied after draining: %v", err)
+	}
+}
+
+func TestSpillFileReplayedOnStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill.jsonl")
+	var lines []string
+	for _, c := range []string{"left over 1", "left over 2"} {
+		data, _ := json.Marshal(LogEntry{Timestamp: "2025-01-01T00:00:00Z", Level: "info", Content: c})
+		lines = append(lines, string(data))
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db := &mockDB{}
+	w := newTestWriter(t, db, &PostgresConfig{SpillFile: path})
+	waitFor(t, "spilled entries to be written", func() bool { return len(db.inserts()) == 2 })
+	if got := insertedContents(t, w, db); !slices.Equal(got, []string{"left over 1", "left over 2"}) {
+		t.Errorf("inserted %v", got)
+	}
+}
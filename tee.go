@@ -0,0 +1,172 @@
+package writer
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultSubscriberBuffer 订阅通道的默认容量
+const defaultSubscriberBuffer = 256
+
+// TeeWriter 将调用原样转发给被包装的 Writer，同时把每条日志的副本推送给订阅者（用于管理后台的实时日志等）
+// 推送不阻塞写日志的调用：订阅者的通道已满时丢弃该条并计数；没有订阅者时只多一次原子读取
+type TeeWriter struct {
+	writer     Writer
+	bufferSize int
+
+	mu      sync.RWMutex
+	subs    map[*subscription]struct{}
+	active  atomic.Int32 // 订阅者数量，无订阅者时跳过构造条目
+	dropped atomic.Int64
+}
+
+// subscription 单个订阅者
+type subscription struct {
+	ch     chan LogEntry
+	closed bool // 由 TeeWriter.mu 保护
+}
+
+// NewTeeWriter 包装 w，bufferSize 为每个订阅通道的容量（不大于 0 时默认 256）
+func NewTeeWriter(w Writer, bufferSize int) *TeeWriter {
+	if bufferSize <= 0 {
+		bufferSize = defaultSubscriberBuffer
+	}
+	return &TeeWriter{
+		writer:     w,
+		bufferSize: bufferSize,
+		subs:       make(map[*subscription]struct{}),
+	}
+}
+
+// Subscribe 订阅经过此 Writer 的日志，返回接收通道和取消订阅函数（可重复调用）
+// 取消订阅或 Close 后通道被关闭；条目的 Fields 与下游 Writer 共享，订阅者只应读取
+func (t *TeeWriter) Subscribe() (<-chan LogEntry, func()) {
+	sub := &subscription{ch: make(chan LogEntry, t.bufferSize)}
+
+	t.mu.Lock()
+	t.subs[sub] = struct{}{}
+	t.active.Add(1)
+	t.mu.Unlock()
+
+	return sub.ch, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.removeLocked(sub)
+	}
+}
+
+// removeLocked 在已持有锁的情况下移除订阅并关闭通道
+func (t *TeeWriter) removeLocked(sub *subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(t.subs, sub)
+	t.active.Add(-1)
+	close(sub.ch)
+}
+
+// Dropped 返回因订阅者消费过慢而丢弃的累计条数（每个订阅者分别计数后累加）
+func (t *TeeWriter) Dropped() int64 {
+	return t.dropped.Load()
+}
+
+// publish 将条目非阻塞地推送给所有订阅者
+func (t *TeeWriter) publish(entry LogEntry) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for sub := range t.subs {
+		select {
+		case sub.ch <- entry:
+		default:
+			t.dropped.Add(1)
+		}
+	}
+}
+
+// Log 写入日志（核心方法）
+func (t *TeeWriter) Log(level string, content any, fields ...LogField) {
+	if t.active.Load() > 0 {
+		t.publish(newLogEntry(level, content, fields))
+	}
+	t.writer.Log(level, content, fields...)
+}
+
+// Info 写入 info 级别日志
+func (t *TeeWriter) Info(content any, fields ...LogField) {
+	t.Log("info", content, fields...)
+}
+
+// Error 写入 error 级别日志
+func (t *TeeWriter) Error(content any, fields ...LogField) {
+	t.Log("error", content, fields...)
+}
+
+// Debug 写入 debug 级别日志
+func (t *TeeWriter) Debug(content any, fields ...LogField) {
+	t.Log("debug", content, fields...)
+}
+
+// Warn 写入 warn 级别日志
+func (t *TeeWriter) Warn(content any, fields ...LogField) {
+	t.Log("warn", content, fields...)
+}
+
+// Infof 写入 info 级别格式化日志
+func (t *TeeWriter) Infof(format string, args ...any) {
+	t.Log("info", fmt.Sprintf(format, args...))
+}
+
+// Errorf 写入 error 级别格式化日志
+func (t *TeeWriter) Errorf(format string, args ...any) {
+	t.Log("error", fmt.Sprintf(format, args...))
+}
+
+// Debugf 写入 debug 级别格式化日志
+func (t *TeeWriter) Debugf(format string, args ...any) {
+	t.Log("debug", fmt.Sprintf(format, args...))
+}
+
+// Warnf 写入 warn 级别格式化日志
+func (t *TeeWriter) Warnf(format string, args ...any) {
+	t.Log("warn", fmt.Sprintf(format, args...))
+}
+
+// Logf 写入格式化日志
+func (t *TeeWriter) Logf(level string, format string, args ...any) {
+	t.Log(level, fmt.Sprintf(format, args...))
+}
+
+// AddEntry 推送并提交日志条目
+func (t *TeeWriter) AddEntry(entry LogEntry) {
+	if t.active.Load() > 0 {
+		t.publish(entry)
+	}
+	t.writer.AddEntry(entry)
+}
+
+// Named 返回带组件名的派生 Writer，派生 Writer 的日志同样推送给订阅者
+func (t *TeeWriter) Named(component string) Writer {
+	return newDerivedWriter(t, component)
+}
+
+// With 返回附加默认字段的派生 Writer，派生 Writer 的日志同样推送给订阅者
+func (t *TeeWriter) With(fields ...LogField) Writer {
+	return newFieldsWriter(t, fields)
+}
+
+// Flush 刷新被包装的 Writer
+func (t *TeeWriter) Flush() {
+	t.writer.Flush()
+}
+
+// Close 关闭所有订阅通道，再关闭被包装的 Writer
+func (t *TeeWriter) Close() error {
+	t.mu.Lock()
+	for sub := range t.subs {
+		t.removeLocked(sub)
+	}
+	t.mu.Unlock()
+	return t.writer.Close()
+}
@@ -0,0 +1,61 @@
+package writer
+
+import "testing"
+
+func TestTeeWriterSubscribeReceiveUnsubscribe(t *testing.T) {
+	mem := &memoryWriter{}
+	tee := NewTeeWriter(mem, 4)
+	ch, unsubscribe := tee.Subscribe()
+
+	tee.Info("hello", Field("k", "v"))
+	select {
+	case entry := <-ch:
+		if entry.Level != "info" || entry.Content != "hello" || entry.Fields["k"] != "v" {
+			t.Errorf("received %+v", entry)
+		}
+	default:
+		t.Fatal("subscriber received nothing")
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if _, ok := <-ch; ok {
+		t.Error("channel still open after unsubscribe")
+	}
+	tee.Info("after")
+	if got := len(mem.all()); got != 2 {
+		t.Errorf("wrapped writer got %d entries, want 2", got)
+	}
+	if got := tee.Dropped(); got != 0 {
+		t.Errorf("Dropped = %d after unsubscribe, want 0", got)
+	}
+}
+
+func TestTeeWriterDropsForSlowConsumer(t *testing.T) {
+	mem := &memoryWriter{}
+	tee := NewTeeWriter(mem, 2)
+	slow, _ := tee.Subscribe()
+	fast, _ := tee.Subscribe()
+
+	// 无人读取 slow 时写日志不阻塞，超出通道容量的条目被丢弃并计数
+	for i := 0; i < 5; i++ {
+		tee.Info("msg")
+		<-fast
+	}
+	if got := len(slow); got != 2 {
+		t.Errorf("slow subscriber buffered %d entries, want 2", got)
+	}
+	if got := tee.Dropped(); got != 3 {
+		t.Errorf("Dropped = %d, want 3", got)
+	}
+	if got := len(mem.all()); got != 5 {
+		t.Errorf("wrapped writer got %d entries, want 5", got)
+	}
+
+	tee.Close()
+	for range slow {
+	}
+	if _, ok := <-fast; ok {
+		t.Error("channel still open after Close")
+	}
+}
@@ -0,0 +1,145 @@
+package writer
+
+import (
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// callWithTimeout 在新协程中执行 fn，最多等待 timeout，超时返回 true
+// stalled 标记上一次调用仍未返回：此时直接跳过本次调用（返回 false），避免在卡住的 Writer 上堆积协程
+func callWithTimeout(timeout time.Duration, stalled *atomic.Bool, fn func()) bool {
+	if !stalled.CompareAndSwap(false, true) {
+		return false
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer stalled.Store(false)
+		fn()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// TimeoutWriter 为任意 Writer 的每次调用加上超时，超时后放弃等待并通过回调返回 ErrWriterTimeout
+// 被包装的 Writer 在超时调用返回之前收到的日志会被丢弃；Close 不受超时限制
+type TimeoutWriter struct {
+	writer  Writer
+	timeout time.Duration
+	onError func(err error)
+	stalled atomic.Bool
+}
+
+// NewTimeoutWriter 包装 w，每次调用最多等待 timeout（不大于 0 时直接调用，不加超时）
+// onError: 超时回调（可选）
+func NewTimeoutWriter(w Writer, timeout time.Duration, onError func(err error)) *TimeoutWriter {
+	return &TimeoutWriter{writer: w, timeout: timeout, onError: onError}
+}
+
+// call 带超时执行 fn
+func (t *TimeoutWriter) call(fn func(w Writer)) {
+	if t.timeout <= 0 {
+		fn(t.writer)
+		return
+	}
+	if callWithTimeout(t.timeout, &t.stalled, func() { fn(t.writer) }) && t.onError != nil {
+		t.onError(fmt.Errorf("writer (%T) exceeded %s: %w", t.writer, t.timeout, ErrWriterTimeout))
+	}
+}
+
+// detach 复制字段切片：超时的调用在返回后仍可能读取字段，不能与调用方共享
+func (t *TimeoutWriter) detach(fields []LogField) []LogField {
+	if t.timeout <= 0 {
+		return fields
+	}
+	return slices.Clone(fields)
+}
+
+// Log 写入日志（核心方法）
+func (t *TimeoutWriter) Log(level string, content any, fields ...LogField) {
+	fields = t.detach(fields)
+	t.call(func(w Writer) { w.Log(level, content, fields...) })
+}
+
+// Info 写入 info 级别日志
+func (t *TimeoutWriter) Info(content any, fields ...LogField) {
+	fields = t.detach(fields)
+	t.call(func(w Writer) { w.Info(content, fields...) })
+}
+
+// Error 写入 error 级别日志
+func (t *TimeoutWriter) Error(content any, fields ...LogField) {
+	fields = t.detach(fields)
+	t.call(func(w Writer) { w.Error(content, fields...) })
+}
+
+// Debug 写入 debug 级别日志
+func (t *TimeoutWriter) Debug(content any, fields ...LogField) {
+	fields = t.detach(fields)
+	t.call(func(w Writer) { w.Debug(content, fields...) })
+}
+
+// Warn 写入 warn 级别日志
+func (t *TimeoutWriter) Warn(content any, fields ...LogField) {
+	fields = t.detach(fields)
+	t.call(func(w Writer) { w.Warn(content, fields...) })
+}
+
+// Infof 写入 info 级别格式化日志
+func (t *TimeoutWriter) Infof(format string, args ...any) {
+	t.Log("info", fmt.Sprintf(format, args...))
+}
+
+// Errorf 写入 error 级别格式化日志
+func (t *TimeoutWriter) Errorf(format string, args ...any) {
+	t.Log("error", fmt.Sprintf(format, args...))
+}
+
+// Debugf 写入 debug 级别格式化日志
+func (t *TimeoutWriter) Debugf(format string, args ...any) {
+	t.Log("debug", fmt.Sprintf(format, args...))
+}
+
+// Warnf 写入 warn 级别格式化日志
+func (t *TimeoutWriter) Warnf(format string, args ...any) {
+	t.Log("warn", fmt.Sprintf(format, args...))
+}
+
+// Logf 写入格式化日志
+func (t *TimeoutWriter) Logf(level string, format string, args ...any) {
+	t.Log(level, fmt.Sprintf(format, args...))
+}
+
+// AddEntry 提交日志条目
+func (t *TimeoutWriter) AddEntry(entry LogEntry) {
+	t.call(func(w Writer) { w.AddEntry(entry) })
+}
+
+// Named 返回带组件名的派生 Writer，派生 Writer 的调用同样受超时限制
+func (t *TimeoutWriter) Named(component string) Writer {
+	return newDerivedWriter(t, component)
+}
+
+// With 返回附加默认字段的派生 Writer，派生 Writer 的调用同样受超时限制
+func (t *TimeoutWriter) With(fields ...LogField) Writer {
+	return newFieldsWriter(t, fields)
+}
+
+// Flush 刷新被包装的 Writer（受超时限制）
+func (t *TimeoutWriter) Flush() {
+	t.call(func(w Writer) { w.Flush() })
+}
+
+// Close 关闭被包装的 Writer，等待其完成
+func (t *TimeoutWriter) Close() error {
+	return t.writer.Close()
+}
@@ -0,0 +1,46 @@
+package writer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimeoutWriterSlowWrapped(t *testing.T) {
+	slow := &slowWriter{release: make(chan struct{})}
+	var mu sync.Mutex
+	var errs []error
+	w := NewTimeoutWriter(slow, 20*time.Millisecond, func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	})
+
+	start := time.Now()
+	w.Info("stuck")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Info blocked for %v, want about the timeout", elapsed)
+	}
+	// 上一次调用仍未返回：直接跳过，不再堆积协程
+	w.Info("skipped")
+
+	mu.Lock()
+	if len(errs) != 1 || !errors.Is(errs[0], ErrWriterTimeout) {
+		t.Errorf("errors = %v, want one ErrWriterTimeout", errs)
+	}
+	mu.Unlock()
+
+	close(slow.release)
+	waitFor(t, "stalled call to return", func() bool { return !w.stalled.Load() })
+	w.Info("fast")
+	entries := slow.all()
+	if len(entries) != 2 || entries[0].Content != "stuck" || entries[1].Content != "fast" {
+		t.Errorf("entries = %+v, want stuck then fast", entries)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 1 {
+		t.Errorf("fast call reported %v", errs[1:])
+	}
+}
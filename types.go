@@ -2,9 +2,33 @@ package writer
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"regexp"
 	"time"
 )
 
+// ErrWriterClosed 写入器已关闭后仍写入日志时返回（通过 OnError 回调通知）
+var ErrWriterClosed = errors.New("writer is closed")
+
+// ErrWriterNotStarted 开启 DeferStart 后尚未调用 Start 时同步写入日志返回，未启动就关闭时随丢弃的条数通过 OnError 回调通知
+var ErrWriterNotStarted = errors.New("writer is not started")
+
+// ErrWriterStarted 重复调用 Start 时返回
+var ErrWriterStarted = errors.New("writer is already started")
+
+// ErrBufferBackedUp 待写入日志持续超过高水位时返回（通过 OnError 回调通知）
+var ErrBufferBackedUp = errors.New("log buffer is backing up")
+
+// ErrBatchRejected ValidateBatch 拒绝一批日志时返回（通过 OnError 回调通知），该批日志转交 Fallback
+var ErrBatchRejected = errors.New("log batch rejected by validation")
+
+// ErrUnknownLevel 级别未知且按 UnknownLevelPolicy 被丢弃（或 warn 策略下首次出现）时返回（通过 OnError 回调通知）
+var ErrUnknownLevel = errors.New("unknown log level")
+
+// ErrWriteDeadline 单批写库超过 WriteTimeout 时返回（通过 OnError 回调通知），该批中尚未写入的日志整体转交 Fallback
+var ErrWriteDeadline = errors.New("log batch write deadline exceeded")
+
 // DBExecutor 数据库执行器接口，用于抽象数据库操作
 // 用户可以使用任意 PostgreSQL 驱动（pgx, pq 等）实现此接口
 type DBExecutor interface {
@@ -43,32 +67,411 @@ func Field(key string, value any) LogField {
 	return LogField{Key: key, Value: value}
 }
 
+// PayloadKey Payload 字段的键名
+const PayloadKey = "payload"
+
+// Payload 创建结构化负载字段：content 只保存可搜索的消息，结构化数据作为整体保存在 fields.payload 中
+// 如 w.Info("user login", Payload(req))；JSON 存储下为嵌套对象（可用 fields->'payload'->>'ip' 查询），hstore 存储下为 JSON 文本
+func Payload(v any) LogField {
+	return LogField{Key: PayloadKey, Value: v}
+}
+
+// ErrorKey WithError 字段的键名
+const ErrorKey = "error"
+
+// WithError 将错误作为结构化字段附加到日志上，如 w.Error("failed", WithError(err))
+// 写入时保存为错误消息（fields.error，或开启 ErrorColumn 时的 error 列），开启 CaptureErrorChain 时同样展开错误链；err 为 nil 时值为 null
+func WithError(err error) LogField {
+	if err == nil {
+		return LogField{Key: ErrorKey}
+	}
+	return LogField{Key: ErrorKey, Value: err}
+}
+
+// timestampLayout LogEntry 中时间字段的格式（RFC3339，保留纳秒精度）
+const timestampLayout = time.RFC3339Nano
+
+// defaultMaxConcurrentWrites 默认同时写库的批次数上限
+const defaultMaxConcurrentWrites = 2
+
+// defaultWriteTimeout 默认单批写库（以及 LogSync）的超时
+const defaultWriteTimeout = 30 * time.Second
+
+// TimestampType timestamp 列类型
+type TimestampType string
+
+const (
+	// TimestampTypeTZ 使用 TIMESTAMPTZ（默认）
+	TimestampTypeTZ TimestampType = "timestamptz"
+	// TimestampTypeNoTZ 使用不带时区的 TIMESTAMP，建议配合 UseUTC 使用
+	TimestampTypeNoTZ TimestampType = "timestamp"
+)
+
+// PrimaryKeyType 日志表主键类型
+type PrimaryKeyType string
+
+const (
+	// PrimaryKeyBigSerial 使用数据库自增的 BIGSERIAL（默认）
+	PrimaryKeyBigSerial PrimaryKeyType = "bigserial"
+	// PrimaryKeyUUIDv7 使用客户端生成的按时间排序的 UUID（v7），适合分片或多写入端，且不暴露日志量
+	PrimaryKeyUUIDv7 PrimaryKeyType = "uuid_v7"
+)
+
 // LogEntry 表示一条日志条目
 type LogEntry struct {
 	Timestamp string                 `json:"@timestamp"`
+	EntryID   string                 `json:"entry_id,omitempty"` // 日志条目唯一标识（配置 IDGenerator 时生成）
 	Level     string                 `json:"level"`
 	Content   string                 `json:"content"`
 	LogType   string                 `json:"log_type,omitempty"` // 日志类型：user（用户）、system（系统）等
 	Duration  string                 `json:"duration,omitempty"`
 	Trace     string                 `json:"trace,omitempty"`
 	Span      string                 `json:"span,omitempty"`
-	UserID    *int64                 `json:"user_id,omitempty"`  // 用户ID（可选）
-	Username  string                 `json:"username,omitempty"` // 用户名（可选）
+	UserID    *int64                 `json:"user_id,omitempty"`    // 用户ID（可选）
+	Username  string                 `json:"username,omitempty"`   // 用户名（可选）
+	Component string                 `json:"component,omitempty"`  // 组件名（通过 Named 设置，可选）
+	ExpiresAt string                 `json:"expires_at,omitempty"` // 过期时间（RFC3339，通过 ttl 字段设置，可选）
 	Fields    map[string]interface{} `json:"fields,omitempty"`
+	PrevHash  string                 `json:"prev_hash,omitempty"` // 审计哈希链中上一条的 hash（开启 AuditChain 时生成）
+	Hash      string                 `json:"hash,omitempty"`      // 审计哈希链中本条的 hash
 }
 
+// FieldStorage fields 列的存储类型
+type FieldStorage string
+
+const (
+	// FieldStorageJSONB 使用 JSONB 存储（默认）
+	FieldStorageJSONB FieldStorage = "jsonb"
+	// FieldStorageHstore 使用 hstore 存储，非字符串值会被转换为字符串
+	FieldStorageHstore FieldStorage = "hstore"
+	// FieldStorageText 使用 TEXT 存储 JSON 文本
+	FieldStorageText FieldStorage = "text"
+)
+
+// DurationStorage duration 列的存储类型
+type DurationStorage string
+
+const (
+	// DurationStorageText 使用 VARCHAR(50) 原样存储（默认）
+	DurationStorageText DurationStorage = "text"
+	// DurationStorageInterval 使用 INTERVAL 存储（精确到微秒），可直接用 avg、percentile_cont 等聚合
+	DurationStorageInterval DurationStorage = "interval"
+	// DurationStorageMilliseconds 使用 DOUBLE PRECISION 存储毫秒数
+	DurationStorageMilliseconds DurationStorage = "milliseconds"
+)
+
+// PlaceholderStyle SQL 参数占位符风格
+type PlaceholderStyle string
+
+const (
+	// PlaceholderDollar 使用 $1, $2 ... 占位符（PostgreSQL，默认）
+	PlaceholderDollar PlaceholderStyle = "dollar"
+	// PlaceholderQuestion 使用 ? 占位符（MySQL 风格的驱动）
+	PlaceholderQuestion PlaceholderStyle = "question"
+)
+
+// EmptyContentMode 空内容（nil 或空字符串）日志的处理方式
+type EmptyContentMode string
+
+const (
+	// EmptyContentKeep 原样写入（默认）
+	EmptyContentKeep EmptyContentMode = "keep"
+	// EmptyContentSkip 丢弃该条日志
+	EmptyContentSkip EmptyContentMode = "skip"
+	// EmptyContentPlaceholder 以 EmptyContentPlaceholder 替换内容
+	EmptyContentPlaceholder EmptyContentMode = "placeholder"
+)
+
+// SpecialKeyMode 特殊字段名（trace、span、duration、log_type、user_id、username、component、ttl 等）的处理方式
+type SpecialKeyMode string
+
+const (
+	// SpecialKeysExtract 提取到对应的列，不出现在 fields 中（默认）
+	SpecialKeysExtract SpecialKeyMode = "extract"
+	// SpecialKeysWarn 照常提取，但值的类型与列不符时（多半是想作为普通字段）每个键通过 OnError 报告一次 ErrSpecialKeyType
+	SpecialKeysWarn SpecialKeyMode = "warn"
+	// SpecialKeysOff 不提取，特殊字段名与普通字段一样写入 fields
+	SpecialKeysOff SpecialKeyMode = "off"
+)
+
+// ErrSpecialKeyType SpecialKeysWarn 模式下特殊字段的值类型与对应列不符时返回（通过 OnError 回调通知）
+var ErrSpecialKeyType = errors.New("unexpected value type for special field")
+
+// EscalationRule 日志级别升级规则：内容匹配 Pattern 和/或字段匹配 Field 时，将级别改为 Level
+// Pattern 与 Field 同时设置时需同时满足；FieldValue 为空时只要求字段存在
+type EscalationRule struct {
+	Pattern    *regexp.Regexp `json:"-"`           // 匹配日志内容的正则（可选）
+	Field      string         `json:"field"`       // 匹配的字段名（可选）
+	FieldValue string         `json:"field_value"` // 字段值（按 %v 格式化后比较，可选）
+	Level      string         `json:"level"`       // 升级后的级别，如 severe
+}
+
+// matches 判断规则是否匹配该条日志
+func (r EscalationRule) matches(content string, fields []LogField) bool {
+	if r.Pattern == nil && r.Field == "" {
+		return false
+	}
+	if r.Pattern != nil && !r.Pattern.MatchString(content) {
+		return false
+	}
+	if r.Field == "" {
+		return true
+	}
+	for _, f := range fields {
+		if f.Key == r.Field && (r.FieldValue == "" || fmt.Sprintf("%v", f.Value) == r.FieldValue) {
+			return true
+		}
+	}
+	return false
+}
+
+// escalateLevel 按顺序应用升级规则，返回第一条匹配规则的级别，没有匹配时返回原级别
+func escalateLevel(rules []EscalationRule, level, content string, fields []LogField) string {
+	for _, rule := range rules {
+		if rule.Level != "" && rule.matches(content, fields) {
+			return rule.Level
+		}
+	}
+	return level
+}
+
+// FieldOverflowMode 字段超过字节上限时的处理方式
+type FieldOverflowMode string
+
+const (
+	// FieldOverflowTruncate 截断超长字段（非字符串值先序列化为 JSON 文本再截断，默认）
+	FieldOverflowTruncate FieldOverflowMode = "truncate"
+	// FieldOverflowDrop 丢弃超长字段
+	FieldOverflowDrop FieldOverflowMode = "drop"
+)
+
+// 嵌套字段展开的默认值
+const (
+	defaultFlattenSeparator = "."
+	defaultFlattenMaxDepth  = 10
+)
+
+// fieldOverflowMarker 记录被截断或丢弃的字段名的标记字段
+const fieldOverflowMarker = "_truncated_fields"
+
+// defaultEmptyContentPlaceholder 空内容的默认替换文本
+const defaultEmptyContentPlaceholder = "(empty)"
+
 // PostgresConfig Postgresql Writer 配置
 type PostgresConfig struct {
-	TableName     string        `json:"table_name"`     // 表名
-	BufferSize    int           `json:"buffer_size"`    // 缓冲区大小
-	FlushInterval time.Duration `json:"flush_interval"` // 刷新间隔
+	Disabled       bool   `json:"disabled"`         // 禁用写入器：所有日志被直接丢弃，且不访问数据库
+	TableName      string `json:"table_name"`       // 表名
+	ErrorTableName string `json:"error_table_name"` // 错误日志表名（可选），error/alert/severe/stack 级别写入此表
+	// LevelTables 按级别指定目标表（可选），如 {"debug": "logs_debug", "error": "logs_durable"}，优先于 ErrorTableName；
+	// 未列出的级别按 TableName/ErrorTableName 写入，所有表在 Start 时创建
+	LevelTables map[string]string `json:"level_tables"`
+	// TableNameFunc 按条目计算目标表（可选），如按 tenant_id 字段返回 logs_tenant42；返回空字符串时按 LevelTables/ErrorTableName/TableName 写入
+	// 表名须为合法标识符，首次写入时自动建表并缓存；在写库协程中对每条日志调用多次，应保持确定且轻量
+	TableNameFunc    func(entry LogEntry) string `json:"-"`
+	BufferSize       int                         `json:"buffer_size"`       // 缓冲区大小
+	FlushInterval    time.Duration               `json:"flush_interval"`    // 刷新间隔
+	FieldStorage     FieldStorage                `json:"field_storage"`     // fields 列存储类型：jsonb（默认）、hstore、text
+	PlaceholderStyle PlaceholderStyle            `json:"placeholder_style"` // 参数占位符风格：dollar（默认）、question
+	TimestampType    TimestampType               `json:"timestamp_type"`    // timestamp 列类型：timestamptz（默认）、timestamp
+	PrimaryKey       PrimaryKeyType              `json:"primary_key"`       // 主键类型：bigserial（默认）、uuid_v7（只影响新建的表，已有表的 id 列类型不会修改）
+
+	// DisabledColumns 关闭不使用的可选列：建表时省略、INSERT 中不写入，对应属性被丢弃
+	// 可选列：log_type、duration、trace、span、user_id、username、fields、entry_id、expires_at、component（timestamp、level、content 始终保留）
+	// 已有表中的列不会被删除；按已关闭的列过滤查询时返回错误，关闭 expires_at 后 ttl 字段不再生效
+	DisabledColumns []string `json:"disabled_columns"`
+
+	// ContentMaxLength content 列使用 VARCHAR(n)，写入前将内容截断到 n 个字符（0 表示使用不限长度的 TEXT）
+	// 只影响新建的表；已有表的 content 列类型不会修改，但截断同样生效
+	ContentMaxLength int `json:"content_max_length"`
+
+	// DurationStorage duration 列存储类型：text（默认）、interval、milliseconds；只影响新建的表
+	// 非 text 时写入前解析 duration：支持 time.ParseDuration 格式（如 150ms、1.5s，允许数字与单位间有空格），纯数字按毫秒处理；
+	// 无法解析的值该列写入 NULL，原值保存在 fields 的 duration 键中
+	DurationStorage DurationStorage `json:"duration_storage"`
+
+	// AuditChain 审计哈希链：每条日志的 hash 列覆盖其内容和上一条的 hash（prev_hash 列），修改或删除条目可通过 VerifyAuditChain 发现
+	// audit 只链接 log_type 为 audit 的日志，all 链接所有日志（为空表示不开启）；链尾保存在内存中，启动时从表中恢复（需实现 DBQuerier）
+	// 只覆盖经缓冲区写入的日志（LogTx/LogSync 不参与），同一张表应只有一个写入器实例开启此选项
+	AuditChain AuditChainMode `json:"audit_chain"`
+
+	// VerifyTable 创建时调用 VerifyTable 检查表结构，缺少列或类型不兼容时返回错误而不是在后台静默写入失败（需实现 DBQuerier）
+	VerifyTable bool                         `json:"verify_table"`
+	UseUTC      bool                         `json:"use_utc"` // 写入前将时间转换为 UTC
+	Fallback    Writer                       `json:"-"`       // 写库失败的日志转交的备用 Writer（可选，如 ConsoleWriter 或 FileWriter），错误仍通过 OnError 通知；其生命周期由调用方管理
+	OnFlush     func(n int, d time.Duration) `json:"-"`       // 每批写入完成后的回调（可选），n 为成功写入的条数，d 为写入耗时；部分失败时 n 为成功条数，错误另通过 OnError 通知
+	OnError     func(err error)              `json:"-"`       // 写入失败回调（可选）
+	// BeforeWrite 写入前的钩子（可选）：在字段提取之后、进入缓冲区（及采样、限流）之前执行，可原地修改条目（补充字段、脱敏、调整 log_type 等），返回 false 丢弃该条日志
+	// 对 AddEntry、LogTx、LogSync 同样生效；在调用方协程中同步执行，应保持轻量
+	BeforeWrite func(entry *LogEntry) bool `json:"-"`
+	// ValidateBatch 每批写库前的校验钩子（可选），可检查或原地修改条目；返回错误时整批不写入，转交 Fallback，并通过 OnError 返回包含 ErrBatchRejected 的错误
+	// 在后台写库协程中执行（FlushSync 时在调用方协程）；Replay、LogTx、LogSync 不经过此钩子
+	ValidateBatch func(entries []LogEntry) error `json:"-"`
+	FieldKeyFunc  func(string) string            `json:"-"` // 字段名规范化函数（可选），如 SnakeCaseKey；特殊字段识别基于规范化后的名称
+	// SpecialKeys 特殊字段名的处理方式：extract（默认）、warn、off
+	// off 模式下 Named 的组件名、log_type、ttl 等也只写入 fields，对应的列保持为空（DefaultLogType 仍然生效）
+	SpecialKeys             SpecialKeyMode   `json:"special_keys"`
+	IDGenerator             func() string    `json:"-"`                         // 日志条目 ID 生成函数（可选），如 NewUUID，结果写入 entry_id 列
+	ParseContentFields      bool             `json:"parse_content_fields"`      // 从内容中提取 key=value 片段写入 fields（不覆盖显式字段，不修改内容）
+	EmptyContent            EmptyContentMode `json:"empty_content"`             // 空内容日志的处理方式：keep（默认）、skip、placeholder；skip、placeholder 下 nil 内容同样视为空内容
+	NilContent              string           `json:"nil_content"`               // nil 内容的替换文本（默认为空字符串，即 nil 按空内容处理；设置后优先于 EmptyContent 对 nil 的处理）
+	MaxReaderBytes          int64            `json:"max_reader_bytes"`          // 内容为 io.Reader 时最多读取的字节数（默认 64KB），超出部分截断
+	EmptyContentPlaceholder string           `json:"empty_content_placeholder"` // placeholder 模式下的替换文本（默认 "(empty)"）
+	TrimTrailingSpace       bool             `json:"trim_trailing_space"`       // 去掉内容末尾的空白和换行（内容中间的换行保留），先于 EmptyContent 处理
+	// MinLevel 最低记录级别：debug < info（及 slow、stat 等自定义级别）< warn < error < alert/severe/stack（为空表示全部记录）
+	// 低于该级别的日志在 Log 入口直接返回，不加锁、不格式化（先于 EscalationRules 判断）
+	MinLevel string `json:"min_level"`
+
+	// EscalationRules 级别升级规则，在写入缓冲区前按顺序匹配，第一条匹配的规则决定级别（升级为 error 类级别的日志会写入 ErrorTableName）
+	// 例如 {Pattern: regexp.MustCompile(`panic|OOM`), Level: "severe"}
+	EscalationRules []EscalationRule `json:"escalation_rules"`
+
+	// 字段大小上限：按 JSON 序列化后的字节数计算，超出的字段按 FieldOverflow 截断或丢弃，并在 _truncated_fields 中记录字段名
+	MaxFieldBytes  int               `json:"max_field_bytes"`  // 单个字段值的字节上限（0 表示不限制）
+	MaxFieldsBytes int               `json:"max_fields_bytes"` // 全部字段的字节上限，按字段名顺序累计（0 表示不限制）
+	FieldOverflow  FieldOverflowMode `json:"field_overflow"`   // 超限处理方式：truncate（默认）或 drop
+
+	// 嵌套字段展开：将值为 map 的字段展开为带分隔符的键（如 {"a":{"b":1}} → a.b=1），便于查询和建索引
+	FlattenFields    bool   `json:"flatten_fields"`    // 是否展开嵌套 map
+	FlattenSeparator string `json:"flatten_separator"` // 键名分隔符（默认 "."）
+	FlattenMaxDepth  int    `json:"flatten_max_depth"` // 最多展开的层数，更深的 map 保持原样（默认 10）
+
+	SanitizeStrings    bool `json:"sanitize_strings"`     // 写入前去除内容和字段中的 NUL 字节、将非法 UTF-8 替换为 U+FFFD（PostgreSQL 的 text/JSONB 会拒绝这类字符串，导致该条日志写入失败）
+	IncludeGoroutineID bool `json:"include_goroutine_id"` // 在 fields 中记录写日志的协程 ID（goroutine 字段），需解析调用栈，有一定开销
+	IncludeCaller      bool `json:"include_caller"`       // 在 fields 中记录包外调用位置（caller 字段，如 main.go:42），需遍历调用栈
+	CallerFunction     bool `json:"caller_function"`      // caller 字段中包含函数名（如 main.handleLogin main.go:42），需额外解析符号
+	CaptureErrorChain  bool `json:"capture_error_chain"`  // content 为 error（或带有 WithError 字段）时，将展开的错误链写入 fields.error_chain
+	ErrorColumn        bool `json:"error_column"`         // WithError 的错误消息写入独立的 error TEXT 列而不是 fields.error（已有表启动时自动添加该列，查询时合并回 fields）
+
+	// 限流：超出速率的日志被丢弃，恢复后写入一条 "N logs suppressed" 汇总日志
+	RateLimit             float64 `json:"rate_limit"`               // 每秒允许写入的日志条数（0 表示不限流）
+	RateBurst             int     `json:"rate_burst"`               // 允许的突发条数（默认等于 RateLimit）
+	RateLimitExemptErrors bool    `json:"rate_limit_exempt_errors"` // error/alert/severe/stack 级别不受限流影响
+
+	// trace 采样：按 trace 哈希保留一部分 trace 的全部日志，同一 trace 的日志同时保留或丢弃；没有 trace 的日志不参与采样
+	TraceSampleRate   float64  `json:"trace_sample_rate"`   // 保留的 trace 比例，取值 (0, 1)（0 或 ≥1 表示不采样）
+	TraceSampleLevels []string `json:"trace_sample_levels"` // 参与采样的级别（默认只对 debug 采样）
+
+	// 积压告警：待写入日志数（BufferLen）持续超过高水位达到指定时长时，通过 OnError 回调返回 ErrBufferBackedUp（每次积压只通知一次）
+	BufferHighWater         int           `json:"buffer_high_water"`          // 高水位（0 表示不检测）
+	BufferHighWaterDuration time.Duration `json:"buffer_high_water_duration"` // 持续超过高水位多久后告警
+
+	// SpillFile 缓冲区溢出文件：写库受阻导致缓冲区超过 SpillThreshold 时，后续日志以 JSONL 追加到该文件而不是继续占用内存，
+	// 缓冲区有空间时按顺序读回写库；与 Fallback 死信不同，溢出的日志仍会写入数据库。Close 时未读回的日志保留在文件中，下次启动优先写入
+	// 进程异常退出时已读回但尚未写库的一批可能重复写入（可借助 entry_id 去重）；不能与 AuditChain 同时使用，Start 之前的日志不溢出
+	SpillFile      string `json:"spill_file"`
+	SpillThreshold int    `json:"spill_threshold"` // 缓冲区中日志条数达到该值后开始溢出（默认 10 倍 BufferSize）
+
+	// ManualFlush 手动刷新模式：不启动后台刷新协程，日志只在调用 Flush/FlushSync/Close 或缓冲区达到 BufferSize 时写入，
+	// 适用于批处理工具等需要精确控制写入时机的场景；此时 FlushInterval、IdleFlushInterval、ErrorFlushInterval 不生效
+	ManualFlush bool `json:"manual_flush"`
+
+	// DeferStart 延迟启动：NewPostgresqlWriter 只做配置校验和构造，不访问数据库；
+	// 需在数据库就绪后调用 Start 连接、建表并启动后台协程，此前写入的日志保留在缓冲区中
+	DeferStart bool `json:"defer_start"`
+	// IdleFlushInterval 空闲刷新：缓冲区在此时长内没有新日志时刷新，FlushInterval 作为从第一条日志起的最长等待时间；
+	// 缓冲区为空时刷新协程不会被唤醒（0 表示按 FlushInterval 固定间隔刷新）
+	IdleFlushInterval time.Duration `json:"idle_flush_interval"`
+	// ErrorFlushInterval 缓冲区中出现 error/alert/severe/stack 级别日志后，最迟在此时长内刷新（0 表示与其他级别一样按 FlushInterval 刷新）
+	// 各级别共用一个缓冲区：快速刷新会连同已缓冲的低级别日志一起写出；缓冲区达到 BufferSize 时仍立即刷新
+	ErrorFlushInterval      time.Duration `json:"error_flush_interval"`
+	FlushOnError            bool          `json:"flush_on_error"`             // error/alert/severe/stack 级别日志写入时立即同步刷新缓冲区（调用方等待写库完成）
+	MaxConcurrentWrites     int           `json:"max_concurrent_writes"`      // 同时写库的批次数上限（默认 2），达到上限时日志暂留缓冲区，由正在写入的协程接续写出
+	InlineFlushOnSaturation bool          `json:"inline_flush_on_saturation"` // 写库协程已满且缓冲区已满时，由调用方同步写入（背压），而不是继续积压
+	// BlockOnSaturation 写库协程达到 MaxConcurrentWrites 时，Flush（以及缓冲区已满时的 AddEntry）阻塞到有协程写完，而不是让缓冲区继续增长
+	// 等待时长累计到 Stats().FlushWaitTotal；OnError/OnFlush 回调在写库协程中执行，回调中不要同步写入同一个 Writer
+	BlockOnSaturation bool `json:"block_on_saturation"`
+	// StrictOrder 所有批次经由同一个写库协程依次写入（MaxConcurrentWrites 视为 1，FlushSync 等待正在写的批次完成后再写），
+	// 保证 id 顺序与提交顺序一致，代价是写库吞吐受单个连接限制；LogSync/LogTx 直接写库，不在保证范围内
+	StrictOrder bool `json:"strict_order"`
+
+	// 写库重试：单条日志写入失败且被判定为临时错误（连接断开、超时、死锁等）时，按指数退避重试；永久错误（约束冲突、SQL 错误等）直接转交 Fallback
+	// 重试在后台写库协程中进行，受单批 WriteTimeout 限制；LogTx/LogSync 不重试
+	MaxRetries      int                  `json:"max_retries"`   // 最大重试次数（0 表示不重试）
+	RetryBackoff    time.Duration        `json:"retry_backoff"` // 首次重试前的等待时间，之后每次翻倍（默认 100ms）
+	RetryClassifier func(err error) bool `json:"-"`             // 判断错误是否值得重试（为空时使用 IsRetryableError）
+	// WriteTimeout 单批写库的超时（默认 30 秒，LogSync 同样适用）；超时后不再逐条尝试，剩余日志整体转交 Fallback 并返回 ErrWriteDeadline
+	WriteTimeout time.Duration `json:"write_timeout"`
+
+	// MultiRowInsert 每批日志按目标表合并为多行 INSERT ... VALUES (...), (...)，减少往返次数
+	// 单条语句的参数数不超过 PostgreSQL 的 65535 上限，超出时自动拆分为多条语句；语句失败时该组日志逐条重写（含重试），只有真正失败的条目转交 Fallback
+	MultiRowInsert bool `json:"multi_row_insert"`
+
+	// SearchPath 构造时（Ping 之后、建表之前）执行 SET search_path TO ...，如 "logging" 或 "logging, public"（schema 名只允许字母、数字和下划线）
+	// 注意 SET 只作用于执行它的会话：使用连接池时后续写入可能落在其他连接上，建议同时在连接池的连接初始化中设置
+	SearchPath string `json:"search_path"`
+
+	// LevelEnum 设置后 level 列使用该名称的 PostgreSQL ENUM 类型（建表前创建并补齐缺失的值），在数据库层保证级别合法
+	// 只影响新建的表；已有表需自行执行 ALTER TABLE ... ALTER COLUMN level TYPE <LevelEnum> USING level::<LevelEnum>
+	LevelEnum       string   `json:"level_enum"`        // 枚举类型名，如 log_level（为空表示使用 VARCHAR(20)）
+	LevelEnumValues []string `json:"level_enum_values"` // 追加的自定义级别（内置：debug、info、slow、stat、warn、error、alert、severe、stack）
+	UnknownLevel    string   `json:"unknown_level"`     // 不在枚举中的级别替换为此值（自动加入枚举；为空表示丢弃并返回 ErrUnknownLevel）
+	// UnknownLevelPolicy 级别不在内置级别和 LevelEnumValues 中时的处理方式：accept（原样写入）、map（替换为 UnknownLevel，默认 info）、
+	// reject（丢弃并通过 OnError 返回 ErrUnknownLevel）、warn（原样写入，每个未知级别通过 OnError 报告一次）
+	// 为空时未启用 LevelEnum 为 accept，启用时按 UnknownLevel 是否为空取 map 或 reject；LevelEnum 只能与 map、reject 搭配
+	UnknownLevelPolicy UnknownLevelPolicy `json:"unknown_level_policy"`
+
+	NotifyChannel string `json:"notify_channel"` // error/severe 日志写入后通过 pg_notify 发送通知的频道（可选）
+
+	// 日志保留：后台定期删除过期日志。带 ttl 字段的日志按 expires_at 过期，其余日志按 Retention 过期
+	Retention         time.Duration `json:"retention"`          // 全局保留时长（0 表示仅按 expires_at 清理）
+	RetentionInterval time.Duration `json:"retention_interval"` // 清理间隔（设置了 Retention 时默认 1 小时；两者都为 0 时不启动清理）
+	MaintenanceDB     DBExecutor    `json:"-"`                  // 执行清理等维护操作的执行器（可选，如低优先级连接池；为空时使用主执行器），其关闭由调用方负责
+
+	// RetentionDropPartitions 日志表按 timestamp 范围分区（如由 pg_partman 管理）时，清理直接 DROP 上界不晚于 Retention 截止时间的分区，
+	// 而不是逐行 DELETE；仍有未到期 expires_at 的分区会保留。需要 MaintenanceDB（或主执行器）实现 DBQuerier，未分区的表照常 DELETE
+	RetentionDropPartitions bool `json:"retention_drop_partitions"`
+
+	// HealthCheckInterval 后台定期 Ping 数据库的间隔（0 表示不检查）
+	// Ping 失败时 IsHealthy 返回 false 并通过 OnError 回调通知，恢复后写入一条 info 日志
+	HealthCheckInterval time.Duration `json:"health_check_interval"`
+
+	// HeartbeatInterval 心跳间隔（0 表示不启用）：每个周期写入一条 stat 级别、log_type 为 system 的 heartbeat 日志，
+	// fields 中汇总自上次心跳以来各级别条数、写入/失败/限流丢弃条数和平均写入延迟，兼作写入器存活信号
+	HeartbeatInterval time.Duration `json:"heartbeat_interval"`
+
+	// log_type 白名单：设置后不在列表中的 log_type 会被替换为 UnknownLogType
+	AllowedLogTypes    []string `json:"allowed_log_types"`     // 允许的 log_type，如 user、system、audit、security（为空表示不校验）
+	UnknownLogType     string   `json:"unknown_log_type"`      // 未知 log_type 的替换值（默认为空）
+	WarnUnknownLogType bool     `json:"warn_unknown_log_type"` // 遇到未知 log_type 时额外写入一条 warn 日志
+
+	DefaultLogType string `json:"default_log_type"` // 未指定 log_type 时使用的默认值，如 system（为空表示保持 NULL；显式传入的 log_type 优先）
+}
+
+// ConsoleConfig 控制台 Writer 配置
+type ConsoleConfig struct {
+	Disabled                bool                       `json:"disabled"`                  // 禁用写入器：所有日志被直接丢弃
+	IDGenerator             func() string              `json:"-"`                         // 日志条目 ID 生成函数（可选），如 NewUUID，输出为 entry_id 字段
+	IncludeGoroutineID      bool                       `json:"include_goroutine_id"`      // 输出写日志的协程 ID（goroutine 字段）
+	CallerFunction          bool                       `json:"caller_function"`           // 调用位置中包含函数名（如 main.handleLogin main.go:42），需额外解析符号
+	BeforeWrite             func(entry *LogEntry) bool `json:"-"`                         // 输出前的钩子（同 PostgresConfig），返回 false 丢弃该条日志
+	EmptyContent            EmptyContentMode           `json:"empty_content"`             // 空内容日志的处理方式（同 PostgresConfig）
+	NilContent              string                     `json:"nil_content"`               // nil 内容的替换文本（同 PostgresConfig）
+	MaxReaderBytes          int64                      `json:"max_reader_bytes"`          // 内容为 io.Reader 时最多读取的字节数（同 PostgresConfig）
+	EmptyContentPlaceholder string                     `json:"empty_content_placeholder"` // placeholder 模式下的替换文本
+	KeepTrailingSpace       bool                       `json:"keep_trailing_space"`       // 保留内容末尾的空白和换行（默认去掉，内容中间的换行始终保留）
+	MinLevel                string                     `json:"min_level"`                 // 最低输出级别（同 PostgresConfig，为空表示全部输出）
+	EscalationRules         []EscalationRule           `json:"escalation_rules"`          // 级别升级规则（同 PostgresConfig），升级为 error 类级别的日志输出到 stderr
+	Pretty                  bool                       `json:"pretty"`                    // 多行模式：首行输出级别、时间和内容，每个字段单独缩进一行并对齐键名（适合本地开发；设置了 Encoder 时忽略）
+	Encoder                 Encoder                    `json:"-"`                         // 输出格式（可选，默认为彩色文本 TextEncoder；内置 JSONEncoder，也可自定义）
+	FieldSeparator          string                     `json:"field_separator"`           // 字段分隔符（默认空格，如 " | "；设置了 Encoder 时忽略）
+	KeyValueDelimiter       string                     `json:"key_value_delimiter"`       // 键值分隔符（默认 =，如 :；设置了 Encoder 时忽略）
+	FieldPrefix             string                     `json:"field_prefix"`              // 键名前缀（默认无，如 @；设置了 Encoder 时忽略）
+	// UnknownLevelPolicy 级别不在内置级别和 CustomLevels 中时的处理方式（同 PostgresConfig，默认 accept）：
+	// map 替换为 UnknownLevel 后按该级别着色和选择 stdout/stderr，reject 直接丢弃，warn 首次遇到时额外输出一条 warn 提示
+	UnknownLevelPolicy UnknownLevelPolicy `json:"unknown_level_policy"`
+	UnknownLevel       string             `json:"unknown_level"` // map 策略的替换级别（默认 info）
+	CustomLevels       []string           `json:"custom_levels"` // 追加的已知级别
 }
 
 // DefaultPostgresConfig 返回默认 Postgresql 配置
 func DefaultPostgresConfig() *PostgresConfig {
 	return &PostgresConfig{
-		TableName:     "logs",
-		BufferSize:    100,
-		FlushInterval: 5 * time.Second,
+		TableName:           "logs",
+		BufferSize:          100,
+		FlushInterval:       5 * time.Second,
+		FieldStorage:        FieldStorageJSONB,
+		PlaceholderStyle:    PlaceholderDollar,
+		TimestampType:       TimestampTypeTZ,
+		MaxConcurrentWrites: defaultMaxConcurrentWrites,
 	}
 }
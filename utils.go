@@ -1,37 +1,298 @@
 package writer
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"reflect"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
-// FormatContent 将任意类型转换为字符串
+// defaultMaxReaderContentSize 从 io.Reader 读取内容的默认最大字节数，超出部分被截断
+const defaultMaxReaderContentSize = 64 * 1024
+
+// contentFormatter 按 Writer 的配置（NilContent、MaxReaderBytes）将日志内容转换为字符串
+type contentFormatter struct {
+	nilContent     string
+	maxReaderBytes int64
+}
+
+// defaultContent FormatContent 使用的默认配置
+var defaultContent = newContentFormatter("", 0)
+
+// newContentFormatter 创建内容格式化器：nilContent 为 nil 内容输出的文本（为空时 nil 按空内容处理，由 EmptyContent 决定去留）；
+// maxReaderBytes 不大于 0 时为 64KB
+func newContentFormatter(nilContent string, maxReaderBytes int64) contentFormatter {
+	if maxReaderBytes <= 0 {
+		maxReaderBytes = defaultMaxReaderContentSize
+	}
+	return contentFormatter{nilContent: nilContent, maxReaderBytes: maxReaderBytes}
+}
+
+// FormatContent 将任意类型转换为字符串，nil 返回空字符串
+// []byte 为合法 UTF-8 时按字符串输出，否则输出 "base64:" 前缀的 Base64 编码；
+// io.Reader 最多读取 64KB；PostgresqlWriter 和 ConsoleWriter 可通过 NilContent、MaxReaderBytes 调整
 func FormatContent(v any) string {
+	return defaultContent.format(v)
+}
+
+// format 按配置将日志内容转换为字符串，规则同 FormatContent
+func (f contentFormatter) format(v any) string {
 	switch val := v.(type) {
+	case nil:
+		return f.nilContent
 	case string:
 		return val
+	case []byte:
+		return formatBytes(val)
 	case error:
 		return val.Error()
 	case fmt.Stringer:
 		return val.String()
+	case io.Reader:
+		return formatReader(val, f.maxReaderBytes)
 	default:
 		return fmt.Sprintf("%v", v)
 	}
 }
 
-// GetCaller 获取调用者信息
+// formatBytes 将字节切片转换为可读字符串
+func formatBytes(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	return "base64:" + base64.StdEncoding.EncodeToString(b)
+}
+
+// formatReader 从 io.Reader 最多读取 limit 字节的内容
+func formatReader(r io.Reader, limit int64) string {
+	if limit <= 0 {
+		return ""
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	truncated := int64(len(data)) > limit
+	if truncated {
+		data = data[:limit]
+		// 避免截断到多字节字符中间导致文本被当作二进制
+		if !utf8.Valid(data) {
+			for i := 1; i < utf8.UTFMax && i < len(data); i++ {
+				if utf8.Valid(data[:len(data)-i]) {
+					data = data[:len(data)-i]
+					break
+				}
+			}
+		}
+	}
+
+	content := formatBytes(data)
+	if truncated {
+		content += "...(truncated)"
+	}
+	if err != nil {
+		content += fmt.Sprintf("...(read error: %v)", err)
+	}
+	return content
+}
+
+// GetCaller 获取调用者信息（file:line），同一调用位置的结果按程序计数器缓存
 func GetCaller(skip int) string {
-	_, file, line, ok := runtime.Caller(skip + 1)
-	if !ok {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return ""
+	}
+	return lookupCaller(pcs[0]).short
+}
+
+// GetCallerDetailed 获取调用者信息，同时包含函数名（如 "main.handleLogin main.go:42"），缓存方式同 GetCaller
+func GetCallerDetailed(skip int) string {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
 		return ""
 	}
+	return lookupCaller(pcs[0]).full
+}
+
+// callerInfo 一个程序计数器解析后的调用位置
+type callerInfo struct {
+	short    string // file:line
+	full     string // 带函数名的形式
+	internal bool   // 该位置（含内联展开的栈帧）全部属于本包或 runtime，externalCaller 需继续向外查找
+}
+
+// callerCache 程序计数器 -> callerInfo。调用位置的数量受代码规模限制，缓存不做淘汰
+var callerCache sync.Map
+
+// lookupCaller 返回程序计数器对应的调用位置：short、full 为最内层栈帧的格式化结果，
+// 内层栈帧属于本包时改用该位置内联展开后第一个包外栈帧；首次解析后缓存，之后同一位置不再符号解析和格式化
+func lookupCaller(pc uintptr) callerInfo {
+	if v, ok := callerCache.Load(pc); ok {
+		return v.(callerInfo)
+	}
+	frames := runtime.CallersFrames([]uintptr{pc})
+	var info callerInfo
+	for first := true; ; first = false {
+		frame, more := frames.Next()
+		if first {
+			info = callerInfo{short: formatCaller(frame, false), full: formatCaller(frame, true), internal: true}
+		}
+		// 在本包启动的协程中（如 MultiWriter 超时模式）栈底为 runtime 帧，不视为调用者
+		if !strings.HasPrefix(frame.Function, packagePrefix) && !strings.HasPrefix(frame.Function, "runtime.") {
+			if !first {
+				info.short, info.full = formatCaller(frame, false), formatCaller(frame, true)
+			}
+			info.internal = false
+			break
+		}
+		if !more {
+			break
+		}
+	}
+	callerCache.Store(pc, info)
+	return info
+}
 
-	// 只保留文件名和行号
+// formatCaller 将栈帧格式化为 file:line，withFunc 为 true 时在前面加上去掉包路径的函数名（pkg.Func）
+func formatCaller(frame runtime.Frame, withFunc bool) string {
+	file := frame.File
 	if idx := strings.LastIndex(file, "/"); idx >= 0 {
 		file = file[idx+1:]
 	}
-	return fmt.Sprintf("%s:%d", file, line)
+	if !withFunc || frame.Function == "" {
+		return fmt.Sprintf("%s:%d", file, frame.Line)
+	}
+	function := frame.Function
+	if idx := strings.LastIndex(function, "/"); idx >= 0 {
+		function = function[idx+1:]
+	}
+	return fmt.Sprintf("%s %s:%d", function, file, frame.Line)
+}
+
+// packagePrefix 本包函数名的前缀，如 "github.com/zhengliu92/pg-log-writter."
+var packagePrefix = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	name := runtime.FuncForPC(pc).Name()
+	slash := strings.LastIndex(name, "/")
+	if dot := strings.Index(name[slash+1:], "."); dot >= 0 {
+		return name[:slash+1+dot+1]
+	}
+	return name
+}()
+
+// externalCaller 返回包外第一个调用者的 file:line（withFunc 为 true 时带函数名），跳过本包内的所有栈帧
+func externalCaller(withFunc bool) string {
+	var pcs [32]uintptr
+	n := runtime.Callers(2, pcs[:])
+	for _, pc := range pcs[:n] {
+		info := lookupCaller(pc)
+		if info.internal {
+			continue
+		}
+		if withFunc {
+			return info.full
+		}
+		return info.short
+	}
+	return ""
+}
+
+// mergeFields 返回 base 与 extra 合并后的新切片，同名字段以 extra 为准并保留首次出现的位置
+// 不修改 base 和 extra，派生 Writer 之间不会共享底层数组
+func mergeFields(base, extra []LogField) []LogField {
+	return appendMergedFields(make([]LogField, 0, len(base)+len(extra)), base, extra)
+}
+
+// appendMergedFields 将 base 与 extra 合并追加到 dst（dst 应为空切片），合并规则同 mergeFields
+func appendMergedFields(dst, base, extra []LogField) []LogField {
+	for _, list := range [][]LogField{base, extra} {
+	next:
+		for _, f := range list {
+			for i := range dst {
+				if dst[i].Key == f.Key {
+					dst[i] = f
+					continue next
+				}
+			}
+			dst = append(dst, f)
+		}
+	}
+	return dst
+}
+
+// fieldSlicePool 复用热路径上的临时字段切片（派生 Writer 合并字段、字段名规范化），调用下游 Log 返回后即归还
+var fieldSlicePool = sync.Pool{
+	New: func() any {
+		s := make([]LogField, 0, 16)
+		return &s
+	},
+}
+
+// maxPooledFields 超过此容量的切片不放回池中，避免偶发的大量字段长期占用内存
+const maxPooledFields = 64
+
+// getFieldSlice 从池中取出一个空的字段切片
+func getFieldSlice() *[]LogField {
+	return fieldSlicePool.Get().(*[]LogField)
+}
+
+// putFieldSlice 清空字段切片（释放对字段值的引用）后放回池中
+func putFieldSlice(p *[]LogField) {
+	if cap(*p) > maxPooledFields {
+		return
+	}
+	clear(*p)
+	*p = (*p)[:0]
+	fieldSlicePool.Put(p)
+}
+
+// applyBeforeWrite 执行 BeforeWrite 钩子，返回 false 表示丢弃该条日志
+// 钩子拿到的 Fields 是副本：同一条目可能经 MultiWriter 交给多个 Writer，原地修改不会互相影响
+func applyBeforeWrite(hook func(entry *LogEntry) bool, entry *LogEntry) bool {
+	if hook == nil {
+		return true
+	}
+	if entry.Fields != nil {
+		entry.Fields = maps.Clone(entry.Fields)
+	}
+	return hook(entry)
+}
+
+// hasField 判断字段列表中是否包含指定键
+func hasField(fields []LogField, key string) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// goroutineID 从 runtime.Stack 的首行（"goroutine 123 [running]:"）解析当前协程 ID，失败时返回 0
+// 需要获取调用栈，开销较大，仅在开启 IncludeGoroutineID 时调用
+func goroutineID() int64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	s := strings.TrimPrefix(string(buf[:n]), "goroutine ")
+	if idx := strings.IndexByte(s, ' '); idx > 0 {
+		s = s[:idx]
+	}
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
 }
 
 // ConvertFields 将 FieldAccessor 切片转换为 map
@@ -44,7 +305,7 @@ func ConvertFields(fields []FieldAccessor) map[string]interface{} {
 	for _, field := range fields {
 		key := field.GetKey()
 		// 跳过特殊字段
-		if key == "trace" || key == "span" || key == "duration" || key == "log_type" || key == "logType" || key == "user_id" || key == "userId" || key == "username" || key == "userName" {
+		if isSpecialKey(key) {
 			continue
 		}
 		result[key] = field.GetValue()
@@ -75,28 +336,561 @@ func ExtractFields(fields []FieldAccessor) (trace, span, duration, logType strin
 	return
 }
 
-// extractFields 从 LogField 切片中提取特殊字段
-func extractFields(fields []LogField) (trace, span, duration, logType string, userID *int64, username string) {
+// errorChain 沿 errors.Unwrap 展开错误链，记录每一层的类型和消息
+func errorChain(err error) []map[string]string {
+	var chain []map[string]string
+	for err != nil {
+		chain = append(chain, map[string]string{
+			"type":    fmt.Sprintf("%T", err),
+			"message": err.Error(),
+		})
+		err = errors.Unwrap(err)
+	}
+	return chain
+}
+
+// fieldError 返回 WithError 附加的错误，没有时返回 nil
+func fieldError(fields []LogField) error {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == ErrorKey {
+			err, _ := fields[i].Value.(error)
+			return err
+		}
+	}
+	return nil
+}
+
+// parseContentFields 从内容中提取简单的 key=value 片段
+// 只识别以空白分隔、键为标识符（字母、数字、下划线、点、连字符）、值不含引号和等号的片段，避免误解析普通文本
+func parseContentFields(content string) map[string]string {
+	var result map[string]string
+	for _, token := range strings.Fields(content) {
+		idx := strings.IndexByte(token, '=')
+		if idx <= 0 || idx == len(token)-1 {
+			continue
+		}
+		key, value := token[:idx], token[idx+1:]
+		if !isContentFieldKey(key) || strings.ContainsAny(value, "=\"'`") {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]string)
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// isContentFieldKey 判断内容中的键名是否足够“像”字段名
+func isContentFieldKey(key string) bool {
+	for i, r := range key {
+		switch {
+		case r == '_', unicode.IsLetter(r):
+		case unicode.IsDigit(r) || r == '.' || r == '-':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isSpecialKey 判断是否为会被提取到独立列的特殊字段
+func isSpecialKey(key string) bool {
+	switch key {
+	case "trace", "span", "duration", "log_type", "logType", "user_id", "userId", "username", "userName", "ttl", "component":
+		return true
+	default:
+		return false
+	}
+}
+
+// SnakeCaseKey 将字段名转换为 snake_case，如 requestID -> request_id、http.status -> http_status
+// 可作为 PostgresConfig.FieldKeyFunc 使用
+func SnakeCaseKey(key string) string {
+	runes := []rune(key)
+	var b strings.Builder
+	b.Grow(len(key) + 4)
+
+	lastUnderscore := true
+	for i, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			if i > 0 && !lastUnderscore {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					b.WriteByte('_')
+				}
+			}
+			b.WriteRune(unicode.ToLower(r))
+			lastUnderscore = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			lastUnderscore = false
+		default:
+			if !lastUnderscore {
+				b.WriteByte('_')
+				lastUnderscore = true
+			}
+		}
+	}
+	return strings.TrimRight(b.String(), "_")
+}
+
+// normalizeFieldKeys 使用 fn 规范化字段名并追加到 dst（不修改调用方的切片）
+func normalizeFieldKeys(dst, fields []LogField, fn func(string) string) []LogField {
+	for _, field := range fields {
+		dst = append(dst, LogField{Key: fn(field.Key), Value: field.Value})
+	}
+	return dst
+}
+
+// newLogEntry 根据级别、内容和字段构造日志条目，特殊字段被提取到对应属性
+func newLogEntry(level string, content any, fields []LogField) LogEntry {
+	return newTextLogEntry(level, FormatContent(content), fields)
+}
+
+// newTextLogEntry 与 newLogEntry 相同，内容已格式化为字符串
+func newTextLogEntry(level, text string, fields []LogField) LogEntry {
+	now := time.Now()
+	entry := LogEntry{
+		Timestamp: now.Format(timestampLayout),
+		Level:     level,
+		Content:   text,
+		Fields:    convertLogFields(fields),
+	}
+	applySpecialFields(&entry, fields, now)
+	return entry
+}
+
+// newPlainLogEntry 与 newTextLogEntry 相同，但不提取特殊字段，所有字段都写入 Fields
+func newPlainLogEntry(level, text string, fields []LogField) LogEntry {
+	entry := LogEntry{
+		Timestamp: time.Now().Format(timestampLayout),
+		Level:     level,
+		Content:   text,
+	}
+	if len(fields) > 0 {
+		entry.Fields = make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			entry.Fields[field.Key] = normalizeFieldValue(field.Value)
+		}
+	}
+	return entry
+}
+
+// specialValueOK 判断特殊字段的值能否按对应列的含义解释：user_id 需为数字，ttl 需为时长，其余需为字符串或 fmt.Stringer（包括 time.Duration）
+func specialValueOK(key string, v any) bool {
+	switch key {
+	case "user_id", "userId":
+		_, ok := toInt64(v)
+		return ok
+	case "ttl":
+		_, ok := toDuration(v)
+		return ok
+	}
+	switch v.(type) {
+	case string, fmt.Stringer:
+		return true
+	default:
+		return false
+	}
+}
+
+// applySpecialFields 将 LogField 切片中的特殊字段写入日志条目
+func applySpecialFields(entry *LogEntry, fields []LogField, now time.Time) {
 	for _, field := range fields {
 		value := fmt.Sprintf("%v", field.Value)
 		switch field.Key {
 		case "trace":
-			trace = value
+			entry.Trace = value
 		case "span":
-			span = value
+			entry.Span = value
 		case "duration":
-			duration = value
+			entry.Duration = value
 		case "log_type", "logType":
-			logType = value
+			entry.LogType = value
 		case "user_id", "userId":
 			if id, ok := toInt64(field.Value); ok {
-				userID = &id
+				entry.UserID = &id
 			}
 		case "username", "userName":
-			username = value
+			entry.Username = value
+		case "component":
+			entry.Component = value
+		case "ttl":
+			if ttl, ok := toDuration(field.Value); ok && ttl > 0 {
+				entry.ExpiresAt = now.Add(ttl).Format(timestampLayout)
+			}
 		}
 	}
-	return
+}
+
+// toDuration 尝试将值转换为 time.Duration，字符串按 time.ParseDuration 解析，数字按秒处理
+func toDuration(v any) (time.Duration, bool) {
+	switch val := v.(type) {
+	case time.Duration:
+		return val, true
+	case string:
+		d, err := time.ParseDuration(val)
+		return d, err == nil
+	default:
+		if n, ok := toInt64(v); ok {
+			return time.Duration(n) * time.Second, true
+		}
+		return 0, false
+	}
+}
+
+// parseDurationText 解析 duration 列的文本：time.ParseDuration 格式（允许数字与单位之间有空格），纯数字按毫秒处理
+func parseDurationText(s string) (time.Duration, bool) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+	if s == "" {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, true
+	}
+	if ms, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(ms, 0) && !math.IsNaN(ms) {
+		return time.Duration(math.Round(ms * float64(time.Millisecond))), true
+	}
+	return 0, false
+}
+
+// trimTrailingSpace 去掉 FormatContent 结果末尾的空白和换行（如 fmt.Println 风格的 "msg\n"、读取文件得到的尾部换行），内容中间的换行保留
+func trimTrailingSpace(content string) string {
+	return strings.TrimRightFunc(content, unicode.IsSpace)
+}
+
+// applyEmptyContent 按配置处理空内容，返回 false 表示该条日志应被丢弃
+func applyEmptyContent(content *string, mode EmptyContentMode, placeholder string) bool {
+	if *content != "" {
+		return true
+	}
+	switch mode {
+	case EmptyContentSkip:
+		return false
+	case EmptyContentPlaceholder:
+		if placeholder == "" {
+			placeholder = defaultEmptyContentPlaceholder
+		}
+		*content = placeholder
+	}
+	return true
+}
+
+// flattenFields 将值为 map 的字段展开为以 sep 连接的键，最多展开 maxDepth 层，更深的 map 保持原样
+// 展开得到的键与已有的同名字段冲突时保留已有字段；没有嵌套字段时原样返回
+func flattenFields(fields map[string]interface{}, sep string, maxDepth int) map[string]interface{} {
+	nested := false
+	for _, value := range fields {
+		if m, ok := stringKeyedMap(value); ok && len(m) > 0 {
+			nested = true
+			break
+		}
+	}
+	if !nested {
+		return fields
+	}
+
+	flat := make(map[string]interface{}, len(fields))
+	// 先放入非嵌套字段，保证它们在键名冲突时优先
+	for key, value := range fields {
+		if m, ok := stringKeyedMap(value); !ok || len(m) == 0 {
+			flat[key] = value
+		}
+	}
+	for key, value := range fields {
+		if m, ok := stringKeyedMap(value); ok && len(m) > 0 {
+			flattenInto(flat, key, m, sep, maxDepth-1)
+		}
+	}
+	return flat
+}
+
+// flattenInto 将 m 以 prefix 为前缀展开写入 flat，depth 为剩余可展开的层数
+func flattenInto(flat map[string]interface{}, prefix string, m map[string]interface{}, sep string, depth int) {
+	for key, value := range m {
+		full := prefix + sep + key
+		if child, ok := stringKeyedMap(value); ok && len(child) > 0 && depth > 0 {
+			flattenInto(flat, full, child, sep, depth-1)
+			continue
+		}
+		if _, exists := flat[full]; !exists {
+			flat[full] = value
+		}
+	}
+}
+
+// stringKeyedMap 将键为字符串的 map 转为 map[string]interface{}，其他类型返回 false
+func stringKeyedMap(v interface{}) (map[string]interface{}, bool) {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	m := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		m[iter.Key().String()] = iter.Value().Interface()
+	}
+	return m, true
+}
+
+// capFields 按字节上限截断或丢弃字段（字符串按字节数计算，其他类型按 JSON 文本计算，截断后转为字符串）
+// 字段按名称顺序处理，被处理的字段名记录在 _truncated_fields 中；maxField 为单个字段上限，maxTotal 为全部字段上限（0 表示不限制）；fields 在原 map 上修改
+func capFields(fields map[string]interface{}, maxField, maxTotal int, mode FieldOverflowMode) map[string]interface{} {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var overflowed []string
+	total := 0
+	for _, k := range keys {
+		// 字符串按字节数计算，其他类型按序列化后的 JSON 文本计算
+		s, isString := fields[k].(string)
+		if !isString {
+			data, err := json.Marshal(fields[k])
+			if err != nil {
+				continue
+			}
+			s = string(data)
+		}
+		size := len(s)
+
+		limit := -1
+		if maxField > 0 && size > maxField {
+			limit = maxField
+		}
+		if maxTotal > 0 && total+size > maxTotal {
+			if remaining := maxTotal - total; limit < 0 || remaining < limit {
+				limit = remaining
+			}
+		}
+		if limit < 0 {
+			total += size
+			continue
+		}
+
+		overflowed = append(overflowed, k)
+		if mode == FieldOverflowDrop || limit <= 0 {
+			delete(fields, k)
+			continue
+		}
+		s = truncateUTF8(s, limit)
+		fields[k] = s
+		total += len(s)
+	}
+
+	if len(overflowed) > 0 {
+		fields[fieldOverflowMarker] = overflowed
+	}
+	return fields
+}
+
+// sanitizeString 去除 NUL 字节并将非法 UTF-8 替换为 U+FFFD，合法字符串原样返回
+func sanitizeString(s string) string {
+	if utf8.ValidString(s) && strings.IndexByte(s, 0) < 0 {
+		return s
+	}
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	return strings.ReplaceAll(s, "\x00", "")
+}
+
+// sanitizeValue 递归清理字段值中的字符串，map 和切片会复制后修改，不影响调用方的数据
+func sanitizeValue(v any) any {
+	switch val := v.(type) {
+	case string:
+		return sanitizeString(val)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[sanitizeString(k)] = sanitizeValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = sanitizeValue(item)
+		}
+		return out
+	case []string:
+		out := make([]string, len(val))
+		for i, item := range val {
+			out[i] = sanitizeString(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// sanitizeEntry 清理日志条目中所有字符串列和字段
+func sanitizeEntry(entry LogEntry) LogEntry {
+	entry.Level = sanitizeString(entry.Level)
+	entry.Content = sanitizeString(entry.Content)
+	entry.LogType = sanitizeString(entry.LogType)
+	entry.Duration = sanitizeString(entry.Duration)
+	entry.Trace = sanitizeString(entry.Trace)
+	entry.Span = sanitizeString(entry.Span)
+	entry.Username = sanitizeString(entry.Username)
+	entry.Component = sanitizeString(entry.Component)
+	entry.EntryID = sanitizeString(entry.EntryID)
+	if entry.Fields != nil {
+		entry.Fields = sanitizeValue(entry.Fields).(map[string]interface{})
+	}
+	return entry
+}
+
+// builtinLevels 内置日志级别，按严重程度排列，LevelEnum 以此为枚举的初始值
+var builtinLevels = []string{"debug", "info", "slow", "stat", "warn", "error", "alert", "severe", "stack"}
+
+// levelEnumValues 返回枚举的全部取值：内置级别、自定义级别和 UnknownLevel（去重，保持顺序）
+func levelEnumValues(extra []string, unknown string) ([]string, error) {
+	values := make([]string, 0, len(builtinLevels)+len(extra)+1)
+	seen := make(map[string]struct{}, cap(values))
+	for _, level := range append(append(append([]string{}, builtinLevels...), extra...), unknown) {
+		if level == "" {
+			continue
+		}
+		if len(level) > 63 {
+			return nil, fmt.Errorf("level enum value too long: %q", level)
+		}
+		if _, ok := seen[level]; ok {
+			continue
+		}
+		seen[level] = struct{}{}
+		values = append(values, level)
+	}
+	return values, nil
+}
+
+// levelRanks 日志级别的严重程度，MinLevel 过滤时使用
+var levelRanks = map[string]int{
+	"debug":  0,
+	"info":   1,
+	"slow":   1,
+	"stat":   1,
+	"warn":   2,
+	"error":  3,
+	"alert":  4,
+	"severe": 4,
+	"stack":  4,
+}
+
+// levelRank 返回级别的严重程度，空级别为最低级，未知的自定义级别按 info 处理
+func levelRank(level string) int {
+	if level == "" {
+		return 0
+	}
+	if rank, ok := levelRanks[level]; ok {
+		return rank
+	}
+	return levelRanks["info"]
+}
+
+// isErrorLevel 判断是否为错误级别（error/alert/severe/stack）
+func isErrorLevel(level string) bool {
+	switch level {
+	case "error", "alert", "severe", "stack":
+		return true
+	default:
+		return false
+	}
+}
+
+// truncateRunes 将字符串截断到不超过 n 个字符（与 PostgreSQL VARCHAR(n) 的计数方式一致）
+func truncateRunes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
+
+// truncateUTF8 将字符串截断到不超过 n 字节，且不截断多字节字符
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// parseSearchPath 校验逗号分隔的 schema 列表，返回规范化后的 search_path（空字符串表示不设置）
+func parseSearchPath(s string) (string, error) {
+	if strings.TrimSpace(s) == "" {
+		return "", nil
+	}
+	parts := strings.Split(s, ",")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if !isValidIdentifier(part) {
+			return "", fmt.Errorf("invalid search_path schema: %q", part)
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// quoteLiteral 将字符串转为 SQL 字符串字面量（单引号包裹，内部单引号加倍），仅用于无法参数化的 DDL
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// isValidIdentifier 判断是否为安全的 SQL 标识符（字母或下划线开头，仅包含字母、数字、下划线）
+func isValidIdentifier(s string) bool {
+	if s == "" || len(s) > 63 {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parseTimeOrNil 解析 RFC3339 时间，空字符串或解析失败时返回 nil（写入 NULL）
+func parseTimeOrNil(s string) any {
+	if s == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// nullIfEmpty 空字符串返回 nil，以便写入 NULL
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
 }
 
 // toInt64 尝试将值转换为 int64
@@ -129,17 +923,114 @@ func convertLogFields(fields []LogField) map[string]interface{} {
 		return nil
 	}
 
-	result := make(map[string]interface{})
+	// 先统计普通字段数：全是特殊字段时不分配 map，否则按实际大小一次分配
+	n := 0
+	for _, field := range fields {
+		if !isSpecialKey(field.Key) {
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+
+	result := make(map[string]interface{}, n)
 	for _, field := range fields {
 		// 跳过特殊字段
-		if field.Key == "trace" || field.Key == "span" || field.Key == "duration" || field.Key == "log_type" || field.Key == "logType" || field.Key == "user_id" || field.Key == "userId" || field.Key == "username" || field.Key == "userName" {
+		if isSpecialKey(field.Key) {
 			continue
 		}
-		result[field.Key] = field.Value
+		result[field.Key] = normalizeFieldValue(field.Value)
 	}
+	return result
+}
 
-	if len(result) == 0 {
-		return nil
+// normalizeFieldValue 将字段值转换为可直接序列化为 JSON 的形式，嵌套结构保持为 JSON 对象/数组
+// slog.Value/slog.Attr 展开为其实际值（group 转为 map），error 转为错误消息（否则会被序列化为 {}）
+func normalizeFieldValue(v any) any {
+	switch val := v.(type) {
+	case slog.Value:
+		return slogValue(val)
+	case slog.Attr:
+		return map[string]interface{}{val.Key: slogValue(val.Value)}
+	case []slog.Attr:
+		return slogAttrs(val)
+	case error:
+		return val.Error()
+	default:
+		return v
 	}
-	return result
+}
+
+// slogValue 解析 slog.Value，group 转为 map
+func slogValue(v slog.Value) any {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		return slogAttrs(v.Group())
+	case slog.KindAny:
+		return normalizeFieldValue(v.Any())
+	default:
+		return v.Any()
+	}
+}
+
+// slogAttrs 将一组 slog.Attr 转为 map
+func slogAttrs(attrs []slog.Attr) map[string]interface{} {
+	m := make(map[string]interface{}, len(attrs))
+	for _, a := range attrs {
+		m[a.Key] = slogValue(a.Value)
+	}
+	return m
+}
+
+// formatFieldValue 将字段值格式化为文本：map、切片、结构体等复合值编码为 JSON，其余按 %v 输出
+// 用于控制台输出和 hstore 存储，避免复合值输出为 Go 的 %v 格式
+func formatFieldValue(v any) string {
+	v = normalizeFieldValue(v)
+	switch v.(type) {
+	case nil:
+		return "<nil>"
+	case string, []byte, error, fmt.Stringer:
+		return FormatContent(v)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		if data, err := json.Marshal(v); err == nil {
+			return string(data)
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// formatHstore 将 map 序列化为 hstore 文本格式，如 "a"=>"1", "b"=>NULL
+func formatHstore(fields map[string]interface{}) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v := fields[k]
+		if v == nil {
+			pairs = append(pairs, hstoreQuote(k)+"=>NULL")
+			continue
+		}
+		pairs = append(pairs, hstoreQuote(k)+"=>"+hstoreQuote(formatFieldValue(v)))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// hstoreQuote 为 hstore 键值加双引号并转义
+func hstoreQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
 }
@@ -0,0 +1,198 @@
+package writer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFormatContentUTF8Bytes(t *testing.T) {
+	if got := FormatContent([]byte("héllo 世界")); got != "héllo 世界" {
+		t.Errorf("FormatContent = %q, want the text", got)
+	}
+}
+
+func TestFormatContentBinaryBytes(t *testing.T) {
+	if got := FormatContent([]byte{0xff, 0x00, 0xfe}); got != "base64:/wD+" {
+		t.Errorf("FormatContent = %q, want base64:/wD+", got)
+	}
+}
+
+// endlessReader 永不结束的 reader，记录被读取的字节数
+type endlessReader struct {
+	read int64
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'a'
+	}
+	r.read += int64(len(p))
+	return len(p), nil
+}
+
+func TestFormatContentBoundedReader(t *testing.T) {
+	r := &endlessReader{}
+	got := FormatContent(r)
+	if !strings.HasSuffix(got, "...(truncated)") {
+		t.Fatalf("content does not end with the truncation marker: %q", got[len(got)-20:])
+	}
+	if n := int64(len(strings.TrimSuffix(got, "...(truncated)"))); n != defaultMaxReaderContentSize {
+		t.Errorf("kept %d bytes, want %d", n, defaultMaxReaderContentSize)
+	}
+	// LimitReader 最多请求 limit+1 字节，不会把 reader 读空
+	if r.read > defaultMaxReaderContentSize+512 {
+		t.Errorf("read %d bytes from the reader, want about %d", r.read, defaultMaxReaderContentSize)
+	}
+}
+
+func TestFormatContentShortReader(t *testing.T) {
+	if got := FormatContent(strings.NewReader("small body")); got != "small body" {
+		t.Errorf("FormatContent = %q, want small body", got)
+	}
+}
+
+func TestFormatContentReaderTruncatesOnRuneBoundary(t *testing.T) {
+	// "世" 占 3 字节，4 字节处截断时退回到完整字符
+	if got := formatReader(strings.NewReader("a世界"), 4); got != "a世...(truncated)" {
+		t.Errorf("format = %q", got)
+	}
+}
+
+// failingReader 读取若干字节后返回错误
+type failingReader struct{ done bool }
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, errors.New("connection reset")
+	}
+	r.done = true
+	return copy(p, "partial"), nil
+}
+
+func TestFormatContentReaderError(t *testing.T) {
+	if got := FormatContent(&failingReader{}); got != "partial...(read error: connection reset)" {
+		t.Errorf("FormatContent = %q", got)
+	}
+}
+
+var _ io.Reader = (*failingReader)(nil)
+
+func TestSnakeCaseKey(t *testing.T) {
+	tests := map[string]string{
+		"requestID":  "request_id",
+		"userName":   "user_name",
+		"HTTPStatus": "http_status",
+		"already_ok": "already_ok",
+		"traceId":    "trace_id",
+		"":           "",
+	}
+	for in, want := range tests {
+		if got := SnakeCaseKey(in); got != want {
+			t.Errorf("SnakeCaseKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseContentFields(t *testing.T) {
+	tests := []struct {
+		content string
+		want    map[string]string
+	}{
+		{"user=42 action=login status=ok", map[string]string{"user": "42", "action": "login", "status": "ok"}},
+		{"login ok for user=42, retry later", map[string]string{"user": "42,"}},
+		{"db.pool-size=10 2x=1 =x y= a=b=c q=\"x\"", map[string]string{"db.pool-size": "10"}},
+		{"the plan is simple: a = b", nil},
+		{"no fields here", nil},
+	}
+	for _, tt := range tests {
+		got := parseContentFields(tt.content)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseContentFields(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestFormatContentNil(t *testing.T) {
+	if got := FormatContent(nil); got != "" {
+		t.Errorf("FormatContent(nil) = %q, want empty string", got)
+	}
+}
+
+func TestGetCallerDetailedIncludesFunction(t *testing.T) {
+	got := GetCallerDetailed(0)
+	if !strings.HasPrefix(got, "pg-log-writter.TestGetCallerDetailedIncludesFunction utils_test.go:") {
+		t.Errorf("GetCallerDetailed = %q, want function name before file:line", got)
+	}
+	if plain := GetCaller(0); strings.Contains(plain, " ") || !strings.HasPrefix(plain, "utils_test.go:") {
+		t.Errorf("GetCaller = %q, want file:line only", plain)
+	}
+}
+
+func TestCallerFieldFunctionName(t *testing.T) {
+	// 测试函数属于本包，会被 externalCaller 跳过，调用者为 testing 包中运行测试的函数
+	withFunc := regexp.MustCompile(`^testing\.\S+ testing\.go:\d+$`)
+	plain := regexp.MustCompile(`^testing\.go:\d+$`)
+	for _, tc := range []struct {
+		callerFunction bool
+		want           *regexp.Regexp
+	}{{true, withFunc}, {false, plain}} {
+		db := &mockDB{}
+		w := newTestWriter(t, db, &PostgresConfig{IncludeCaller: true, CallerFunction: tc.callerFunction})
+		w.Info("hello")
+		flushSync(t, w)
+		caller, _ := fieldsOf(t, w, db.inserts()[0])["caller"].(string)
+		if !tc.want.MatchString(caller) {
+			t.Errorf("CallerFunction=%v: caller = %q, want match %s", tc.callerFunction, caller, tc.want)
+		}
+	}
+}
+
+// uncachedCaller 每次都符号解析并格式化的 GetCaller（缓存之前的实现），作为基准测试的对照
+func uncachedCaller(skip int) string {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
+	}
+	if idx := strings.LastIndex(file, "/"); idx >= 0 {
+		file = file[idx+1:]
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+func TestGetCallerCachedMatchesUncached(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		// 两次调用位于同一行，缓存命中后结果不变
+		got, want := GetCaller(0), uncachedCaller(0)
+		if got != want {
+			t.Fatalf("call %d: GetCaller = %q, want %q", i, got, want)
+		}
+	}
+}
+
+// BenchmarkCaller 同一热点调用位置：uncached 每次符号解析和格式化，cached 为按程序计数器缓存的 GetCaller
+func BenchmarkCaller(b *testing.B) {
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = uncachedCaller(0)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = GetCaller(0)
+		}
+	})
+	b.Run("cached-detailed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = GetCallerDetailed(0)
+		}
+	})
+}
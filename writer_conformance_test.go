@@ -0,0 +1,468 @@
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// conformanceRecord 各 Writer 下游收到的日志，统一为便于比较的形式
+type conformanceRecord struct {
+	level     string
+	content   string
+	component string
+	fields    map[string]any
+}
+
+func recordOf(entry LogEntry) conformanceRecord {
+	return conformanceRecord{level: entry.Level, content: entry.Content, component: entry.Component, fields: entry.Fields}
+}
+
+// errorLog 并发安全地收集 OnError 回调的错误
+type errorLog struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (l *errorLog) add(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errs = append(l.errs, err)
+}
+
+func (l *errorLog) all() []error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]error(nil), l.errs...)
+}
+
+// conformanceCase 一个待检查的 Writer：build 返回 Writer 和读取下游记录的函数（Close 之后调用）
+type conformanceCase struct {
+	name string
+	// build 构造 Writer，onError 为其错误回调（不支持回调的 Writer 忽略）
+	build func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord)
+	// discards Writer 丢弃所有日志
+	discards bool
+	// ownsResources Close 释放资源：关闭后写入通过回调报告 ErrWriterClosed，重复 Close 返回 ErrWriterClosed
+	ownsResources bool
+}
+
+func conformanceCases() []conformanceCase {
+	return []conformanceCase{
+		{name: "postgres", ownsResources: true, build: func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord) {
+			db := &mockDB{}
+			w, err := NewPostgresqlWriter(db, &PostgresConfig{TableName: "logs", ManualFlush: true, OnError: onError})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return w, func() []conformanceRecord {
+				var records []conformanceRecord
+				for _, call := range db.inserts() {
+					r := conformanceRecord{
+						level:   argOf(t, w, call, "level").(string),
+						content: argOf(t, w, call, "content").(string),
+					}
+					if component, ok := argOf(t, w, call, "component").(string); ok {
+						r.component = component
+					}
+					json.Unmarshal(argOf(t, w, call, "fields").([]byte), &r.fields)
+					records = append(records, r)
+				}
+				return records
+			}
+		}},
+		{name: "elastic", ownsResources: true, build: func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord) {
+			var (
+				mu      sync.Mutex
+				records []conformanceRecord
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+				mu.Lock()
+				for i := 1; i < len(lines); i += 2 {
+					var entry LogEntry
+					json.Unmarshal([]byte(lines[i]), &entry)
+					records = append(records, recordOf(entry))
+				}
+				mu.Unlock()
+				io.WriteString(w, `{"errors":false,"items":[]}`)
+			}))
+			t.Cleanup(srv.Close)
+			w, err := NewElasticWriter(&ElasticConfig{URL: srv.URL, Index: "logs", FlushInterval: time.Hour, OnError: onError})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return w, func() []conformanceRecord {
+				mu.Lock()
+				defer mu.Unlock()
+				return records
+			}
+		}},
+		{name: "loki", ownsResources: true, build: func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord) {
+			srv, pushes := lokiServer(t)
+			w, err := NewLokiWriter(&LokiConfig{URL: srv.URL, FlushInterval: time.Hour, Serializer: JSONSerializer{}, OnError: onError})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return w, func() []conformanceRecord {
+				var records []conformanceRecord
+				for len(pushes) > 0 {
+					for _, stream := range (<-pushes).Streams {
+						for _, value := range stream.Values {
+							var entry LogEntry
+							json.Unmarshal([]byte(value[1]), &entry)
+							records = append(records, recordOf(entry))
+						}
+					}
+				}
+				return records
+			}
+		}},
+		{name: "otel", ownsResources: true, build: func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord) {
+			exporter := &recordingExporter{}
+			w, err := NewOTelWriter(&OTelConfig{Exporter: exporter, FlushInterval: time.Hour, OnError: onError})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return w, func() []conformanceRecord {
+				var records []conformanceRecord
+				for _, rec := range exporter.all() {
+					r := conformanceRecord{level: rec.SeverityText, content: rec.Body, fields: rec.Attributes}
+					if component, ok := rec.Attributes["component"].(string); ok {
+						r.component = component
+						delete(r.fields, "component")
+					}
+					records = append(records, r)
+				}
+				return records
+			}
+		}},
+		{name: "grpc", ownsResources: true, build: func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord) {
+			stream := &fakeStream{}
+			w, err := NewGRPCWriter(&GRPCConfig{
+				Open:          func(ctx context.Context) (GRPCLogStream, error) { return stream, nil },
+				FlushInterval: time.Hour,
+				OnError:       onError,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return w, func() []conformanceRecord {
+				var records []conformanceRecord
+				for _, entry := range stream.sent {
+					records = append(records, recordOf(entry))
+				}
+				return records
+			}
+		}},
+		{name: "file", ownsResources: true, build: func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			w, err := NewFileWriter(&FileConfig{Path: path, FlushInterval: time.Hour, OnError: onError})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return w, func() []conformanceRecord {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var records []conformanceRecord
+				scanner := bufio.NewScanner(bytes.NewReader(data))
+				for scanner.Scan() {
+					var entry LogEntry
+					json.Unmarshal(scanner.Bytes(), &entry)
+					records = append(records, recordOf(entry))
+				}
+				return records
+			}
+		}},
+		{name: "journal", ownsResources: true, build: func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord) {
+			if runtime.GOOS != "linux" {
+				t.Skip("journald is only supported on Linux")
+			}
+			socket := filepath.Join(t.TempDir(), "journal.sock")
+			conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { conn.Close() })
+			w, err := NewJournalWriter(&JournalConfig{SocketPath: socket, OnError: onError})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return w, func() []conformanceRecord {
+				var records []conformanceRecord
+				buf := make([]byte, 64*1024)
+				for {
+					conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+					n, err := conn.Read(buf)
+					if err != nil {
+						return records
+					}
+					records = append(records, parseJournalRecord(string(buf[:n])))
+				}
+			}
+		}},
+		{name: "console", build: func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord) {
+			output := captureOutput(t)
+			w := NewConsoleWriterWithConfig(&ConsoleConfig{Encoder: JSONEncoder{}})
+			return w, func() []conformanceRecord {
+				var records []conformanceRecord
+				for _, line := range strings.Split(strings.TrimSpace(output()), "\n") {
+					var entry LogEntry
+					json.Unmarshal([]byte(line), &entry)
+					records = append(records, recordOf(entry))
+				}
+				return records
+			}
+		}},
+		{name: "memory", build: func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord) {
+			mem := &memoryWriter{}
+			return mem, memoryRecords(mem)
+		}},
+		{name: "ring", build: func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord) {
+			ring := NewRingWriter(nil)
+			var records []conformanceRecord
+			// Close 会清空保留的日志，在 Flush 时取出
+			return &flushHook{Writer: ring, onFlush: func() {
+				for _, entry := range ring.Recent() {
+					records = append(records, recordOf(entry))
+				}
+			}}, func() []conformanceRecord {
+				return records
+			}
+		}},
+		{name: "multi", build: func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord) {
+			mem := &memoryWriter{}
+			return NewMultiWriterWithConfig(&MultiWriterConfig{OnError: onError}, mem), memoryRecords(mem)
+		}},
+		{name: "multi-timeout", build: func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord) {
+			mem := &memoryWriter{}
+			return NewMultiWriterWithConfig(&MultiWriterConfig{Timeout: time.Second, OnError: onError}, mem), memoryRecords(mem)
+		}},
+		{name: "timeout", build: func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord) {
+			mem := &memoryWriter{}
+			return NewTimeoutWriter(mem, time.Second, onError), memoryRecords(mem)
+		}},
+		{name: "tee", build: func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord) {
+			mem := &memoryWriter{}
+			return NewTeeWriter(mem, 16), memoryRecords(mem)
+		}},
+		{name: "disabled", discards: true, build: func(t *testing.T, onError func(error)) (Writer, func() []conformanceRecord) {
+			return NewDisabledWriter(), func() []conformanceRecord { return nil }
+		}},
+	}
+}
+
+// TestWriterConformance 对每个 Writer 实现运行同一组行为检查：
+// 各写入方法的日志都送达下游，Named 嵌套以点号连接，With 逐级累加字段，AddEntry 原样提交，
+// Flush 不丢日志，Close 后写入不 panic（持有资源的 Writer 报告 ErrWriterClosed）
+func TestWriterConformance(t *testing.T) {
+	for _, tc := range conformanceCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := &errorLog{}
+			w, records := tc.build(t, errs.add)
+
+			w.Info("plain")
+			w.Named("auth").Named("oauth").Warn("named")
+			w.With(Field("request", "r-1")).With(Field("attempt", 2)).Error("with", Field("request", "r-2"))
+			w.Named("auth").With(Field("request", "r-3")).Debugf("scoped %d", 1)
+			w.AddEntry(LogEntry{Timestamp: time.Now().Format(timestampLayout), Level: "info", Content: "raw", Fields: map[string]any{"source": "import"}})
+			w.Flush()
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			for _, err := range errs.all() {
+				t.Errorf("unexpected error before Close: %v", err)
+			}
+
+			got := make(map[string]conformanceRecord)
+			for _, r := range records() {
+				got[r.content] = r
+			}
+			if tc.discards {
+				if len(got) != 0 {
+					t.Errorf("discarding writer delivered %d records", len(got))
+				}
+			} else {
+				checkConformanceRecords(t, got)
+			}
+
+			// 关闭后写入
+			errs = &errorLog{}
+			w, _ = tc.build(t, errs.add)
+			w.Close()
+			w.AddEntry(LogEntry{Level: "info", Content: "after close"})
+			w.Info("after close")
+			second := w.Close()
+			if !tc.ownsResources {
+				return
+			}
+			if closed := errs.all(); len(closed) != 2 || !errors.Is(closed[0], ErrWriterClosed) || !errors.Is(closed[1], ErrWriterClosed) {
+				t.Errorf("errors after Close = %v, want ErrWriterClosed for each write", closed)
+			}
+			if !errors.Is(second, ErrWriterClosed) {
+				t.Errorf("second Close = %v, want ErrWriterClosed", second)
+			}
+		})
+	}
+}
+
+func checkConformanceRecords(t *testing.T, got map[string]conformanceRecord) {
+	t.Helper()
+	want := []struct {
+		content   string
+		level     string
+		component string
+		fields    map[string]any
+	}{
+		{"plain", "info", "", nil},
+		{"named", "warn", "auth.oauth", nil},
+		{"with", "error", "", map[string]any{"request": "r-2", "attempt": 2}},
+		{"scoped 1", "debug", "auth", map[string]any{"request": "r-3"}},
+		{"raw", "info", "", map[string]any{"source": "import"}},
+	}
+	if len(got) != len(want) {
+		t.Errorf("delivered %d records, want %d: %v", len(got), len(want), got)
+	}
+	for _, w := range want {
+		r, ok := got[w.content]
+		if !ok {
+			t.Errorf("record %q not delivered", w.content)
+			continue
+		}
+		if r.level != w.level || r.component != w.component {
+			t.Errorf("record %q: level %q component %q, want %q %q", w.content, r.level, r.component, w.level, w.component)
+		}
+		for key, value := range w.fields {
+			// JSON 解码的数字为 float64，journal 字段均为文本，按文本形式比较
+			if fmt.Sprint(r.fields[key]) != fmt.Sprint(value) {
+				t.Errorf("record %q: field %s = %v (%T), want %v", w.content, key, r.fields[key], r.fields[key], value)
+			}
+		}
+	}
+}
+
+// memoryRecords 返回读取 memoryWriter 记录的函数
+func memoryRecords(mem *memoryWriter) func() []conformanceRecord {
+	return func() []conformanceRecord {
+		var records []conformanceRecord
+		for _, entry := range mem.all() {
+			records = append(records, recordOf(entry))
+		}
+		return records
+	}
+}
+
+// parseJournalRecord 解析 journald 原生协议的单行字段（测试中不含多行值）
+func parseJournalRecord(datagram string) conformanceRecord {
+	var r conformanceRecord
+	for _, line := range strings.Split(strings.TrimSuffix(datagram, "\n"), "\n") {
+		name, value, _ := strings.Cut(line, "=")
+		switch name {
+		case "MESSAGE":
+			r.content = value
+		case "LEVEL":
+			r.level = value
+		case "COMPONENT":
+			r.component = value
+		case "PRIORITY", "SYSLOG_IDENTIFIER":
+		default:
+			if r.fields == nil {
+				r.fields = make(map[string]any)
+			}
+			r.fields[strings.ToLower(name)] = value
+		}
+	}
+	return r
+}
+
+// recordingExporter 记录导出的 OTel 日志记录
+type recordingExporter struct {
+	mu      sync.Mutex
+	records []OTelLogRecord
+}
+
+func (e *recordingExporter) Export(ctx context.Context, records []OTelLogRecord) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.records = append(e.records, records...)
+	return nil
+}
+
+func (e *recordingExporter) all() []OTelLogRecord {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]OTelLogRecord(nil), e.records...)
+}
+
+// flushHook 在 Flush 时额外调用 onFlush 的包装，派生 Writer 仍指向包装本身
+type flushHook struct {
+	Writer
+	onFlush func()
+}
+
+func (f *flushHook) Named(component string) Writer  { return newDerivedWriter(f, component) }
+func (f *flushHook) With(fields ...LogField) Writer { return newFieldsWriter(f, fields) }
+func (f *flushHook) Flush() {
+	f.Writer.Flush()
+	f.onFlush()
+}
+
+// captureOutput 将标准输出和标准错误重定向到管道，返回读取已输出内容的函数（调用后恢复）
+func captureOutput(t *testing.T) func() string {
+	t.Helper()
+	return capturePipe(t, &os.Stdout, &os.Stderr)
+}
+
+// capturePipe 将 files 指向的文件重定向到同一管道，返回读取已输出内容的函数（调用后恢复）
+func capturePipe(t *testing.T, files ...**os.File) func() string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := make([]*os.File, len(files))
+	for i, f := range files {
+		saved[i] = *f
+		*f = w
+	}
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&buf, r)
+		close(done)
+	}()
+
+	var once sync.Once
+	restore := func() {
+		once.Do(func() {
+			for i, f := range files {
+				*f = saved[i]
+			}
+			w.Close()
+			<-done
+			r.Close()
+		})
+	}
+	t.Cleanup(restore)
+	return func() string {
+		restore()
+		return buf.String()
+	}
+}